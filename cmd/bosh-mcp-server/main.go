@@ -35,6 +35,9 @@ func run() error {
 
 	// Create auth provider
 	authProvider := auth.NewProvider("")
+	authProvider.DefaultEnvironment = cfg.DefaultEnvironment
+	authProvider.Strict = cfg.StrictEnvironment
+	authProvider.SetWriteBackTokens(cfg.WriteBackTokens)
 
 	// Create tool registry
 	registry := tools.NewRegistry(authProvider)
@@ -42,16 +45,23 @@ func run() error {
 	// Create deployment registry with confirmation support
 	deploymentRegistry := tools.NewDeploymentRegistry(registry, cfg)
 
-	// Create MCP server
+	// Create MCP server with completions for prompt arguments
 	s := server.NewMCPServer(
 		"bosh-mcp-server",
 		version,
 		server.WithToolCapabilities(true),
+		server.WithToolFilter(registry.ToolFilter),
+		server.WithToolFilter(tools.RedactionToolFilter(cfg.Redaction)),
+		server.WithToolHandlerMiddleware(registry.EnvironmentMiddleware),
+		server.WithToolHandlerMiddleware(tools.RedactionMiddleware(cfg.Redaction)),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(registry.Completer()),
 	)
 
-	// Register tools
+	// Register tools and prompts
 	registry.RegisterTools(s)
 	deploymentRegistry.RegisterDeploymentTools(s)
+	registry.RegisterPrompts(s)
 
 	// Run server with stdio transport
 	return server.ServeStdio(s)
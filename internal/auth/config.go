@@ -1,41 +1,254 @@
-// ABOUTME: Reads BOSH credentials from ~/.bosh/config file.
-// ABOUTME: Supports named environments and defaults to first environment.
+// ABOUTME: Reads BOSH credentials from ~/.bosh/config file, including bosh log-in tokens.
+// ABOUTME: Caches the parsed file until it changes on disk; safe for concurrent use.
 
 package auth
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // ConfigProvider reads credentials from BOSH config file.
 type ConfigProvider struct {
-	Path        string // Path to config file (default: ~/.bosh/config)
-	Environment string // Named environment to use (optional)
+	Path string // Path to config file (default: ~/.bosh/config)
+	// WriteBackTokens saves refreshed bosh log-in tokens to the config
+	// file, as the BOSH CLI does.
+	WriteBackTokens bool
+
+	mu       sync.Mutex
+	cached   *boshConfig
+	modTime  time.Time
+	size     int64
+	sessions map[string]*configSession
+
+	writeMu sync.Mutex // serializes token write-back
 }
 
 type boshConfig struct {
-	Environments map[string]boshEnvironment `yaml:"environments"`
+	Environments boshEnvironments `yaml:"environments"`
 }
 
+// boshEnvironments holds environments by name. The BOSH CLI writes a list
+// of environments identified by alias or URL; a mapping of names to
+// environments is also accepted.
+type boshEnvironments map[string]boshEnvironment
+
 type boshEnvironment struct {
-	URL          string `yaml:"url"`
-	Client       string `yaml:"client"`
-	ClientSecret string `yaml:"client_secret"`
-	CACert       string `yaml:"ca_cert"`
+	URL             string `yaml:"url"`
+	Alias           string `yaml:"alias"`
+	Client          string `yaml:"client"`
+	ClientSecret    string `yaml:"client_secret"`
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	AccessToken     string `yaml:"access_token"`
+	AccessTokenType string `yaml:"access_token_type"`
+	RefreshToken    string `yaml:"refresh_token"`
+	CACert          string `yaml:"ca_cert"`
+}
+
+// UnmarshalYAML accepts the BOSH CLI's list of environments, naming each
+// by its alias or else its URL, as well as a mapping of names.
+func (e *boshEnvironments) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var envs map[string]boshEnvironment
+		if err := node.Decode(&envs); err != nil {
+			return err
+		}
+		*e = envs
+		return nil
+	}
+
+	var list []boshEnvironment
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*e = make(boshEnvironments, len(list))
+	for _, env := range list {
+		name := environmentName(env)
+		if _, exists := (*e)[name]; !exists && name != "" {
+			(*e)[name] = env
+		}
+	}
+	return nil
+}
+
+// environmentName names a listed environment by its alias or else its URL.
+func environmentName(env boshEnvironment) string {
+	if env.Alias != "" {
+		return env.Alias
+	}
+	return env.URL
+}
+
+// configSession is the shared session for an environment and the tokens
+// the config file held when it was created or last written back.
+type configSession struct {
+	session      *Session
+	accessToken  string
+	refreshToken string
+}
+
+// CredentialsFor reads credentials for the environment named name, matched
+// by name or Director URL, or the first environment by name if name is
+// empty. The environment is a parameter rather than provider state so
+// concurrent calls for different environments can't see each other's
+// selection. Returns nil if file doesn't exist or environment not found.
+func (p *ConfigProvider) CredentialsFor(name string) (*Credentials, error) {
+	config, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil || len(config.Environments) == 0 {
+		return nil, nil
+	}
+
+	key, found := config.find(name)
+	if !found {
+		return nil, nil
+	}
+	env := config.Environments[key]
+
+	creds := &Credentials{
+		Environment:  env.URL,
+		Client:       env.Client,
+		ClientSecret: env.ClientSecret,
+		CACert:       env.CACert,
+		Name:         key,
+		Source:       SourceConfig,
+	}
+	switch {
+	case env.Client != "" && env.ClientSecret != "":
+		// Client credentials take precedence over stored user tokens.
+	case env.AccessToken != "" || env.RefreshToken != "":
+		creds.Client = env.Username
+		creds.Session = p.session(key, env)
+	case env.Username != "" && env.Password != "":
+		// Directors using basic authentication store the user's password.
+		creds.Client = env.Username
+		creds.ClientSecret = env.Password
+	}
+
+	if !creds.Valid() {
+		return nil, nil
+	}
+
+	return creds, nil
+}
+
+// session returns the shared session for an environment, replacing it when
+// the config file holds tokens other than the ones the session started
+// with or wrote back, e.g. after the user runs bosh log-in again.
+func (p *ConfigProvider) session(key string, env boshEnvironment) *Session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cs, ok := p.sessions[key]; ok {
+		if cs.accessToken == env.AccessToken && cs.refreshToken == env.RefreshToken {
+			return cs.session
+		}
+		if access, refresh := cs.session.tokens(); access == env.AccessToken && refresh == env.RefreshToken {
+			cs.accessToken, cs.refreshToken = access, refresh
+			return cs.session
+		}
+	}
+
+	session := NewSession(env.AccessTokenType, env.AccessToken, env.RefreshToken)
+	session.onRefresh = func(accessToken, refreshToken string) {
+		if p.WriteBackTokens {
+			// Best effort: the refreshed token is used either way.
+			_ = p.saveTokens(key, accessToken, refreshToken)
+		}
+	}
+
+	if p.sessions == nil {
+		p.sessions = make(map[string]*configSession)
+	}
+	p.sessions[key] = &configSession{session: session, accessToken: env.AccessToken, refreshToken: env.RefreshToken}
+	return session
+}
+
+// find returns the key of the environment named name, aliased name, or
+// whose URL is the same Director as name. An empty name selects the first environment by
+// name, so the choice doesn't depend on map order.
+func (c *boshConfig) find(name string) (string, bool) {
+	keys := make([]string, 0, len(c.Environments))
+	for key := range c.Environments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		return "", false
+	}
+	if name == "" {
+		return keys[0], true
+	}
+	if _, ok := c.Environments[name]; ok {
+		return name, true
+	}
+	for _, key := range keys {
+		if c.Environments[key].Alias == name {
+			return key, true
+		}
+	}
+	for _, key := range keys {
+		if sameDirector(c.Environments[key].URL, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// Environments returns the sorted names of environments in the config file.
+// Returns an empty list if the file doesn't exist.
+func (p *ConfigProvider) Environments() ([]string, error) {
+	config, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return []string{}, nil
+	}
+
+	names := make([]string, 0, len(config.Environments))
+	for name := range config.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
 }
 
-// GetCredentials reads credentials from BOSH config file.
-// Returns nil if file doesn't exist or environment not found.
-func (p *ConfigProvider) GetCredentials() (*Credentials, error) {
-	path := p.Path
+// load returns the parsed config file, re-reading it only when its
+// modification time or size has changed. Returns nil if the file doesn't
+// exist. The returned config is shared and must not be modified.
+func (p *ConfigProvider) load() (*boshConfig, error) {
+	path := p.path()
 	if path == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
+		return nil, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		p.cached = nil
+		if os.IsNotExist(err) {
 			return nil, nil
 		}
-		path = home + "/.bosh/config"
+		return nil, err
+	}
+	if p.cached != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.cached, nil
 	}
 
 	data, err := os.ReadFile(path)
@@ -51,38 +264,126 @@ func (p *ConfigProvider) GetCredentials() (*Credentials, error) {
 		return nil, err
 	}
 
-	if len(config.Environments) == 0 {
-		return nil, nil
+	p.cached = &config
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+
+	return &config, nil
+}
+
+// path returns the config file path, or "" if there is no home directory.
+func (p *ConfigProvider) path() string {
+	if p.Path != "" {
+		return p.Path
 	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return home + "/.bosh/config"
+}
 
-	var env boshEnvironment
-	var found bool
+// saveTokens writes refreshed tokens to the environment named key in the
+// config file, leaving everything else as it is. The file is replaced
+// atomically so concurrent readers never see it half-written.
+func (p *ConfigProvider) saveTokens(key, accessToken, refreshToken string) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
 
-	if p.Environment != "" {
-		env, found = config.Environments[p.Environment]
-	} else {
-		// Use first environment
-		for _, e := range config.Environments {
-			env = e
-			found = true
-			break
-		}
+	path := p.path()
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
 	}
 
-	if !found {
-		return nil, nil
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	env := findEnvironmentNode(&doc, key)
+	if env == nil {
+		return fmt.Errorf("environment %q not found in %s", key, path)
 	}
+	setMappingValue(env, "access_token", accessToken)
+	setMappingValue(env, "refresh_token", refreshToken)
 
-	creds := &Credentials{
-		Environment:  env.URL,
-		Client:       env.Client,
-		ClientSecret: env.ClientSecret,
-		CACert:       env.CACert,
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
 	}
 
-	if !creds.Valid() {
-		return nil, nil
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
 	}
+	return os.Rename(tmp.Name(), path)
+}
 
-	return creds, nil
+// findEnvironmentNode returns the mapping node of the environment named key
+// in a parsed config document, in either the list or the mapping form.
+func findEnvironmentNode(doc *yaml.Node, key string) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	envs := mappingValue(doc.Content[0], "environments")
+	if envs == nil {
+		return nil
+	}
+
+	switch envs.Kind {
+	case yaml.MappingNode:
+		return mappingValue(envs, key)
+	case yaml.SequenceNode:
+		for _, item := range envs.Content {
+			var env boshEnvironment
+			if item.Decode(&env) == nil && environmentName(env) == key {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key to a string value in a mapping node, adding it if missing.
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	if node := mappingValue(mapping, key); node != nil {
+		node.SetString(value)
+		return
+	}
+	keyNode := &yaml.Node{}
+	keyNode.SetString(key)
+	valueNode := &yaml.Node{}
+	valueNode.SetString(value)
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
 }
@@ -4,15 +4,19 @@
 package auth
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfigProvider_Success(t *testing.T) {
 	configPath := filepath.Join("testdata", "bosh-config.yml")
 	provider := &ConfigProvider{Path: configPath}
 
-	creds, err := provider.GetCredentials()
+	creds, err := provider.CredentialsFor("")
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -30,9 +34,9 @@ func TestConfigProvider_Success(t *testing.T) {
 
 func TestConfigProvider_NamedEnvironment(t *testing.T) {
 	configPath := filepath.Join("testdata", "bosh-config.yml")
-	provider := &ConfigProvider{Path: configPath, Environment: "sandbox"}
+	provider := &ConfigProvider{Path: configPath}
 
-	creds, err := provider.GetCredentials()
+	creds, err := provider.CredentialsFor("sandbox")
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -51,7 +55,7 @@ func TestConfigProvider_NamedEnvironment(t *testing.T) {
 func TestConfigProvider_FileNotFound(t *testing.T) {
 	provider := &ConfigProvider{Path: "/nonexistent/path"}
 
-	creds, err := provider.GetCredentials()
+	creds, err := provider.CredentialsFor("")
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -60,3 +64,251 @@ func TestConfigProvider_FileNotFound(t *testing.T) {
 		t.Errorf("expected nil credentials for missing file, got %+v", creds)
 	}
 }
+
+func TestConfigProvider_Environments(t *testing.T) {
+	configPath := filepath.Join("testdata", "bosh-config.yml")
+	provider := &ConfigProvider{Path: configPath}
+
+	names, err := provider.Environments()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("expected 2 environments, got %d", len(names))
+	}
+	if names[0] != "10.0.0.5" || names[1] != "sandbox" {
+		t.Errorf("expected sorted [10.0.0.5 sandbox], got %v", names)
+	}
+}
+
+func TestConfigProvider_EnvironmentsFileNotFound(t *testing.T) {
+	provider := &ConfigProvider{Path: "/nonexistent/path"}
+
+	names, err := provider.Environments()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("expected no environments for missing file, got %v", names)
+	}
+}
+
+func writeConfig(t *testing.T, path, client string, modTime time.Time) {
+	t.Helper()
+	config := "environments:\n  lab:\n    url: https://lab.example.com:25555\n    client: " + client + "\n    client_secret: secret\n"
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigProvider_ReloadsWhenFileChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	modTime := time.Now().Add(-time.Hour)
+	writeConfig(t, path, "first", modTime)
+
+	provider := &ConfigProvider{Path: path}
+	creds, err := provider.CredentialsFor("lab")
+	if err != nil || creds == nil || creds.Client != "first" {
+		t.Fatalf("expected client first, got %+v (err %v)", creds, err)
+	}
+
+	// Same size and modification time: the cached file is used.
+	writeConfig(t, path, "other", modTime)
+	creds, _ = provider.CredentialsFor("lab")
+	if creds.Client != "first" {
+		t.Errorf("expected the cached config, got client %s", creds.Client)
+	}
+
+	writeConfig(t, path, "second", modTime.Add(time.Second))
+	creds, _ = provider.CredentialsFor("lab")
+	if creds.Client != "second" {
+		t.Errorf("expected the changed config to be re-read, got client %s", creds.Client)
+	}
+
+	os.Remove(path)
+	creds, err = provider.CredentialsFor("lab")
+	if err != nil || creds != nil {
+		t.Errorf("expected no credentials after the file is removed, got %+v (err %v)", creds, err)
+	}
+}
+
+func TestConfigProvider_CredentialsForKeepsDefault(t *testing.T) {
+	provider := &ConfigProvider{Path: filepath.Join("testdata", "bosh-config.yml")}
+
+	if _, err := provider.CredentialsFor("sandbox"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	creds, err := provider.CredentialsFor("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Name != "10.0.0.5" {
+		t.Errorf("expected a named lookup not to change the default, got %s", creds.Name)
+	}
+}
+
+func TestConfigProvider_MatchesURL(t *testing.T) {
+	provider := &ConfigProvider{Path: filepath.Join("testdata", "bosh-config.yml")}
+
+	for _, name := range []string{"https://sandbox.example.com:25555", "https://sandbox.example.com:25555/", "sandbox.example.com"} {
+		creds, err := provider.CredentialsFor(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if creds == nil || creds.Name != "sandbox" || creds.Source != SourceConfig {
+			t.Errorf("CredentialsFor(%q): expected sandbox from config, got %+v", name, creds)
+		}
+	}
+
+	if creds, _ := provider.CredentialsFor("https://other.example.com:25555"); creds != nil {
+		t.Errorf("expected no match for an unknown URL, got %+v", creds)
+	}
+}
+
+func TestConfigProvider_FirstEnvironmentIsDeterministic(t *testing.T) {
+	provider := &ConfigProvider{Path: filepath.Join("testdata", "bosh-config.yml")}
+
+	for i := 0; i < 20; i++ {
+		creds, err := provider.CredentialsFor("")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if creds.Name != "10.0.0.5" {
+			t.Fatalf("expected the first environment by name, got %s", creds.Name)
+		}
+	}
+}
+
+// boshCLIConfig is a config file as the BOSH CLI writes it after bosh log-in.
+const boshCLIConfig = `environments:
+- access_token: access-0
+  access_token_type: bearer
+  alias: lab
+  ca_cert: |-
+    -----BEGIN CERTIFICATE-----
+    test-ca-cert
+    -----END CERTIFICATE-----
+  refresh_token: refresh-0
+  url: %s
+  username: admin
+- alias: basic
+  url: https://basic.example.com:25555
+  username: admin
+  password: basic-pass
+- url: https://client.example.com:25555
+  client: ci
+  client_secret: ci-secret
+`
+
+func TestConfigProvider_BoshCLIFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(path, []byte(fmt.Sprintf(boshCLIConfig, "https://lab.example.com:25555")), 0600)
+	provider := &ConfigProvider{Path: path}
+
+	names, err := provider.Environments()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(names, ",") != "basic,https://client.example.com:25555,lab" {
+		t.Errorf("expected environments named by alias or URL, got %v", names)
+	}
+
+	creds, err := provider.CredentialsFor("lab")
+	if err != nil || creds == nil {
+		t.Fatalf("expected lab credentials, got %+v (err %v)", creds, err)
+	}
+	if creds.Session == nil || creds.Session.Authorization() != "bearer access-0" || creds.Client != "admin" {
+		t.Errorf("expected a bosh log-in session for admin, got %+v", creds)
+	}
+	again, _ := provider.CredentialsFor("https://lab.example.com:25555")
+	if again.Session != creds.Session {
+		t.Error("expected the session to be shared between calls")
+	}
+
+	creds, _ = provider.CredentialsFor("basic")
+	if creds.Session != nil || creds.Client != "admin" || creds.ClientSecret != "basic-pass" {
+		t.Errorf("expected basic auth credentials, got %+v", creds)
+	}
+
+	creds, _ = provider.CredentialsFor("client.example.com")
+	if creds.Session != nil || creds.Client != "ci" {
+		t.Errorf("expected client credentials, got %+v", creds)
+	}
+}
+
+func TestConfigProvider_MatchesAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	config := "environments:\n  prod-east:\n    alias: prod\n    url: https://prod.example.com:25555\n    client: admin\n    client_secret: secret\n"
+	os.WriteFile(path, []byte(config), 0600)
+
+	creds, err := (&ConfigProvider{Path: path}).CredentialsFor("prod")
+	if err != nil || creds == nil || creds.Name != "prod-east" {
+		t.Errorf("expected prod-east by alias, got %+v (err %v)", creds, err)
+	}
+}
+
+func TestConfigProvider_WriteBackTokens(t *testing.T) {
+	requests := 0
+	uaa := newFakeUAA(t, &requests)
+	defer uaa.Close()
+
+	for _, writeBack := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "config")
+		original := fmt.Sprintf(boshCLIConfig, "https://lab.example.com:25555")
+		os.WriteFile(path, []byte(original), 0600)
+		provider := &ConfigProvider{Path: path, WriteBackTokens: writeBack}
+
+		creds, _ := provider.CredentialsFor("lab")
+		if err := creds.Session.Refresh(creds.Session.Authorization(), uaa.URL, uaa.Client().Transport); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+
+		if !writeBack {
+			if string(data) != original {
+				t.Errorf("expected the config file untouched without write-back, got:\n%s", data)
+			}
+			continue
+		}
+
+		text := string(data)
+		for _, want := range []string{"access_token: access-2", "refresh_token: refresh-2", "test-ca-cert", "client_secret: ci-secret", "password: basic-pass"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("expected %q in the written config, got:\n%s", want, text)
+			}
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600 to be kept, got %v", info.Mode().Perm())
+		}
+
+		// The re-read file holds the session's own tokens, so it is kept
+		again, _ := provider.CredentialsFor("lab")
+		if again.Session != creds.Session {
+			t.Error("expected the session to survive its own write-back")
+		}
+	}
+}
+
+func TestConfigProvider_NewLoginReplacesSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	config := fmt.Sprintf(boshCLIConfig, "https://lab.example.com:25555")
+	os.WriteFile(path, []byte(config), 0600)
+	provider := &ConfigProvider{Path: path}
+
+	first, _ := provider.CredentialsFor("lab")
+
+	// bosh log-in again stores new tokens
+	config = strings.Replace(config, "access_token: access-0", "access_token: access-9", 1)
+	os.WriteFile(path, []byte(config), 0600)
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+
+	second, _ := provider.CredentialsFor("lab")
+	if second.Session == first.Session || second.Session.Authorization() != "bearer access-9" {
+		t.Errorf("expected a new session with the new token, got %s", second.Session.Authorization())
+	}
+}
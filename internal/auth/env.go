@@ -16,6 +16,10 @@ func (p *EnvProvider) GetCredentials() (*Credentials, error) {
 		Client:       os.Getenv("BOSH_CLIENT"),
 		ClientSecret: os.Getenv("BOSH_CLIENT_SECRET"),
 		CACert:       os.Getenv("BOSH_CA_CERT"),
+		AllProxy:     os.Getenv("BOSH_ALL_PROXY"),
+		Source:       SourceEnv,
+
+		SkipSSLValidation: os.Getenv("BOSH_SKIP_SSL_VALIDATION") == "true",
 	}
 
 	if !creds.Valid() {
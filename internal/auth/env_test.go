@@ -13,6 +13,7 @@ func TestEnvProvider_Success(t *testing.T) {
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret123")
 	t.Setenv("BOSH_CA_CERT", "/path/to/ca.crt")
+	t.Setenv("BOSH_SKIP_SSL_VALIDATION", "")
 
 	provider := &EnvProvider{}
 	creds, err := provider.GetCredentials()
@@ -32,6 +33,15 @@ func TestEnvProvider_Success(t *testing.T) {
 	if creds.CACert != "/path/to/ca.crt" {
 		t.Errorf("expected ca cert /path/to/ca.crt, got %s", creds.CACert)
 	}
+	if creds.SkipSSLValidation {
+		t.Error("expected certificates to be verified without BOSH_SKIP_SSL_VALIDATION")
+	}
+
+	t.Setenv("BOSH_SKIP_SSL_VALIDATION", "true")
+	creds, _ = provider.GetCredentials()
+	if !creds.SkipSSLValidation {
+		t.Error("expected BOSH_SKIP_SSL_VALIDATION=true to skip verification")
+	}
 }
 
 func TestEnvProvider_MissingRequired(t *testing.T) {
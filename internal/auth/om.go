@@ -1,17 +1,31 @@
-// ABOUTME: Fetches BOSH credentials from Ops Manager using om bosh-env.
+// ABOUTME: Fetches BOSH credentials from the Ops Manager API, without the om CLI.
 // ABOUTME: Caches credentials with configurable TTL to avoid repeated calls.
 
 package auth
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/uaa"
 )
 
-// OMProvider fetches credentials from Ops Manager via om CLI.
+// OMProvider fetches the Director's credentials and root CA from Ops Manager.
+//
+// It reads OM_TARGET and either OM_USERNAME/OM_PASSWORD or
+// OM_CLIENT_ID/OM_CLIENT_SECRET, like the om CLI. OM_CA_CERT (path or PEM)
+// verifies Ops Manager's certificate and OM_SKIP_SSL_VALIDATION=true skips
+// verification. When the Director is only reachable through Ops Manager,
+// OM_SSH_PRIVATE_KEY (a path) tunnels Director connections over SSH to the
+// Ops Manager host as OM_SSH_USER (default ubuntu).
 type OMProvider struct {
 	CacheTTL time.Duration // Cache TTL (default: 5 minutes)
 
@@ -20,11 +34,16 @@ type OMProvider struct {
 	cachedAt time.Time
 }
 
+// directorPort is the Director API port, added when Ops Manager reports a
+// bare address.
+const directorPort = "25555"
+
 // GetCredentials fetches BOSH credentials from Ops Manager.
 // Returns nil if OM environment variables are not set.
 func (p *OMProvider) GetCredentials() (*Credentials, error) {
 	// Check if OM credentials are available
-	if os.Getenv("OM_TARGET") == "" {
+	target := os.Getenv("OM_TARGET")
+	if target == "" {
 		return nil, nil
 	}
 
@@ -32,21 +51,40 @@ func (p *OMProvider) GetCredentials() (*Credentials, error) {
 	defer p.mu.Unlock()
 
 	if p.isCacheValid() {
-		return p.cached, nil
+		// Callers get their own copy so none can change another's credentials.
+		cached := *p.cached
+		return &cached, nil
+	}
+
+	client, err := newOMClient(target)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd := exec.Command("om", "bosh-env")
-	output, err := cmd.Output()
+	creds, err := client.directorCredentials()
 	if err != nil {
 		return nil, err
 	}
+	if creds == nil {
+		return nil, fmt.Errorf("ops manager returned incomplete director credentials")
+	}
 
-	creds, err := p.parseOutput(string(output))
+	creds.Source = SourceOpsManager
+	creds.CACert, err = client.rootCA()
 	if err != nil {
 		return nil, err
 	}
 
-	p.cached = creds
+	if key := os.Getenv("OM_SSH_PRIVATE_KEY"); key != "" {
+		user := os.Getenv("OM_SSH_USER")
+		if user == "" {
+			user = "ubuntu"
+		}
+		creds.AllProxy = sshProxyURL(user, client.target.Hostname(), key)
+	}
+
+	cached := *creds
+	p.cached = &cached
 	p.cachedAt = time.Now()
 
 	return creds, nil
@@ -63,12 +101,14 @@ func (p *OMProvider) isCacheValid() bool {
 	return time.Since(p.cachedAt) < ttl
 }
 
+// parseOutput parses KEY=value pairs as printed by om bosh-env or returned
+// by Ops Manager's bosh_commandline_credentials, one per line or separated
+// by spaces.
 func (p *OMProvider) parseOutput(output string) (*Credentials, error) {
 	creds := &Credentials{}
 
-	for _, line := range strings.Split(output, "\n") {
-		line = strings.TrimPrefix(line, "export ")
-		parts := strings.SplitN(line, "=", 2)
+	for _, field := range strings.Fields(output) {
+		parts := strings.SplitN(field, "=", 2)
 		if len(parts) != 2 {
 			continue
 		}
@@ -93,3 +133,141 @@ func (p *OMProvider) parseOutput(output string) (*Credentials, error) {
 
 	return creds, nil
 }
+
+// directorURL turns a bare Director address such as 10.0.0.5 into its API
+// URL. Addresses that already have a scheme are left alone.
+func directorURL(address string) string {
+	if address == "" || strings.Contains(address, "://") {
+		return address
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, directorPort)
+	}
+	return "https://" + address
+}
+
+// sshProxyURL returns a BOSH_ALL_PROXY URL tunneling through host over SSH.
+func sshProxyURL(user, host, privateKey string) string {
+	u := url.URL{
+		Scheme:   "ssh+socks5",
+		User:     url.User(user),
+		Host:     net.JoinHostPort(host, "22"),
+		RawQuery: url.Values{"private-key": {privateKey}}.Encode(),
+	}
+	return u.String()
+}
+
+// omClient calls the Ops Manager API with a UAA token.
+type omClient struct {
+	target     *url.URL
+	httpClient *http.Client
+	tokens     *uaa.Client
+}
+
+func newOMClient(target string) (*omClient, error) {
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	u, err := url.Parse(strings.TrimSuffix(target, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OM_TARGET: %w", err)
+	}
+
+	// Ops Manager usually has a publicly trusted certificate, so without
+	// OM_CA_CERT the system roots are used.
+	transport, err := NewTransport(os.Getenv("OM_CA_CERT"), "", os.Getenv("OM_SKIP_SSL_VALIDATION") == "true")
+	if err != nil {
+		return nil, err
+	}
+
+	uaaURL := u.String() + "/uaa"
+	var tokens *uaa.Client
+	switch {
+	case os.Getenv("OM_USERNAME") != "":
+		tokens = uaa.NewPasswordClient(uaaURL, "opsman", "", os.Getenv("OM_USERNAME"), os.Getenv("OM_PASSWORD"), transport)
+	case os.Getenv("OM_CLIENT_ID") != "":
+		tokens = uaa.NewClient(uaaURL, os.Getenv("OM_CLIENT_ID"), os.Getenv("OM_CLIENT_SECRET"), transport)
+	default:
+		return nil, fmt.Errorf("OM_TARGET is set but neither OM_USERNAME nor OM_CLIENT_ID is")
+	}
+
+	return &omClient{
+		target: u,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+		tokens: tokens,
+	}, nil
+}
+
+// directorCredentials returns the Director's address and client credentials.
+// The CA path Ops Manager reports is on its own VM and is replaced by rootCA.
+func (c *omClient) directorCredentials() (*Credentials, error) {
+	var resp struct {
+		Credential string `json:"credential"`
+	}
+	if err := c.get("/api/v0/deployed/director/credentials/bosh_commandline_credentials", &resp); err != nil {
+		return nil, err
+	}
+	creds, err := (&OMProvider{}).parseOutput(resp.Credential)
+	if creds != nil {
+		creds.Environment = directorURL(creds.Environment)
+	}
+	return creds, err
+}
+
+// rootCA returns the PEM of Ops Manager's active certificate authorities,
+// which sign the Director's certificate. Every active CA is trusted so
+// credentials keep working during a CA rotation.
+func (c *omClient) rootCA() (string, error) {
+	var resp struct {
+		CertificateAuthorities []struct {
+			Active  bool   `json:"active"`
+			CertPEM string `json:"cert_pem"`
+		} `json:"certificate_authorities"`
+	}
+	if err := c.get("/api/v0/certificate_authorities", &resp); err != nil {
+		return "", err
+	}
+
+	var pems []string
+	for _, ca := range resp.CertificateAuthorities {
+		if ca.Active && ca.CertPEM != "" {
+			pems = append(pems, strings.TrimSpace(ca.CertPEM))
+		}
+	}
+	if len(pems) == 0 {
+		return "", fmt.Errorf("ops manager has no active certificate authority")
+	}
+	return strings.Join(pems, "\n") + "\n", nil
+}
+
+func (c *omClient) get(path string, v any) error {
+	token, err := c.tokens.Token()
+	if err != nil {
+		return fmt.Errorf("ops manager login failed: %w", err)
+	}
+
+	req, err := http.NewRequest("GET", c.target.String()+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ops manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("ops manager %s returned %d: %s", path, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
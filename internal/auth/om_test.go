@@ -1,9 +1,15 @@
-// ABOUTME: Tests for Ops Manager bosh-env auth provider.
-// ABOUTME: Verifies parsing of om bosh-env output.
+// ABOUTME: Tests for the Ops Manager API auth provider.
+// ABOUTME: Verifies credential parsing, caching, and the API flow against a fake Ops Manager.
 
 package auth
 
 import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -56,3 +62,149 @@ func TestOMProvider_CacheExpiry(t *testing.T) {
 		t.Error("expected cache to be expired")
 	}
 }
+
+// newFakeOpsManager serves Ops Manager's UAA token endpoint and the API
+// endpoints the provider calls, requiring the issued token.
+func newFakeOpsManager(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/uaa/oauth/token" {
+			r.ParseForm()
+			client, _, _ := r.BasicAuth()
+			if client != "opsman" || r.PostForm.Get("username") != "admin" || r.PostForm.Get("password") != "om-pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"unauthorized"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"om-token","expires_in":3600}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer om-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v0/deployed/director/credentials/bosh_commandline_credentials":
+			w.Write([]byte(`{"credential":"BOSH_CLIENT=ops_manager BOSH_CLIENT_SECRET=om-secret-123 BOSH_CA_CERT=/var/tempest/workspaces/default/root_ca_certificate BOSH_ENVIRONMENT=10.0.0.5 bosh "}`))
+		case "/api/v0/certificate_authorities":
+			w.Write([]byte(`{"certificate_authorities":[
+				{"guid":"old","active":false,"cert_pem":"-----BEGIN CERTIFICATE-----\nOLD\n-----END CERTIFICATE-----\n"},
+				{"guid":"new","active":true,"cert_pem":"-----BEGIN CERTIFICATE-----\nNEW\n-----END CERTIFICATE-----\n"}
+			]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	// Rejected certificates are expected in some tests; keep them out of the log.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	return server
+}
+
+func setOpsManagerEnv(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	t.Setenv("OM_TARGET", server.URL)
+	t.Setenv("OM_USERNAME", "admin")
+	t.Setenv("OM_PASSWORD", "om-pass")
+	t.Setenv("OM_CLIENT_ID", "")
+	t.Setenv("OM_CLIENT_SECRET", "")
+	t.Setenv("OM_CA_CERT", string(caCert))
+	t.Setenv("OM_SKIP_SSL_VALIDATION", "")
+	t.Setenv("OM_SSH_PRIVATE_KEY", "")
+	t.Setenv("OM_SSH_USER", "")
+}
+
+func TestOMProvider_GetCredentials(t *testing.T) {
+	server := newFakeOpsManager(t)
+	defer server.Close()
+	setOpsManagerEnv(t, server)
+
+	creds, err := (&OMProvider{}).GetCredentials()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Environment != "https://10.0.0.5:25555" {
+		t.Errorf("expected environment https://10.0.0.5:25555, got %s", creds.Environment)
+	}
+	if creds.Client != "ops_manager" || creds.ClientSecret != "om-secret-123" {
+		t.Errorf("unexpected client credentials: %s/%s", creds.Client, creds.ClientSecret)
+	}
+	if !strings.Contains(creds.CACert, "NEW") || strings.Contains(creds.CACert, "OLD") {
+		t.Errorf("expected only the active CA, got %q", creds.CACert)
+	}
+	if creds.AllProxy != "" {
+		t.Errorf("expected no proxy, got %s", creds.AllProxy)
+	}
+}
+
+func TestOMProvider_GetCredentials_SSHTunnel(t *testing.T) {
+	server := newFakeOpsManager(t)
+	defer server.Close()
+	setOpsManagerEnv(t, server)
+	t.Setenv("OM_SSH_PRIVATE_KEY", "/home/me/.ssh/opsman")
+
+	creds, err := (&OMProvider{}).GetCredentials()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "ssh+socks5://ubuntu@127.0.0.1:22?private-key=%2Fhome%2Fme%2F.ssh%2Fopsman"
+	if creds.AllProxy != want {
+		t.Errorf("expected proxy %s, got %s", want, creds.AllProxy)
+	}
+}
+
+func TestOMProvider_GetCredentials_LoginFailure(t *testing.T) {
+	server := newFakeOpsManager(t)
+	defer server.Close()
+	setOpsManagerEnv(t, server)
+	t.Setenv("OM_PASSWORD", "wrong")
+
+	_, err := (&OMProvider{}).GetCredentials()
+	if err == nil || !strings.Contains(err.Error(), "ops manager login failed") {
+		t.Errorf("expected login failure, got %v", err)
+	}
+}
+
+func TestOMProvider_GetCredentials_UntrustedCertificate(t *testing.T) {
+	server := newFakeOpsManager(t)
+	defer server.Close()
+	setOpsManagerEnv(t, server)
+	t.Setenv("OM_CA_CERT", "")
+
+	if _, err := (&OMProvider{}).GetCredentials(); err == nil {
+		t.Fatal("expected a certificate error without OM_CA_CERT")
+	}
+
+	t.Setenv("OM_SKIP_SSL_VALIDATION", "true")
+	if _, err := (&OMProvider{}).GetCredentials(); err != nil {
+		t.Errorf("expected OM_SKIP_SSL_VALIDATION to skip verification, got %v", err)
+	}
+}
+
+func TestOMProvider_GetCredentials_NoLogin(t *testing.T) {
+	t.Setenv("OM_TARGET", "https://opsman.example.com")
+	t.Setenv("OM_USERNAME", "")
+	t.Setenv("OM_CLIENT_ID", "")
+
+	if _, err := (&OMProvider{}).GetCredentials(); err == nil {
+		t.Error("expected an error without OM_USERNAME or OM_CLIENT_ID")
+	}
+}
+
+func TestDirectorURL(t *testing.T) {
+	tests := map[string]string{
+		"10.0.0.5":               "https://10.0.0.5:25555",
+		"10.0.0.5:443":           "https://10.0.0.5:443",
+		"director.example.com":   "https://director.example.com:25555",
+		"https://10.0.0.5:25555": "https://10.0.0.5:25555",
+	}
+	for in, want := range tests {
+		if got := directorURL(in); got != want {
+			t.Errorf("directorURL(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -1,12 +1,23 @@
 // ABOUTME: Chains auth providers with defined precedence.
-// ABOUTME: Resolves credentials: env vars > config file > om bosh-env.
+// ABOUTME: Resolves credentials: requested environment, then env vars > config file > Ops Manager API.
 
 package auth
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
 
 // Provider chains multiple auth providers with precedence.
 type Provider struct {
+	// DefaultEnvironment is the config file environment used when a call
+	// names none and no BOSH_* environment variables are set (optional).
+	DefaultEnvironment string
+	// Strict makes a DefaultEnvironment that can't be found an error
+	// instead of falling back to the next provider.
+	Strict bool
+
 	env    *EnvProvider
 	config *ConfigProvider
 	om     *OMProvider
@@ -21,9 +32,61 @@ func NewProvider(configPath string) *Provider {
 	}
 }
 
+// SetWriteBackTokens controls whether bosh log-in tokens refreshed by the
+// server are saved to the BOSH config file, as the BOSH CLI does. Call it
+// before resolving credentials.
+func (p *Provider) SetWriteBackTokens(enabled bool) {
+	p.config.WriteBackTokens = enabled
+}
+
 // GetCredentials resolves credentials using provider chain.
-// If environment is specified, it targets that named environment in config.
+//
+// A requested environment is matched by name or Director URL against the
+// BOSH_* environment variables, the config file, and Ops Manager, in that
+// order. If none match it is an error: a call that names a director never
+// runs against a different one. Without a requested environment, env vars
+// are used, then DefaultEnvironment or the first config file environment
+// by name, then Ops Manager. It is safe to call concurrently for different
+// environments.
 func (p *Provider) GetCredentials(environment string) (*Credentials, error) {
+	if environment == "" {
+		return p.defaultCredentials()
+	}
+
+	// 1. Environment variables naming the same director
+	creds, err := p.env.GetCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("env provider: %w", err)
+	}
+	if creds != nil && sameDirector(creds.Environment, environment) {
+		creds.Name = environment
+		return creds, nil
+	}
+
+	// 2. Config file environment by name or URL
+	creds, err = p.config.CredentialsFor(environment)
+	if err != nil {
+		return nil, fmt.Errorf("config provider: %w", err)
+	}
+	if creds != nil {
+		return creds, nil
+	}
+
+	// 3. Ops Manager's director
+	creds, err = p.om.GetCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("om provider: %w", err)
+	}
+	if creds != nil && sameDirector(creds.Environment, environment) {
+		creds.Name = environment
+		return creds, nil
+	}
+
+	return nil, p.notFound(environment)
+}
+
+// defaultCredentials resolves credentials when no environment is requested.
+func (p *Provider) defaultCredentials() (*Credentials, error) {
 	// 1. Try environment variables (highest priority)
 	creds, err := p.env.GetCredentials()
 	if err != nil {
@@ -34,18 +97,18 @@ func (p *Provider) GetCredentials(environment string) (*Credentials, error) {
 	}
 
 	// 2. Try config file
-	if environment != "" {
-		p.config.Environment = environment
-	}
-	creds, err = p.config.GetCredentials()
+	creds, err = p.config.CredentialsFor(p.DefaultEnvironment)
 	if err != nil {
 		return nil, fmt.Errorf("config provider: %w", err)
 	}
 	if creds != nil {
 		return creds, nil
 	}
+	if p.DefaultEnvironment != "" && p.Strict {
+		return nil, fmt.Errorf("default environment: %w", p.notFound(p.DefaultEnvironment))
+	}
 
-	// 3. Try om bosh-env
+	// 3. Try the Ops Manager API
 	creds, err = p.om.GetCredentials()
 	if err != nil {
 		return nil, fmt.Errorf("om provider: %w", err)
@@ -56,3 +119,56 @@ func (p *Provider) GetCredentials(environment string) (*Credentials, error) {
 
 	return nil, fmt.Errorf("no BOSH credentials available")
 }
+
+// notFound returns the error for an environment no provider knows,
+// listing the config file's environments.
+func (p *Provider) notFound(environment string) error {
+	names, _ := p.config.Environments()
+	if len(names) == 0 {
+		return fmt.Errorf("environment %q not found", environment)
+	}
+	return fmt.Errorf("environment %q not found; known environments: %s", environment, strings.Join(names, ", "))
+}
+
+// sameDirector returns true if a and b address the same Director, comparing
+// URLs and bare addresses such as 10.0.0.5 after adding the default scheme
+// and port.
+func sameDirector(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	ua, errA := url.Parse(directorURL(strings.TrimSuffix(a, "/")))
+	ub, errB := url.Parse(directorURL(strings.TrimSuffix(b, "/")))
+	if errA != nil || errB != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// Environments returns the named environments available in the BOSH config file.
+func (p *Provider) Environments() ([]string, error) {
+	names, err := p.config.Environments()
+	if err != nil {
+		return nil, fmt.Errorf("config provider: %w", err)
+	}
+	return names, nil
+}
+
+// NamedCredentials returns credentials for a named environment in the BOSH
+// config file, matched by name or URL, ignoring environment variables and
+// Ops Manager.
+func (p *Provider) NamedCredentials(environment string) (*Credentials, error) {
+	if environment == "" {
+		return nil, fmt.Errorf("environment name is required")
+	}
+
+	creds, err := p.config.CredentialsFor(environment)
+	if err != nil {
+		return nil, fmt.Errorf("config provider: %w", err)
+	}
+	if creds == nil {
+		return nil, p.notFound(environment)
+	}
+
+	return creds, nil
+}
@@ -1,10 +1,12 @@
 // ABOUTME: Tests for the auth provider chain.
-// ABOUTME: Verifies precedence: env vars > config file > om bosh-env.
+// ABOUTME: Verifies precedence: env vars > config file > Ops Manager API.
 
 package auth
 
 import (
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -71,3 +73,163 @@ func TestProvider_NamedEnvironment(t *testing.T) {
 		t.Errorf("expected sandbox-admin, got %s", creds.Client)
 	}
 }
+
+func TestProvider_NamedCredentialsIgnoresEnv(t *testing.T) {
+	t.Setenv("BOSH_ENVIRONMENT", "https://env.example.com:25555")
+	t.Setenv("BOSH_CLIENT", "env-client")
+	t.Setenv("BOSH_CLIENT_SECRET", "env-secret")
+
+	provider := NewProvider(filepath.Join("testdata", "bosh-config.yml"))
+
+	creds, err := provider.NamedCredentials("sandbox")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Client != "sandbox-admin" {
+		t.Errorf("expected sandbox-admin, got %s", creds.Client)
+	}
+
+	if _, err := provider.NamedCredentials("missing"); err == nil {
+		t.Error("expected error for unknown environment")
+	}
+}
+
+// TestProvider_ConcurrentEnvironments resolves different environments from
+// many goroutines at once; run with -race to catch shared selection state.
+func TestProvider_ConcurrentEnvironments(t *testing.T) {
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	t.Setenv("BOSH_CLIENT", "")
+	t.Setenv("BOSH_CLIENT_SECRET", "")
+	t.Setenv("OM_TARGET", "")
+
+	provider := NewProvider(filepath.Join("testdata", "bosh-config.yml"))
+	want := map[string]string{
+		"10.0.0.5": "https://10.0.0.5:25555",
+		"sandbox":  "https://sandbox.example.com:25555",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for environment, url := range want {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				creds, err := provider.GetCredentials(environment)
+				if err != nil {
+					t.Errorf("GetCredentials(%s): %v", environment, err)
+					return
+				}
+				if creds.Environment != url {
+					t.Errorf("GetCredentials(%s) reached %s, want %s", environment, creds.Environment, url)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				creds, err := provider.NamedCredentials(environment)
+				if err != nil {
+					t.Errorf("NamedCredentials(%s): %v", environment, err)
+					return
+				}
+				if creds.Environment != url {
+					t.Errorf("NamedCredentials(%s) reached %s, want %s", environment, creds.Environment, url)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func clearBoshEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	t.Setenv("BOSH_CLIENT", "")
+	t.Setenv("BOSH_CLIENT_SECRET", "")
+	t.Setenv("OM_TARGET", "")
+}
+
+func TestProvider_RequestedEnvironmentBeatsEnv(t *testing.T) {
+	t.Setenv("BOSH_ENVIRONMENT", "https://env.example.com:25555")
+	t.Setenv("BOSH_CLIENT", "env-client")
+	t.Setenv("BOSH_CLIENT_SECRET", "env-secret")
+
+	provider := NewProvider(filepath.Join("testdata", "bosh-config.yml"))
+
+	creds, err := provider.GetCredentials("sandbox")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Client != "sandbox-admin" || creds.Name != "sandbox" || creds.Source != SourceConfig {
+		t.Errorf("expected sandbox from config, got %+v", creds)
+	}
+
+	// Naming the env vars' director selects them
+	creds, err = provider.GetCredentials("env.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Client != "env-client" || creds.Source != SourceEnv {
+		t.Errorf("expected env credentials, got %+v", creds)
+	}
+}
+
+func TestProvider_UnknownEnvironment(t *testing.T) {
+	clearBoshEnv(t)
+	provider := NewProvider(filepath.Join("testdata", "bosh-config.yml"))
+
+	// Never falls back to the default director, strict or not
+	for _, strict := range []bool{false, true} {
+		provider.Strict = strict
+		creds, err := provider.GetCredentials("prod")
+		if err == nil || !strings.Contains(err.Error(), `environment "prod" not found; known environments: 10.0.0.5, sandbox`) {
+			t.Errorf("strict=%v: expected not found error listing environments, got %v, %+v", strict, err, creds)
+		}
+	}
+
+	// Nor to the BOSH_* env vars when they name another director
+	t.Setenv("BOSH_ENVIRONMENT", "https://10.0.0.9:25555")
+	t.Setenv("BOSH_CLIENT", "env-client")
+	t.Setenv("BOSH_CLIENT_SECRET", "env-secret")
+	provider.Strict = false
+	if _, err := provider.GetCredentials("prod"); err == nil {
+		t.Error("expected not found error with BOSH_* env vars set")
+	}
+}
+
+func TestProvider_DefaultEnvironment(t *testing.T) {
+	clearBoshEnv(t)
+	provider := NewProvider(filepath.Join("testdata", "bosh-config.yml"))
+	provider.DefaultEnvironment = "sandbox"
+
+	creds, err := provider.GetCredentials("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Name != "sandbox" {
+		t.Errorf("expected default environment sandbox, got %+v", creds)
+	}
+
+	provider.DefaultEnvironment = "missing"
+	provider.Strict = true
+	if _, err := provider.GetCredentials(""); err == nil || !strings.Contains(err.Error(), "default environment") {
+		t.Errorf("expected missing default environment error, got %v", err)
+	}
+}
+
+func TestSameDirector(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://10.0.0.5:25555", "10.0.0.5", true},
+		{"https://10.0.0.5:25555/", "https://10.0.0.5:25555", true},
+		{"https://Director.example.com:25555", "director.example.com", true},
+		{"https://10.0.0.5:25555", "10.0.0.6", false},
+		{"https://10.0.0.5:443", "10.0.0.5", false},
+		{"https://10.0.0.5:25555", "", false},
+	}
+	for _, tt := range tests {
+		if got := sameDirector(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameDirector(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// ABOUTME: Holds the UAA tokens of a user logged in with bosh log-in and refreshes them.
+// ABOUTME: One session is shared per environment so every client reuses a refreshed token.
+
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/uaa"
+)
+
+// sessionClient is the UAA client the BOSH CLI logs users in with. Its
+// secret is empty.
+const sessionClient = "bosh_cli"
+
+// refreshLeeway is how long before expiry an access token is refreshed.
+const refreshLeeway = 30 * time.Second
+
+// Session holds the access and refresh tokens stored in ~/.bosh/config by
+// bosh log-in. It is safe for concurrent use.
+type Session struct {
+	mu           sync.Mutex
+	tokenType    string
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time // zero if unknown
+	onRefresh    func(accessToken, refreshToken string)
+}
+
+// NewSession creates a session from stored tokens. The access token's
+// expiry is read from its JWT exp claim when present.
+func NewSession(tokenType, accessToken, refreshToken string) *Session {
+	if tokenType == "" {
+		tokenType = "bearer"
+	}
+	return &Session{
+		tokenType:    tokenType,
+		accessToken:  accessToken,
+		refreshToken: refreshToken,
+		expiresAt:    jwtExpiry(accessToken),
+	}
+}
+
+// Authorization returns the Authorization header value for the current
+// access token, e.g. "bearer eyJ...".
+func (s *Session) Authorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorization()
+}
+
+func (s *Session) authorization() string {
+	if s.accessToken == "" {
+		return ""
+	}
+	return s.tokenType + " " + s.accessToken
+}
+
+// CanRefresh returns whether the session has a refresh token.
+func (s *Session) CanRefresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshToken != ""
+}
+
+// NeedsRefresh returns whether the access token is missing or about to
+// expire and can be refreshed.
+func (s *Session) NeedsRefresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refreshToken == "" {
+		return false
+	}
+	return s.accessToken == "" || (!s.expiresAt.IsZero() && time.Now().After(s.expiresAt.Add(-refreshLeeway)))
+}
+
+// Refresh exchanges the refresh token for a new access token through the
+// UAA at uaaURL. rejected is the Authorization value that failed or
+// expired; if another caller has already replaced it, Refresh does
+// nothing, so concurrent failures refresh only once.
+func (s *Session) Refresh(rejected, uaaURL string, transport http.RoundTripper) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.authorization() != rejected {
+		return nil
+	}
+	if s.refreshToken == "" {
+		return fmt.Errorf("access token expired and no refresh token is stored; run bosh log-in")
+	}
+
+	token, err := uaa.Refresh(uaaURL, sessionClient, "", s.refreshToken, transport)
+	if err != nil {
+		return fmt.Errorf("failed to refresh access token; run bosh log-in: %w", err)
+	}
+
+	s.accessToken = token.AccessToken
+	if token.TokenType != "" {
+		s.tokenType = token.TokenType
+	}
+	if token.RefreshToken != "" {
+		s.refreshToken = token.RefreshToken
+	}
+	s.expiresAt = jwtExpiry(token.AccessToken)
+	if s.expiresAt.IsZero() && token.ExpiresIn > 0 {
+		s.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	if s.onRefresh != nil {
+		s.onRefresh(s.accessToken, s.refreshToken)
+	}
+	return nil
+}
+
+// TokenSource returns the session's access tokens for clients that take
+// bearer tokens, such as CredHub, refreshing through the UAA at uaaURL.
+func (s *Session) TokenSource(uaaURL string, transport http.RoundTripper) *SessionTokens {
+	return &SessionTokens{session: s, uaaURL: uaaURL, transport: transport}
+}
+
+// SessionTokens provides a session's access tokens.
+type SessionTokens struct {
+	session   *Session
+	uaaURL    string
+	transport http.RoundTripper
+}
+
+// Token returns the current access token, refreshing it if it is about to expire.
+func (t *SessionTokens) Token() (string, error) {
+	if t.session.NeedsRefresh() {
+		if err := t.session.Refresh(t.session.Authorization(), t.uaaURL, t.transport); err != nil {
+			return "", err
+		}
+	}
+	t.session.mu.Lock()
+	defer t.session.mu.Unlock()
+	if t.session.accessToken == "" {
+		return "", fmt.Errorf("no access token is stored; run bosh log-in")
+	}
+	return t.session.accessToken, nil
+}
+
+// tokens returns the session's current tokens.
+func (s *Session) tokens() (accessToken, refreshToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accessToken, s.refreshToken
+}
+
+// jwtExpiry returns the exp claim of a JWT, without verifying it, or the
+// zero time if the token isn't a JWT.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,100 @@
+// ABOUTME: Tests for bosh log-in sessions and their token refresh.
+// ABOUTME: Uses a fake UAA serving the refresh token grant.
+
+package auth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT expiring at exp.
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJub25lIn0." + payload + ".c2ln"
+}
+
+// newFakeUAA serves the refresh token grant, issuing access-N tokens.
+func newFakeUAA(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/oauth/token" || r.PostForm.Get("grant_type") != "refresh_token" {
+			t.Errorf("unexpected request: %s %v", r.URL.Path, r.PostForm)
+		}
+		mu.Lock()
+		*requests++
+		n := *requests
+		mu.Unlock()
+		fmt.Fprintf(w, `{"access_token":"access-%d","token_type":"bearer","refresh_token":"refresh-%d","expires_in":600}`, n, n)
+	}))
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Unix(1900000000, 0)
+	if got := jwtExpiry(testJWT(exp)); !got.Equal(exp) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if got := jwtExpiry("opaque-token"); !got.IsZero() {
+		t.Errorf("expected no expiry for an opaque token, got %v", got)
+	}
+}
+
+func TestSession_NeedsRefresh(t *testing.T) {
+	if NewSession("", testJWT(time.Now().Add(time.Hour)), "refresh").NeedsRefresh() {
+		t.Error("expected a valid token not to need refreshing")
+	}
+	if !NewSession("", testJWT(time.Now().Add(-time.Minute)), "refresh").NeedsRefresh() {
+		t.Error("expected an expired token to need refreshing")
+	}
+	if NewSession("", testJWT(time.Now().Add(-time.Minute)), "").NeedsRefresh() {
+		t.Error("expected a session without a refresh token not to refresh")
+	}
+	if NewSession("", "opaque", "refresh").NeedsRefresh() {
+		t.Error("expected a token of unknown expiry to be used until rejected")
+	}
+}
+
+func TestSession_Refresh(t *testing.T) {
+	requests := 0
+	server := newFakeUAA(t, &requests)
+	defer server.Close()
+
+	session := NewSession("bearer", "access-0", "refresh-0")
+	var saved []string
+	session.onRefresh = func(accessToken, refreshToken string) {
+		saved = append(saved, accessToken, refreshToken)
+	}
+
+	rejected := session.Authorization()
+	if err := session.Refresh(rejected, server.URL, server.Client().Transport); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if session.Authorization() != "bearer access-1" {
+		t.Errorf("expected refreshed token, got %s", session.Authorization())
+	}
+	if len(saved) != 2 || saved[0] != "access-1" || saved[1] != "refresh-1" {
+		t.Errorf("expected refreshed tokens to be reported, got %v", saved)
+	}
+
+	// A second caller that saw the old token doesn't refresh again
+	if err := session.Refresh(rejected, server.URL, server.Client().Transport); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected one refresh, got %d", requests)
+	}
+}
+
+func TestSession_RefreshWithoutRefreshToken(t *testing.T) {
+	session := NewSession("bearer", "access-0", "")
+	if err := session.Refresh(session.Authorization(), "https://uaa.invalid", http.DefaultTransport); err == nil {
+		t.Error("expected an error without a refresh token")
+	}
+}
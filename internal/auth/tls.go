@@ -0,0 +1,42 @@
+// ABOUTME: Builds TLS configuration for the Director, UAA, and CredHub from a CA certificate.
+// ABOUTME: The CA may be given as a file path or PEM content; without one the system roots are used.
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig returns a TLS configuration trusting caCert, a path or PEM
+// content. Without a CA the system roots are trusted. A CA that can't be
+// read or parsed is an error rather than a reason to stop verifying;
+// certificates go unverified only when skipVerify is set.
+func TLSConfig(caCert string, skipVerify bool) (*tls.Config, error) {
+	if skipVerify {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	if caCert == "" {
+		return &tls.Config{}, nil
+	}
+
+	var pem []byte
+	if strings.HasPrefix(strings.TrimSpace(caCert), "-----BEGIN") {
+		pem = []byte(caCert)
+	} else {
+		var err error
+		pem, err = os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA cert: %w", err)
+		}
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA cert contains no valid PEM certificates")
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
@@ -0,0 +1,83 @@
+// ABOUTME: Tests for building TLS configuration from a CA certificate.
+// ABOUTME: Verifies PEM content, file paths, system roots, and the explicit skip opt-in.
+
+package auth
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	os.WriteFile(caPath, []byte(caPEM), 0600)
+
+	for name, caCert := range map[string]string{"pem": caPEM, "path": caPath} {
+		config, err := TLSConfig(caCert, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if config.RootCAs == nil || config.InsecureSkipVerify {
+			t.Errorf("%s: expected CA to be trusted", name)
+		}
+	}
+
+	config, err := TLSConfig("", false)
+	if err != nil || config.InsecureSkipVerify || config.RootCAs != nil {
+		t.Errorf("expected system roots without a CA, got %+v, %v", config, err)
+	}
+
+	config, err = TLSConfig(caPEM, true)
+	if err != nil || !config.InsecureSkipVerify {
+		t.Errorf("expected unverified TLS when skipping verification, got %+v, %v", config, err)
+	}
+
+	if _, err := TLSConfig(filepath.Join(t.TempDir(), "missing.crt"), false); err == nil {
+		t.Error("expected error for missing CA file")
+	}
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.crt")
+	os.WriteFile(invalidPath, []byte("not a certificate"), 0600)
+	for name, caCert := range map[string]string{"pem": "-----BEGIN CERTIFICATE-----\ngarbage\n-----END CERTIFICATE-----\n", "path": invalidPath} {
+		if _, err := TLSConfig(caCert, false); err == nil || !strings.Contains(err.Error(), "no valid PEM certificates") {
+			t.Errorf("%s: expected error for invalid CA, got %v", name, err)
+		}
+	}
+}
+
+func TestNewTransport_VerifiesDirectorCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	// The test server's certificate isn't signed by a system root.
+	transport, err := NewTransport("", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err == nil {
+		t.Error("expected certificate verification to fail without a CA")
+	}
+
+	transport, err = NewTransport("", "", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected request to succeed when skipping verification: %v", err)
+	}
+	resp.Body.Close()
+}
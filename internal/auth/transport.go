@@ -0,0 +1,187 @@
+// ABOUTME: Builds HTTP transports for the Director, UAA, and CredHub, optionally through an SSH tunnel.
+// ABOUTME: Tunnels use the BOSH CLI's ssh+socks5 proxy URL form and are shared between clients.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// NewTransport returns an HTTP transport trusting caCert, or not verifying
+// certificates if skipVerify is set, and, if allProxy is set, dialing
+// through it. allProxy takes the BOSH CLI's BOSH_ALL_PROXY form:
+// ssh+socks5://user@host:port?private-key=/path/to/key.
+func NewTransport(caCert, allProxy string, skipVerify bool) (*http.Transport, error) {
+	tlsConfig, err := TLSConfig(caCert, skipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	if allProxy == "" {
+		return transport, nil
+	}
+
+	tunnel, err := parseSSHProxy(allProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = tunnel.dial
+	return transport, nil
+}
+
+// sshTunnel dials connections through an SSH server.
+type sshTunnel struct {
+	address string
+	config  *ssh.ClientConfig
+}
+
+func parseSSHProxy(allProxy string) (*sshTunnel, error) {
+	u, err := url.Parse(allProxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", allProxy, err)
+	}
+	if u.Scheme != "ssh+socks5" {
+		return nil, fmt.Errorf("unsupported proxy scheme %q; expected ssh+socks5://user@host:port?private-key=path", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("proxy %q has no SSH user", allProxy)
+	}
+
+	keyPath := u.Query().Get("private-key")
+	if keyPath == "" {
+		return nil, fmt.Errorf("proxy %q has no private-key", allProxy)
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH private key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH private key: %w", err)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	return &sshTunnel{
+		address: address,
+		config: &ssh.ClientConfig{
+			User: u.User.Username(),
+			Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			// Like the BOSH CLI's ssh+socks5 proxy, the jumpbox's host key
+			// is not verified.
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         30 * time.Second,
+		},
+	}, nil
+}
+
+// sshClients holds open SSH connections by user and address, so clients
+// created per tool call share one connection.
+var sshClients = struct {
+	sync.Mutex
+	conns map[string]*sshConn
+}{conns: make(map[string]*sshConn)}
+
+// sshConn is a shared SSH connection. Its lock is held while connecting,
+// so concurrent first dials wait for one connection instead of each
+// opening their own.
+type sshConn struct {
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func (t *sshTunnel) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	key := t.config.User + "@" + t.address
+
+	sshClients.Lock()
+	shared := sshClients.conns[key]
+	if shared == nil {
+		shared = &sshConn{}
+		sshClients.conns[key] = shared
+	}
+	sshClients.Unlock()
+
+	client, err := shared.connect(t)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err == nil || !shared.evictIfDead(client) {
+		return conn, err
+	}
+
+	// The connection dropped; reconnect once.
+	client, err = shared.connect(t)
+	if err != nil {
+		return nil, err
+	}
+	return client.DialContext(ctx, network, addr)
+}
+
+// connect returns the shared client, connecting if there is none.
+func (c *sshConn) connect(t *sshTunnel) (*ssh.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+	client, err := ssh.Dial("tcp", t.address, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH tunnel %s: %w", strings.TrimSuffix(t.address, ":22"), err)
+	}
+	c.client = client
+	return client, nil
+}
+
+// evictIfDead closes and forgets client if the SSH server no longer
+// answers on it, and reports whether it did. A client another caller has
+// already replaced counts as dead. A live client is kept: the failed dial
+// was the target's fault, not the tunnel's.
+func (c *sshConn) evictIfDead(client *ssh.Client) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != client {
+		return true
+	}
+	if sshAlive(client) {
+		return false
+	}
+	client.Close()
+	c.client = nil
+	return true
+}
+
+// sshKeepaliveTimeout is how long the SSH server has to answer a keepalive.
+const sshKeepaliveTimeout = 10 * time.Second
+
+// sshAlive returns whether the SSH server answers a keepalive on client.
+func sshAlive(client *ssh.Client) bool {
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err == nil
+	case <-time.After(sshKeepaliveTimeout):
+		return false
+	}
+}
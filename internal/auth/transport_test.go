@@ -0,0 +1,280 @@
+// ABOUTME: Tests for Director transports, SSH tunnel proxy parsing, and tunnel reuse.
+// ABOUTME: Runs an in-process SSH server that forwards connections to a test Director.
+
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func writeSSHKey(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewTransport_NoProxy(t *testing.T) {
+	transport, err := NewTransport("", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transport.DialContext != nil {
+		t.Error("expected the default dialer without a proxy")
+	}
+}
+
+func TestNewTransport_SSHProxy(t *testing.T) {
+	keyPath := writeSSHKey(t)
+
+	transport, err := NewTransport("", "ssh+socks5://ubuntu@opsman.example.com?private-key="+keyPath, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transport.DialContext == nil {
+		t.Error("expected connections to be dialed through the tunnel")
+	}
+}
+
+func TestNewTransport_InvalidProxy(t *testing.T) {
+	keyPath := writeSSHKey(t)
+
+	tests := map[string]string{
+		"socks5://opsman:1080":                                   "unsupported proxy scheme",
+		"ssh+socks5://opsman:22?private-key=" + keyPath:          "no SSH user",
+		"ssh+socks5://ubuntu@opsman:22":                          "no private-key",
+		"ssh+socks5://ubuntu@opsman:22?private-key=/nonexistent": "failed to read SSH private key",
+	}
+	for proxy, want := range tests {
+		_, err := NewTransport("", proxy, false)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("NewTransport(%q): expected error containing %q, got %v", proxy, want, err)
+		}
+	}
+}
+
+// sshTestServer is an SSH server that forwards direct-tcpip channels.
+type sshTestServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+
+	mu          sync.Mutex
+	connections int
+	conns       []*ssh.ServerConn
+}
+
+func startSSHServer(t *testing.T) *sshTestServer {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) { return nil, nil },
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &sshTestServer{listener: listener, config: config}
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.dropConnections()
+	})
+	return s
+}
+
+func (s *sshTestServer) serve() {
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			conn, channels, requests, err := ssh.NewServerConn(nc, s.config)
+			if err != nil {
+				nc.Close()
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+
+			go ssh.DiscardRequests(requests)
+			for ch := range channels {
+				go forward(ch)
+			}
+		}()
+	}
+}
+
+// forward connects a direct-tcpip channel to its target.
+func forward(ch ssh.NewChannel) {
+	if ch.ChannelType() != "direct-tcpip" {
+		ch.Reject(ssh.UnknownChannelType, "unsupported")
+		return
+	}
+	var target struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(ch.ExtraData(), &target); err != nil {
+		ch.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+	if err != nil {
+		ch.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := ch.Accept()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(channel, upstream)
+		channel.Close()
+	}()
+	io.Copy(upstream, channel)
+	upstream.Close()
+}
+
+// connectionCount returns how many SSH connections the server has accepted.
+func (s *sshTestServer) connectionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// dropConnections closes every open SSH connection, as a restarted jumpbox would.
+func (s *sshTestServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func tunnelTransport(t *testing.T, server *sshTestServer) *http.Transport {
+	t.Helper()
+	proxy := "ssh+socks5://ubuntu@" + server.listener.Addr().String() + "?private-key=" + writeSSHKey(t)
+	transport, err := NewTransport("", proxy, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return transport
+}
+
+func TestSSHTunnel_ConcurrentDialsShareConnection(t *testing.T) {
+	director := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer director.Close()
+	sshServer := startSSHServer(t)
+
+	// Separate transports, like clients created per tool call.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		transport := tunnelTransport(t, sshServer)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := transport.DialContext(context.Background(), "tcp", director.Listener.Addr().String())
+			if err != nil {
+				t.Errorf("dial failed: %v", err)
+				return
+			}
+			conn.Close()
+		}()
+	}
+	wg.Wait()
+
+	if n := sshServer.connectionCount(); n != 1 {
+		t.Errorf("expected concurrent dials to share one SSH connection, got %d", n)
+	}
+}
+
+func TestSSHTunnel_ReconnectsDroppedConnection(t *testing.T) {
+	director := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer director.Close()
+	sshServer := startSSHServer(t)
+	transport := tunnelTransport(t, sshServer)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(director.URL)
+	if err != nil {
+		t.Fatalf("request through tunnel failed: %v", err)
+	}
+	resp.Body.Close()
+
+	sshServer.dropConnections()
+	transport.CloseIdleConnections()
+
+	resp, err = client.Get(director.URL)
+	if err != nil {
+		t.Fatalf("expected the tunnel to reconnect, got %v", err)
+	}
+	resp.Body.Close()
+
+	if n := sshServer.connectionCount(); n != 2 {
+		t.Errorf("expected one reconnect, got %d SSH connections", n)
+	}
+}
+
+func TestSSHTunnel_KeepsLiveConnectionOnTargetError(t *testing.T) {
+	sshServer := startSSHServer(t)
+	transport := tunnelTransport(t, sshServer)
+
+	// A port nothing listens on: the target refuses, the tunnel is fine.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := transport.DialContext(context.Background(), "tcp", closed); err == nil {
+			t.Fatal("expected dial to a closed port to fail")
+		}
+	}
+	if n := sshServer.connectionCount(); n != 1 {
+		t.Errorf("expected the live SSH connection to be kept, got %d connections", n)
+	}
+}
@@ -5,13 +5,32 @@ package auth
 
 // Credentials holds BOSH Director authentication details.
 type Credentials struct {
-	Environment  string // BOSH Director URL
-	Client       string // UAA client name
-	ClientSecret string // UAA client secret
-	CACert       string // CA certificate (path or PEM content)
+	Environment  string   // BOSH Director URL
+	Client       string   // UAA client name
+	ClientSecret string   // UAA client secret
+	CACert       string   // CA certificate (path or PEM content)
+	AllProxy     string   // Tunnel for Director connections, e.g. ssh+socks5://ubuntu@opsman:22?private-key=/path (optional)
+	Session      *Session // Tokens from bosh log-in, used instead of a client secret (optional)
+	Name         string   // Environment name the credentials were resolved for, if any
+	Source       string   // Provider that supplied them: SourceEnv, SourceConfig, or SourceOpsManager
+
+	// SkipSSLValidation turns off certificate verification for the
+	// Director, UAA, and CredHub. It is only set on explicit opt-in.
+	SkipSSLValidation bool
 }
 
-// Valid returns true if minimum required fields are set.
+// Credential sources, reported so callers can tell which director was used.
+const (
+	SourceEnv        = "env"
+	SourceConfig     = "config"
+	SourceOpsManager = "ops_manager"
+)
+
+// Valid returns true if minimum required fields are set: a Director URL
+// and either client credentials or a bosh log-in session.
 func (c *Credentials) Valid() bool {
-	return c.Environment != "" && c.Client != "" && c.ClientSecret != ""
+	if c.Environment == "" {
+		return false
+	}
+	return (c.Client != "" && c.ClientSecret != "") || c.Session != nil
 }
@@ -0,0 +1,109 @@
+// ABOUTME: Authenticates Director requests with client credentials or a bosh log-in session.
+// ABOUTME: Refreshes an expired session token through the Director's UAA and retries once.
+
+package bosh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+)
+
+// authTransport adds credentials to every Director request. With a bosh
+// log-in session it sends the access token, refreshing it before it
+// expires or once after the Director rejects it.
+type authTransport struct {
+	base    http.RoundTripper
+	creds   *auth.Credentials
+	baseURL string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session := t.creds.Session
+	if session == nil {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.creds.Client, t.creds.ClientSecret)
+		return t.base.RoundTrip(req)
+	}
+
+	if session.NeedsRefresh() {
+		if err := t.refresh(session, session.Authorization()); err != nil {
+			return nil, err
+		}
+	}
+
+	authorization := session.Authorization()
+	resp, err := t.send(req, authorization)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !session.CanRefresh() {
+		return resp, err
+	}
+	// A streamed body has been consumed and can't be sent again.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if err := t.refresh(session, authorization); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return t.send(retry, session.Authorization())
+}
+
+func (t *authTransport) send(req *http.Request, authorization string) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", authorization)
+	return t.base.RoundTrip(req)
+}
+
+// refresh refreshes the session through the UAA the Director advertises.
+func (t *authTransport) refresh(session *auth.Session, rejected string) error {
+	uaaURL, err := t.uaaURL()
+	if err != nil {
+		return err
+	}
+	return session.Refresh(rejected, uaaURL, t.base)
+}
+
+// uaaURL reads the Director's UAA URL from its unauthenticated /info endpoint.
+func (t *authTransport) uaaURL() (string, error) {
+	req, err := http.NewRequest("GET", t.baseURL+"/info", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var info Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", err
+	}
+	if info.UserAuthentication.Type != "uaa" || info.UserAuthentication.Options.URL == "" {
+		return "", fmt.Errorf("cannot refresh the access token: the Director uses %q authentication", info.UserAuthentication.Type)
+	}
+	return info.UserAuthentication.Options.URL, nil
+}
@@ -0,0 +1,100 @@
+// ABOUTME: Tests for Director authentication with client credentials and bosh log-in sessions.
+// ABOUTME: Verifies a rejected session token is refreshed through the Director's UAA and retried.
+
+package bosh
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+)
+
+// newSessionDirector serves a Director that only accepts "bearer access-new"
+// and its UAA, which issues that token for refresh-0.
+func newSessionDirector(t *testing.T, refreshes *int32) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			fmt.Fprintf(w, `{"name":"test","user_authentication":{"type":"uaa","options":{"url":%q}}}`, server.URL+"/uaa")
+		case "/uaa/oauth/token":
+			r.ParseForm()
+			if r.PostForm.Get("refresh_token") != "refresh-0" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			atomic.AddInt32(refreshes, 1)
+			w.Write([]byte(`{"access_token":"access-new","token_type":"bearer","refresh_token":"refresh-0","expires_in":600}`))
+		default:
+			if r.Header.Get("Authorization") != "bearer access-new" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"code":600000,"description":"Require one of the scopes"}`))
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			if r.Method == "POST" && string(body) != `{"properties":{}}` {
+				t.Errorf("expected the request body to be resent, got %q", body)
+			}
+			w.Write([]byte(`[{"name":"cf"}]`))
+		}
+	}))
+	return server
+}
+
+func TestClient_SessionRefreshesRejectedToken(t *testing.T) {
+	var refreshes int32
+	server := newSessionDirector(t, &refreshes)
+	defer server.Close()
+
+	client, err := NewClient(&auth.Credentials{
+		Environment: server.URL,
+		CACert:      testCA(server),
+		Session:     auth.NewSession("bearer", "access-old", "refresh-0"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		deployments, err := client.ListDeployments()
+		if err != nil {
+			t.Fatalf("ListDeployments failed: %v", err)
+		}
+		if len(deployments) != 1 || deployments[0].Name != "cf" {
+			t.Errorf("unexpected deployments: %+v", deployments)
+		}
+	}
+	if _, err := client.doRequestWithBody("POST", "/deployments", nil, []byte(`{"properties":{}}`)); err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	if refreshes != 1 {
+		t.Errorf("expected one refresh, got %d", refreshes)
+	}
+}
+
+func TestClient_SessionWithoutRefreshToken(t *testing.T) {
+	var refreshes int32
+	server := newSessionDirector(t, &refreshes)
+	defer server.Close()
+
+	client, _ := NewClient(&auth.Credentials{
+		Environment: server.URL,
+		CACert:      testCA(server),
+		Session:     auth.NewSession("bearer", "access-old", ""),
+	})
+
+	_, err := client.ListDeployments()
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected the Director's 401, got %v", err)
+	}
+	if refreshes != 0 {
+		t.Errorf("expected no refresh, got %d", refreshes)
+	}
+}
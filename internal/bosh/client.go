@@ -4,14 +4,14 @@
 package bosh
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -33,60 +33,109 @@ type TaskFilter struct {
 	Limit      int    // Maximum number of tasks to return
 }
 
+// JobStateOptions specifies how the Director changes job state.
+type JobStateOptions struct {
+	SkipDrain   bool   // Skip drain scripts before stopping jobs
+	Fix         bool   // Recreate unresponsive VMs and ignore their errors (recreate only)
+	Canaries    string // Number or percentage of canary instances, overriding the manifest
+	MaxInFlight string // Number or percentage of instances updated at once, overriding the manifest
+}
+
+// Query returns the request parameters for changing job state to state.
+func (o JobStateOptions) Query(state string) url.Values {
+	query := url.Values{"state": {state}}
+	if o.SkipDrain {
+		query.Set("skip_drain", "true")
+	}
+	if o.Fix {
+		query.Set("fix", "true")
+	}
+	if o.Canaries != "" {
+		query.Set("canaries", o.Canaries)
+	}
+	if o.MaxInFlight != "" {
+		query.Set("max_in_flight", o.MaxInFlight)
+	}
+	return query
+}
+
+// ConfigFilter specifies config list filters.
+type ConfigFilter struct {
+	Type    string // Filter by config type (cloud, runtime, cpi, etc.)
+	Name    string // Filter by config name
+	History bool   // Include previous versions, not just the latest of each name
+	Limit   int    // Maximum number of configs to return when History is set
+}
+
+// APIError is returned when the Director responds with an error status.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound returns true if err is a Director 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 // NewClient creates a new BOSH API client.
 func NewClient(creds *auth.Credentials) (*Client, error) {
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true, // Default for test servers
-	}
-
-	// Load CA cert if provided
-	if creds.CACert != "" {
-		caCertPool := x509.NewCertPool()
-		var caCert []byte
-		var err error
-
-		if strings.HasPrefix(creds.CACert, "-----BEGIN") {
-			caCert = []byte(creds.CACert)
-		} else {
-			caCert, err = os.ReadFile(creds.CACert)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read CA cert: %w", err)
-			}
-		}
-
-		if ok := caCertPool.AppendCertsFromPEM(caCert); ok {
-			tlsConfig = &tls.Config{
-				RootCAs: caCertPool,
-			}
-		}
+	transport, err := auth.NewTransport(creds.CACert, creds.AllProxy, creds.SkipSSLValidation)
+	if err != nil {
+		return nil, err
 	}
 
+	baseURL := strings.TrimSuffix(creds.Environment, "/")
 	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
+		Transport: &authTransport{base: transport, creds: creds, baseURL: baseURL},
+		// Don't follow redirects - task redirects carry the task ID in the Location header
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
 		},
 	}
 
 	return &Client{
-		baseURL:    strings.TrimSuffix(creds.Environment, "/"),
+		baseURL:    baseURL,
 		httpClient: httpClient,
 		creds:      creds,
 	}, nil
 }
 
 func (c *Client) doRequest(method, path string, query url.Values) ([]byte, error) {
+	return c.doRequestWithBody(method, path, query, nil)
+}
+
+// doRequestWithBody performs a synchronous request with a JSON body.
+func (c *Client) doRequestWithBody(method, path string, query url.Values, body []byte) ([]byte, error) {
+	return c.doRequestContext(context.Background(), method, path, query, body)
+}
+
+// doRequestContext performs a request that is abandoned when ctx is done.
+func (c *Client) doRequestContext(ctx context.Context, method, path string, query url.Values, body []byte) ([]byte, error) {
 	u := c.baseURL + path
 	if len(query) > 0 {
 		u += "?" + query.Encode()
 	}
 
-	req, err := http.NewRequest(method, u, nil)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
 	if err != nil {
 		return nil, err
 	}
 
-	req.SetBasicAuth(c.creds.Client, c.creds.ClientSecret)
 	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -94,16 +143,16 @@ func (c *Client) doRequest(method, path string, query url.Values) ([]byte, error
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
-	return body, nil
+	return respBody, nil
 }
 
 // ListVMs returns VMs for a deployment.
@@ -121,10 +170,38 @@ func (c *Client) ListVMs(deployment string) ([]VM, error) {
 	return vms, nil
 }
 
-// ListInstances returns instances with process details for a deployment.
-func (c *Client) ListInstances(deployment string) ([]Instance, error) {
+// taskResultTimeout bounds how long a listing waits for the Director task
+// that gathers agent state.
+const taskResultTimeout = 2 * time.Minute
+
+// ListVMsWithVitals returns VMs for a deployment including CPU, memory,
+// swap, load, and disk vitals. The Director gathers vitals from each agent
+// in a task, so this call waits for that task to finish, until ctx is done
+// or taskResultTimeout passes.
+func (c *Client) ListVMsWithVitals(ctx context.Context, deployment string) ([]VM, error) {
 	query := url.Values{"format": {"full"}}
-	body, err := c.doRequest("GET", "/deployments/"+deployment+"/instances", query)
+	body, err := c.doTaskResultRequest(ctx, "/deployments/"+deployment+"/vms", query)
+	if err != nil {
+		return nil, err
+	}
+
+	full, err := decodeList[fullVM](body)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]VM, len(full))
+	for i, vm := range full {
+		vms[i] = vm.normalize()
+	}
+
+	return vms, nil
+}
+
+// ListInstances returns the instances of a deployment, without process
+// details. The Director answers directly.
+func (c *Client) ListInstances(deployment string) ([]Instance, error) {
+	body, err := c.doRequest("GET", "/deployments/"+deployment+"/instances", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +214,109 @@ func (c *Client) ListInstances(deployment string) ([]Instance, error) {
 	return instances, nil
 }
 
+// ListInstancesWithProcesses returns instances with their process state
+// and processes. The Director asks each agent in a task, so this call waits
+// for that task to finish, until ctx is done or taskResultTimeout passes.
+func (c *Client) ListInstancesWithProcesses(ctx context.Context, deployment string) ([]Instance, error) {
+	query := url.Values{"format": {"full"}}
+	body, err := c.doTaskResultRequest(ctx, "/deployments/"+deployment+"/instances", query)
+	if err != nil {
+		return nil, err
+	}
+
+	full, err := decodeList[fullInstance](body)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, len(full))
+	for i, inst := range full {
+		instances[i] = inst.normalize()
+	}
+
+	return instances, nil
+}
+
+// doTaskResultRequest performs a GET that the Director may answer directly or
+// by redirecting to a task. For a task, it waits for completion and returns
+// the task's result output. Waiting stops when ctx is done or
+// taskResultTimeout passes.
+func (c *Client) doTaskResultRequest(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, taskResultTimeout)
+	defer cancel()
+
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if resp.StatusCode != http.StatusFound {
+		return body, nil
+	}
+
+	var taskID int
+	location := resp.Header.Get("Location")
+	if _, err := fmt.Sscanf(location, "/tasks/%d", &taskID); err != nil {
+		return nil, fmt.Errorf("failed to parse task ID from %s: %w", location, err)
+	}
+
+	task, err := c.waitForTaskContext(ctx, taskID, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if task.State != "done" {
+		return nil, fmt.Errorf("task %d %s: %s", taskID, task.State, task.Result)
+	}
+
+	return c.doRequestContext(ctx, "GET", "/tasks/"+strconv.Itoa(taskID)+"/output", url.Values{"type": {"result"}}, nil)
+}
+
+// decodeList decodes a JSON array or newline-delimited JSON objects, the
+// format of task result output.
+func decodeList[T any](body []byte) ([]T, error) {
+	trimmed := bytes.TrimSpace(body)
+	items := []T{}
+
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	for decoder.More() {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
 // ListTasks returns tasks matching the filter.
 func (c *Client) ListTasks(filter TaskFilter) ([]Task, error) {
 	query := url.Values{}
@@ -192,6 +372,21 @@ func (c *Client) GetTaskOutput(id int, outputType string) (string, error) {
 	return string(body), nil
 }
 
+// GetInfo returns the Director's name, version, CPI, authentication, and features.
+func (c *Client) GetInfo() (*Info, error) {
+	body, err := c.doRequest("GET", "/info", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var info Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
 // ListDeployments returns all deployments.
 func (c *Client) ListDeployments() ([]Deployment, error) {
 	body, err := c.doRequest("GET", "/deployments", nil)
@@ -237,6 +432,23 @@ func (c *Client) ListReleases() ([]Release, error) {
 	return releases, nil
 }
 
+// InspectRelease returns the jobs and packages of a release version,
+// including which stemcells its packages are compiled for.
+func (c *Client) InspectRelease(name, version string) (*ReleaseDetails, error) {
+	path := "/releases/" + url.PathEscape(name) + "/versions/" + url.PathEscape(version)
+	body, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var details ReleaseDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, err
+	}
+
+	return &details, nil
+}
+
 // GetCloudConfig returns the current cloud config.
 func (c *Client) GetCloudConfig() (*CloudConfig, error) {
 	query := url.Values{"type": {"cloud"}, "latest": {"true"}}
@@ -293,6 +505,126 @@ func (c *Client) GetCPIConfig() (*CPIConfig, error) {
 	return &configs[0], nil
 }
 
+// ListConfigs returns configs of every type and name matching filter. Without
+// History, only the latest version of each config is returned.
+func (c *Client) ListConfigs(filter ConfigFilter) ([]Config, error) {
+	query := url.Values{}
+	if filter.Type != "" {
+		query.Set("type", filter.Type)
+	}
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+	query.Set("latest", strconv.FormatBool(!filter.History))
+	if filter.History && filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	body, err := c.doRequest("GET", "/configs", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []Config
+	if err := json.Unmarshal(body, &configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// GetConfig returns a config version by ID.
+func (c *Client) GetConfig(id string) (*Config, error) {
+	body, err := c.doRequest("GET", "/configs/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// DiffConfig compares proposed content with the latest config of the given
+// type and name. Every line of the resulting config is returned, marked
+// added or removed where it changed.
+func (c *Client) DiffConfig(configType, name, content string) ([]ConfigDiffLine, error) {
+	reqBody, err := json.Marshal(map[string]string{
+		"type":    configType,
+		"name":    name,
+		"content": content,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequestWithBody("POST", "/configs/diff", nil, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Diff [][]*string `json:"diff"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	lines := make([]ConfigDiffLine, 0, len(resp.Diff))
+	for _, entry := range resp.Diff {
+		var line ConfigDiffLine
+		if len(entry) > 0 && entry[0] != nil {
+			line.Line = *entry[0]
+		}
+		if len(entry) > 1 && entry[1] != nil {
+			line.Change = *entry[1]
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// UpdateConfig uploads a new version of the config of the given type and name.
+// If expectedLatestID is set, the Director rejects the update when the latest
+// version is no longer that ID.
+func (c *Client) UpdateConfig(configType, name, content, expectedLatestID string) (*Config, error) {
+	fields := map[string]string{
+		"type":    configType,
+		"name":    name,
+		"content": content,
+	}
+	if expectedLatestID != "" {
+		fields["expected_latest_id"] = expectedLatestID
+	}
+	reqBody, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequestWithBody("POST", "/configs", nil, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// DeleteConfig deletes every version of the config of the given type and name.
+func (c *Client) DeleteConfig(configType, name string) error {
+	query := url.Values{"type": {configType}, "name": {name}}
+	_, err := c.doRequest("DELETE", "/configs", query)
+	return err
+}
+
 // ListVariables returns variables for a deployment.
 func (c *Client) ListVariables(deployment string) ([]Variable, error) {
 	body, err := c.doRequest("GET", "/deployments/"+deployment+"/variables", nil)
@@ -334,50 +666,160 @@ func (c *Client) DeleteDeployment(deployment string, force bool) (int, error) {
 	return c.doAsyncRequest("DELETE", path, query)
 }
 
-// ChangeJobState changes the state of a job (start, stop, restart, detach).
-// Job can be empty to target all jobs, or "job_name" or "job_name/index".
-func (c *Client) ChangeJobState(deployment, job, state string) (int, error) {
+// DeleteRelease deletes a release version, or every version of the release
+// if version is empty. Returns the task ID.
+func (c *Client) DeleteRelease(name, version string, force bool) (int, error) {
+	query := url.Values{}
+	if version != "" {
+		query.Set("version", version)
+	}
+	if force {
+		query.Set("force", "true")
+	}
+	return c.doAsyncRequest("DELETE", "/releases/"+url.PathEscape(name), query)
+}
+
+// DeleteStemcell deletes a stemcell version. Returns the task ID.
+func (c *Client) DeleteStemcell(name, version string, force bool) (int, error) {
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	return c.doAsyncRequest("DELETE", "/stemcells/"+url.PathEscape(name)+"/"+url.PathEscape(version), query)
+}
+
+// ChangeJobState changes the state of a job (started, stopped, restart, or
+// detached, which stops and deletes the VMs). Job can be empty to target all
+// jobs, and instance can be an instance index or ID to target one instance.
+func (c *Client) ChangeJobState(deployment, job, instance, state string, opts JobStateOptions) (int, error) {
 	path := "/deployments/" + deployment + "/jobs"
 	if job != "" {
 		path += "/" + job
+		if instance != "" {
+			path += "/" + instance
+		}
 	}
-	query := url.Values{"state": {state}}
-	return c.doAsyncRequest("PUT", path, query)
+	return c.doAsyncRequest("PUT", path, opts.Query(state))
 }
 
 // Recreate recreates VMs for a deployment.
-// Job and index can be empty to target all, or specific job/instance.
-func (c *Client) Recreate(deployment, job, index string) (int, error) {
+// Job and instance can be empty to target all, or a job and an instance
+// index or ID to target one instance.
+func (c *Client) Recreate(deployment, job, instance string, opts JobStateOptions) (int, error) {
 	path := "/deployments/" + deployment
 	if job != "" {
 		path += "/jobs/" + job
-		if index != "" {
-			path += "/" + index
+		if instance != "" {
+			path += "/" + instance
 		}
 	}
-	query := url.Values{"state": {"recreate"}}
-	return c.doAsyncRequest("PUT", path, query)
+	return c.doAsyncRequest("PUT", path, opts.Query("recreate"))
+}
+
+// SetInstanceIgnore sets whether the Director ignores an instance during
+// deploys and other deployment-wide operations. The instance is identified
+// by its instance group and ID.
+func (c *Client) SetInstanceIgnore(deployment, group, id string, ignore bool) error {
+	body, err := json.Marshal(map[string]bool{"ignore": ignore})
+	if err != nil {
+		return err
+	}
+	path := "/deployments/" + deployment + "/instance_groups/" + url.PathEscape(group) + "/" + url.PathEscape(id) + "/ignore"
+	_, err = c.doRequestWithBody("PUT", path, nil, body)
+	return err
+}
+
+// ListOrphanedDisks returns persistent disks orphaned by deleted instances.
+func (c *Client) ListOrphanedDisks() ([]OrphanedDisk, error) {
+	query := url.Values{"orphaned": {"true"}}
+	body, err := c.doRequest("GET", "/disks", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []OrphanedDisk
+	if err := json.Unmarshal(body, &disks); err != nil {
+		return nil, err
+	}
+
+	return disks, nil
+}
+
+// DeleteOrphanedDisk permanently deletes an orphaned persistent disk from the
+// IaaS. Returns the task ID.
+func (c *Client) DeleteOrphanedDisk(diskCID string) (int, error) {
+	return c.doAsyncRequest("DELETE", "/disks/"+url.PathEscape(diskCID), nil)
+}
+
+// AttachDisk attaches a persistent disk to an instance, replacing and orphaning
+// the instance's current persistent disk. The instance must be stopped with
+// --hard first. Returns the task ID.
+func (c *Client) AttachDisk(diskCID, deployment, job, instanceID string) (int, error) {
+	query := url.Values{
+		"deployment":  {deployment},
+		"job":         {job},
+		"instance_id": {instanceID},
+	}
+	return c.doAsyncRequest("PUT", "/disks/"+url.PathEscape(diskCID)+"/attachments", query)
+}
+
+// ListOrphanedVMs returns VMs orphaned by deploys that have not been cleaned up.
+func (c *Client) ListOrphanedVMs() ([]OrphanedVM, error) {
+	body, err := c.doRequest("GET", "/orphaned_vms", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var vms []OrphanedVM
+	if err := json.Unmarshal(body, &vms); err != nil {
+		return nil, err
+	}
+
+	return vms, nil
+}
+
+// Cleanup removes unused releases, stemcells, orphaned disks, and orphaned VMs.
+// With removeAll, all unused resources are removed; otherwise the Director keeps
+// the two most recent unused versions of each release and stemcell and keeps
+// orphaned disks. Returns the task ID.
+func (c *Client) Cleanup(removeAll bool) (int, error) {
+	body, err := json.Marshal(map[string]any{
+		"config": map[string]bool{"remove_all": removeAll},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return c.doAsyncRequestWithBody("POST", "/cleanup", nil, body)
 }
 
 // doAsyncRequest performs a request that returns a task ID in the Location header.
 func (c *Client) doAsyncRequest(method, path string, query url.Values) (int, error) {
+	return c.doAsyncRequestWithBody(method, path, query, nil)
+}
+
+// doAsyncRequestWithBody performs an async request with a JSON body.
+func (c *Client) doAsyncRequestWithBody(method, path string, query url.Values, body []byte) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return c.doAsyncStream(method, path, query, "application/json", reader)
+}
+
+// doAsyncStream performs an async request whose body is read from reader as
+// it is sent, so large uploads are never held in memory.
+func (c *Client) doAsyncStream(method, path string, query url.Values, contentType string, reader io.Reader) (int, error) {
 	u := c.baseURL + path
 	if len(query) > 0 {
 		u += "?" + query.Encode()
 	}
 
-	req, err := http.NewRequest(method, u, nil)
+	req, err := http.NewRequest(method, u, reader)
 	if err != nil {
 		return 0, err
 	}
 
-	req.SetBasicAuth(c.creds.Client, c.creds.ClientSecret)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Don't follow redirects - we need the Location header
-	c.httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-		return http.ErrUseLastResponse
-	}
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -387,7 +829,7 @@ func (c *Client) doAsyncRequest(method, path string, query url.Values) (int, err
 
 	if resp.StatusCode != http.StatusFound && resp.StatusCode != http.StatusAccepted {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return 0, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	location := resp.Header.Get("Location")
@@ -437,3 +879,29 @@ func (c *Client) WaitForTask(taskID int, timeout time.Duration, pollInterval tim
 		time.Sleep(pollInterval)
 	}
 }
+
+// waitForTaskContext polls a task until it reaches a terminal state or ctx
+// is done.
+func (c *Client) waitForTaskContext(ctx context.Context, taskID int, pollInterval time.Duration) (*Task, error) {
+	for {
+		body, err := c.doRequestContext(ctx, "GET", "/tasks/"+strconv.Itoa(taskID), nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task %d: %w", taskID, err)
+		}
+		var task *Task
+		if err := json.Unmarshal(body, &task); err != nil {
+			return nil, fmt.Errorf("failed to get task %d: %w", taskID, err)
+		}
+
+		switch task.State {
+		case "done", "error", "cancelled":
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return task, fmt.Errorf("gave up waiting for task %d (current state: %s): %w", taskID, task.State, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
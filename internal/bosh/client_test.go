@@ -4,15 +4,25 @@
 package bosh
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/malston/bosh-mcp-server/internal/auth"
 )
 
+// testCA returns the PEM of server's certificate, for clients to trust.
+func testCA(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
 func TestClient_ListVMs(t *testing.T) {
 	vms := []VM{
 		{Job: "diego_cell", Index: 0, ProcessState: "running", IPs: []string{"10.0.1.5"}},
@@ -30,6 +40,7 @@ func TestClient_ListVMs(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -69,6 +80,7 @@ func TestClient_ListTasks(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -104,6 +116,7 @@ func TestClient_ListStemcells(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -142,6 +155,7 @@ func TestClient_ListReleases(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -161,6 +175,55 @@ func TestClient_ListReleases(t *testing.T) {
 	}
 }
 
+func TestClient_GetInfo(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"name": "bosh",
+			"uuid": "d1f6-uuid",
+			"version": "280.0.14 (00000000)",
+			"cpi": "vsphere_cpi",
+			"user_authentication": {"type": "uaa", "options": {"url": "https://10.0.0.6:8443", "urls": ["https://10.0.0.6:8443"]}},
+			"features": {
+				"local_dns": {"status": true, "extras": {"domain_name": "bosh"}},
+				"power_dns": {"status": false, "extras": {"domain_name": "bosh"}},
+				"config_server": {"status": true, "extras": {"urls": ["https://10.0.0.6:8844/api/"]}}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	creds := &auth.Credentials{
+		Environment:  server.URL,
+		CACert:       testCA(server),
+		Client:       "admin",
+		ClientSecret: "secret",
+	}
+
+	client, err := NewClient(creds)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	info, err := client.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+
+	if info.UUID != "d1f6-uuid" || info.CPI != "vsphere_cpi" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if info.UserAuthentication.Type != "uaa" || info.UserAuthentication.Options.URL != "https://10.0.0.6:8443" {
+		t.Errorf("unexpected user authentication: %+v", info.UserAuthentication)
+	}
+	if !info.FeatureEnabled("config_server") || info.FeatureEnabled("power_dns") || info.FeatureEnabled("snapshots") {
+		t.Errorf("unexpected features: %+v", info.Features)
+	}
+}
+
 func TestClient_GetCloudConfig(t *testing.T) {
 	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/configs" {
@@ -176,6 +239,7 @@ func TestClient_GetCloudConfig(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -211,6 +275,7 @@ func TestClient_ListLocks(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -245,6 +310,7 @@ func TestClient_DeleteDeployment(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -276,6 +342,7 @@ func TestClient_StopInstance(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -285,7 +352,7 @@ func TestClient_StopInstance(t *testing.T) {
 		t.Fatalf("failed to create client: %v", err)
 	}
 
-	taskID, err := client.ChangeJobState("cf", "diego_cell", "stopped")
+	taskID, err := client.ChangeJobState("cf", "diego_cell", "", "stopped", JobStateOptions{})
 	if err != nil {
 		t.Fatalf("ChangeJobState failed: %v", err)
 	}
@@ -307,6 +374,7 @@ func TestClient_Recreate(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -316,7 +384,7 @@ func TestClient_Recreate(t *testing.T) {
 		t.Fatalf("failed to create client: %v", err)
 	}
 
-	taskID, err := client.Recreate("cf", "", "")
+	taskID, err := client.Recreate("cf", "", "", JobStateOptions{})
 	if err != nil {
 		t.Fatalf("Recreate failed: %v", err)
 	}
@@ -326,6 +394,44 @@ func TestClient_Recreate(t *testing.T) {
 	}
 }
 
+func TestClient_Recreate_Options(t *testing.T) {
+	var query url.Values
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Location", "/tasks/789")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	creds := &auth.Credentials{
+		Environment:  server.URL,
+		CACert:       testCA(server),
+		Client:       "admin",
+		ClientSecret: "secret",
+	}
+
+	client, err := NewClient(creds)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	opts := JobStateOptions{SkipDrain: true, Fix: true, Canaries: "1", MaxInFlight: "25%"}
+	if _, err := client.Recreate("cf", "router", "", opts); err != nil {
+		t.Fatalf("Recreate failed: %v", err)
+	}
+
+	want := url.Values{
+		"state":         {"recreate"},
+		"skip_drain":    {"true"},
+		"fix":           {"true"},
+		"canaries":      {"1"},
+		"max_in_flight": {"25%"},
+	}
+	if query.Encode() != want.Encode() {
+		t.Errorf("expected query %s, got %s", want.Encode(), query.Encode())
+	}
+}
+
 func TestClient_WaitForTask(t *testing.T) {
 	callCount := 0
 	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -342,6 +448,7 @@ func TestClient_WaitForTask(t *testing.T) {
 
 	creds := &auth.Credentials{
 		Environment:  server.URL,
+		CACert:       testCA(server),
 		Client:       "admin",
 		ClientSecret: "secret",
 	}
@@ -363,3 +470,240 @@ func TestClient_WaitForTask(t *testing.T) {
 		t.Errorf("expected at least 3 poll calls, got %d", callCount)
 	}
 }
+
+func TestClient_NotFoundError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":70000,"description":"Deployment 'cff' doesn't exist"}`))
+	}))
+	defer server.Close()
+
+	creds := &auth.Credentials{
+		Environment:  server.URL,
+		CACert:       testCA(server),
+		Client:       "admin",
+		ClientSecret: "secret",
+	}
+
+	client, err := NewClient(creds)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	_, err = client.ListVMs("cff")
+	if err == nil {
+		t.Fatal("expected error for missing deployment")
+	}
+	if !IsNotFound(err) {
+		t.Errorf("expected IsNotFound to be true, got error: %v", err)
+	}
+}
+
+func TestClient_ListVMsWithVitals_FollowsTask(t *testing.T) {
+	resultOutput := `{"job_name":"router","index":0,"id":"r1","job_state":"running","vitals":{"mem":{"kb":"1024","percent":"42"},"load":["0.5","0.4","0.3"],"disk":{"ephemeral":{"percent":"91","inode_percent":"4"}}}}
+{"job_name":"router","index":1,"id":"r2","job_state":"failing","vitals":{"mem":{"kb":"2048","percent":"88"}}}
+`
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deployments/cf/vms":
+			if r.URL.Query().Get("format") != "full" {
+				t.Errorf("expected format=full, got %s", r.URL.RawQuery)
+			}
+			w.Header().Set("Location", "/tasks/77")
+			w.WriteHeader(http.StatusFound)
+		case "/tasks/77":
+			json.NewEncoder(w).Encode(Task{ID: 77, State: "done"})
+		case "/tasks/77/output":
+			if r.URL.Query().Get("type") != "result" {
+				t.Errorf("expected result output, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(resultOutput))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	creds := &auth.Credentials{
+		Environment:  server.URL,
+		CACert:       testCA(server),
+		Client:       "admin",
+		ClientSecret: "secret",
+	}
+
+	client, err := NewClient(creds)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	vms, err := client.ListVMsWithVitals(context.Background(), "cf")
+	if err != nil {
+		t.Fatalf("ListVMsWithVitals failed: %v", err)
+	}
+
+	if len(vms) != 2 {
+		t.Fatalf("expected 2 VMs, got %d", len(vms))
+	}
+	if vms[0].Job != "router" || vms[1].ProcessState != "failing" {
+		t.Errorf("expected job_name and job_state to be normalized, got %+v", vms)
+	}
+	if vms[0].Vitals == nil || vms[0].Vitals.Disk.Ephemeral.Percent != "91" || vms[0].Vitals.Load[0] != "0.5" {
+		t.Errorf("unexpected vitals: %+v", vms[0].Vitals)
+	}
+}
+
+func TestClient_ListInstances_TaskError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deployments/cf/instances":
+			w.Header().Set("Location", "/tasks/78")
+			w.WriteHeader(http.StatusFound)
+		case "/tasks/78":
+			json.NewEncoder(w).Encode(Task{ID: 78, State: "error", Result: "agent timed out"})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	creds := &auth.Credentials{
+		Environment:  server.URL,
+		CACert:       testCA(server),
+		Client:       "admin",
+		ClientSecret: "secret",
+	}
+
+	client, err := NewClient(creds)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	_, err = client.ListInstancesWithProcesses(context.Background(), "cf")
+	if err == nil || !strings.Contains(err.Error(), "agent timed out") {
+		t.Errorf("expected task error, got %v", err)
+	}
+}
+
+func TestClient_ListInstancesWithProcesses_NormalizesFullFormat(t *testing.T) {
+	resultOutput := `{"agent_id":"a1","job_name":"router","index":0,"id":"r1","job_state":"running","state":"started","az":"z1","ips":["10.0.1.5"],"vm_cid":"vm-1","expects_vm":true,"processes":[{"name":"gorouter","state":"running"}]}
+{"agent_id":"a2","job_name":"router","index":1,"id":"r2","job_state":"failing","state":"started","az":"z2","ips":["10.0.1.6"],"vm_cid":"vm-2","expects_vm":true,"processes":[{"name":"gorouter","state":"failing"}]}
+`
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deployments/cf/instances":
+			w.Header().Set("Location", "/tasks/80")
+			w.WriteHeader(http.StatusFound)
+		case "/tasks/80":
+			json.NewEncoder(w).Encode(Task{ID: 80, State: "done"})
+		case "/tasks/80/output":
+			w.Write([]byte(resultOutput))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&auth.Credentials{Environment: server.URL, CACert: testCA(server), Client: "admin", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	instances, err := client.ListInstancesWithProcesses(context.Background(), "cf")
+	if err != nil {
+		t.Fatalf("ListInstancesWithProcesses failed: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances, got %d", len(instances))
+	}
+	if instances[0].Job != "router" || instances[0].ProcessState != "running" || instances[0].State != "started" {
+		t.Errorf("expected job_name and job_state to be normalized, got %+v", instances[0])
+	}
+	if instances[1].ProcessState != "failing" || len(instances[1].Processes) != 1 {
+		t.Errorf("unexpected second instance: %+v", instances[1])
+	}
+}
+
+func TestClient_ListInstancesWithProcesses_StopsWithContext(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deployments/cf/instances":
+			w.Header().Set("Location", "/tasks/79")
+			w.WriteHeader(http.StatusFound)
+		case "/tasks/79":
+			json.NewEncoder(w).Encode(Task{ID: 79, State: "processing"})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&auth.Credentials{Environment: server.URL, CACert: testCA(server), Client: "admin", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.ListInstancesWithProcesses(ctx, "cf")
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected to stop with the context, took %v", elapsed)
+	}
+}
+
+func TestClient_ListInstances_NoTask(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/deployments/cf/instances" || r.URL.RawQuery != "" {
+			t.Errorf("unexpected request: %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode([]Instance{{Job: "router", ID: "r1", Index: 0}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&auth.Credentials{Environment: server.URL, CACert: testCA(server), Client: "admin", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	instances, err := client.ListInstances("cf")
+	if err != nil || len(instances) != 1 || instances[0].ID != "r1" {
+		t.Errorf("expected one instance, got %+v, %v", instances, err)
+	}
+}
+
+func TestClient_DiffConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/configs/diff" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var fields map[string]string
+		json.NewDecoder(r.Body).Decode(&fields)
+		if fields["type"] != "cloud" || fields["name"] != "default" || fields["content"] != "vm_types: []" {
+			t.Errorf("unexpected body: %v", fields)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"diff":[["vm_types:",null],["- name: small","removed"]]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&auth.Credentials{Environment: server.URL, CACert: testCA(server), Client: "admin", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	lines, err := client.DiffConfig("cloud", "default", "vm_types: []")
+	if err != nil {
+		t.Fatalf("DiffConfig failed: %v", err)
+	}
+
+	expected := []ConfigDiffLine{{Line: "vm_types:"}, {Line: "- name: small", Change: "removed"}}
+	if len(lines) != len(expected) || lines[0] != expected[0] || lines[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, lines)
+	}
+}
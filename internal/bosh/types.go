@@ -19,24 +19,105 @@ type VM struct {
 	State        string   `json:"state"`
 	VMType       string   `json:"vm_type"`
 	Ignore       bool     `json:"ignore"`
+	Vitals       *Vitals  `json:"vitals,omitempty"`
+}
+
+// fullVM is a VM as returned by /deployments/:name/vms?format=full, which
+// names some fields differently from the default format.
+type fullVM struct {
+	VM
+	JobName  string `json:"job_name"`
+	JobState string `json:"job_state"`
+}
+
+func (v fullVM) normalize() VM {
+	vm := v.VM
+	if vm.Job == "" {
+		vm.Job = v.JobName
+	}
+	if vm.ProcessState == "" {
+		vm.ProcessState = v.JobState
+	}
+	return vm
+}
+
+// Vitals represents resource usage reported by a VM's agent. The Director
+// reports all values as strings.
+type Vitals struct {
+	CPU    *VitalsCPU    `json:"cpu,omitempty"`
+	Mem    *VitalsMemory `json:"mem,omitempty"`
+	Swap   *VitalsMemory `json:"swap,omitempty"`
+	Load   []string      `json:"load,omitempty"`
+	Disk   *VitalsDisks  `json:"disk,omitempty"`
+	Uptime *Uptime       `json:"uptime,omitempty"`
+}
+
+// VitalsCPU represents CPU usage percentages.
+type VitalsCPU struct {
+	Sys  string `json:"sys"`
+	User string `json:"user"`
+	Wait string `json:"wait"`
+}
+
+// VitalsMemory represents memory or swap usage.
+type VitalsMemory struct {
+	KB      string `json:"kb"`
+	Percent string `json:"percent"`
+}
+
+// VitalsDisks represents usage of a VM's system, ephemeral, and persistent disks.
+type VitalsDisks struct {
+	System     *VitalsDisk `json:"system,omitempty"`
+	Ephemeral  *VitalsDisk `json:"ephemeral,omitempty"`
+	Persistent *VitalsDisk `json:"persistent,omitempty"`
+}
+
+// VitalsDisk represents usage of a single disk.
+type VitalsDisk struct {
+	Percent      string `json:"percent"`
+	InodePercent string `json:"inode_percent"`
 }
 
 // Instance represents a BOSH instance with process details.
 type Instance struct {
-	AgentID    string    `json:"agent_id"`
-	AZ         string    `json:"az"`
-	Bootstrap  bool      `json:"bootstrap"`
-	Deployment string    `json:"deployment"`
-	Disk       string    `json:"disk_cid,omitempty"`
-	Expects    VMState   `json:"expects_vm"`
-	ID         string    `json:"id"`
-	IPs        []string  `json:"ips"`
-	Job        string    `json:"job"`
-	Index      int       `json:"index"`
-	State      string    `json:"state"`
-	VMType     string    `json:"vm_type"`
-	VMCID      string    `json:"vm_cid"`
-	Processes  []Process `json:"processes,omitempty"`
+	AgentID    string   `json:"agent_id"`
+	AZ         string   `json:"az"`
+	Bootstrap  bool     `json:"bootstrap"`
+	Deployment string   `json:"deployment"`
+	Disk       string   `json:"disk_cid,omitempty"`
+	Expects    VMState  `json:"expects_vm"`
+	ID         string   `json:"id"`
+	IPs        []string `json:"ips"`
+	Job        string   `json:"job"`
+	Index      int      `json:"index"`
+	State      string   `json:"state"`
+	// ProcessState is the agent's overall process state, e.g. running or
+	// failing, as opposed to State, the desired state (started, stopped).
+	ProcessState string    `json:"process_state,omitempty"`
+	VMType       string    `json:"vm_type"`
+	VMCID        string    `json:"vm_cid"`
+	Ignore       bool      `json:"ignore"`
+	Processes    []Process `json:"processes,omitempty"`
+}
+
+// fullInstance is an instance as returned by
+// /deployments/:name/instances?format=full, which names the job and its
+// process state like fullVM does.
+type fullInstance struct {
+	Instance
+	JobName  string `json:"job_name"`
+	JobState string `json:"job_state"`
+}
+
+func (i fullInstance) normalize() Instance {
+	inst := i.Instance
+	if inst.Job == "" {
+		inst.Job = i.JobName
+	}
+	if inst.ProcessState == "" {
+		inst.ProcessState = i.JobState
+	}
+	return inst
 }
 
 // VMState represents expected VM state.
@@ -110,6 +191,43 @@ type Release struct {
 	UncommittedChanges bool   `json:"uncommitted_changes"`
 }
 
+// ReleaseDetails is the content of a release version.
+type ReleaseDetails struct {
+	Jobs     []ReleaseJob     `json:"jobs"`
+	Packages []ReleasePackage `json:"packages"`
+}
+
+// ReleaseJob is a job in a release version.
+type ReleaseJob struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version,omitempty"`
+	Fingerprint string   `json:"fingerprint"`
+	SHA1        string   `json:"sha1"`
+	BlobstoreID string   `json:"blobstore_id"`
+	Packages    []string `json:"packages,omitempty"`
+}
+
+// ReleasePackage is a package in a release version. SHA1 and BlobstoreID
+// are empty when the release contains only compiled packages.
+type ReleasePackage struct {
+	Name             string            `json:"name"`
+	Version          string            `json:"version,omitempty"`
+	Fingerprint      string            `json:"fingerprint"`
+	SHA1             string            `json:"sha1"`
+	BlobstoreID      string            `json:"blobstore_id"`
+	Dependencies     []string          `json:"dependencies"`
+	CompiledPackages []CompiledPackage `json:"compiled_packages"`
+}
+
+// CompiledPackage is a package compiled for a stemcell, identified as
+// "operating-system/version".
+type CompiledPackage struct {
+	Stemcell      string `json:"stemcell"`
+	SHA1          string `json:"sha1"`
+	BlobstoreID   string `json:"blobstore_id"`
+	DependencyKey string `json:"dependency_key"`
+}
+
 // CloudConfig represents a cloud config.
 type CloudConfig struct {
 	Properties string `json:"properties"`
@@ -129,6 +247,25 @@ type CPIConfig struct {
 	CreatedAt  string `json:"created_at"`
 }
 
+// Config is a version of a generic Director config, such as a cloud, runtime,
+// or CPI config. Current is set on the latest version of each name.
+type Config struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Content   string `json:"content,omitempty"`
+	CreatedAt string `json:"created_at"`
+	Team      string `json:"team,omitempty"`
+	Current   bool   `json:"current"`
+}
+
+// ConfigDiffLine is one line of a config diff. Change is "added", "removed",
+// or empty for unchanged context.
+type ConfigDiffLine struct {
+	Line   string `json:"line"`
+	Change string `json:"change,omitempty"`
+}
+
 // Variable represents a deployment variable.
 type Variable struct {
 	ID   string `json:"id"`
@@ -142,3 +279,57 @@ type Lock struct {
 	Timeout  string `json:"timeout"`
 	TaskID   string `json:"task_id"`
 }
+
+// OrphanedDisk represents a persistent disk left behind by a deleted instance.
+type OrphanedDisk struct {
+	DiskCID    string `json:"disk_cid"`
+	Size       int    `json:"size"`
+	AZ         string `json:"az"`
+	Deployment string `json:"deployment_name"`
+	Instance   string `json:"instance_name"`
+	OrphanedAt string `json:"orphaned_at"`
+}
+
+// OrphanedVM represents a VM orphaned by a deploy, awaiting cleanup.
+type OrphanedVM struct {
+	CID        string   `json:"cid"`
+	AZ         string   `json:"az"`
+	Deployment string   `json:"deployment_name"`
+	Instance   string   `json:"instance_name"`
+	IPs        []string `json:"ip_addresses"`
+	OrphanedAt string   `json:"orphaned_at"`
+}
+
+// Info describes a Director: its identity, version, CPI, how users
+// authenticate, and which optional features are enabled.
+type Info struct {
+	Name               string             `json:"name"`
+	UUID               string             `json:"uuid"`
+	Version            string             `json:"version"`
+	User               string             `json:"user,omitempty"`
+	CPI                string             `json:"cpi"`
+	StemcellOS         string             `json:"stemcell_os,omitempty"`
+	StemcellVersion    string             `json:"stemcell_version,omitempty"`
+	UserAuthentication UserAuthentication `json:"user_authentication"`
+	Features           map[string]Feature `json:"features"`
+}
+
+// UserAuthentication describes how users authenticate with the Director.
+// Type is "uaa" or "basic"; URL is set for UAA.
+type UserAuthentication struct {
+	Type    string `json:"type"`
+	Options struct {
+		URL string `json:"url,omitempty"`
+	} `json:"options"`
+}
+
+// Feature is an optional Director feature, such as config_server or local_dns.
+type Feature struct {
+	Status bool           `json:"status"`
+	Extras map[string]any `json:"extras,omitempty"`
+}
+
+// FeatureEnabled returns whether the named feature is enabled.
+func (i *Info) FeatureEnabled(name string) bool {
+	return i.Features[name].Status
+}
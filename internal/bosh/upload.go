@@ -0,0 +1,153 @@
+// ABOUTME: Uploads releases and stemcells, either fetched by the Director from a URL
+// ABOUTME: or streamed from a local file as multipart form data for air-gapped setups.
+
+package bosh
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UploadOptions specifies release and stemcell upload options.
+type UploadOptions struct {
+	SHA1   string // Expected digest: a SHA1 hex string, or "sha256:<hex>" (optional)
+	Fix    bool   // Replace an already-uploaded release or stemcell with the same version
+	Rebase bool   // Rebase the release onto the latest uploaded version (releases only)
+}
+
+// UploadReleaseFromURL has the Director fetch and import a release tarball.
+// The Director verifies the tarball against opts.SHA1 if set. Returns the task ID.
+func (c *Client) UploadReleaseFromURL(location string, opts UploadOptions) (int, error) {
+	return c.uploadFromURL("/releases", location, opts, opts.Rebase)
+}
+
+// UploadStemcellFromURL has the Director fetch and import a stemcell tarball.
+// The Director verifies the tarball against opts.SHA1 if set. Returns the task ID.
+func (c *Client) UploadStemcellFromURL(location string, opts UploadOptions) (int, error) {
+	return c.uploadFromURL("/stemcells", location, opts, false)
+}
+
+// UploadReleaseFile streams a local release tarball to the Director after
+// verifying it against opts.SHA1 if set. Returns the task ID.
+func (c *Client) UploadReleaseFile(path string, opts UploadOptions) (int, error) {
+	return c.uploadFile("/releases", "release", path, opts, opts.Rebase)
+}
+
+// UploadStemcellFile streams a local stemcell tarball to the Director after
+// verifying it against opts.SHA1 if set. Returns the task ID.
+func (c *Client) UploadStemcellFile(path string, opts UploadOptions) (int, error) {
+	return c.uploadFile("/stemcells", "stemcell", path, opts, false)
+}
+
+func (c *Client) uploadFromURL(path, location string, opts UploadOptions, rebase bool) (int, error) {
+	fields := map[string]string{"location": location}
+	if opts.SHA1 != "" {
+		fields["sha1"] = opts.SHA1
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	return c.doAsyncRequestWithBody("POST", path, uploadQuery(opts.Fix, rebase), body)
+}
+
+func (c *Client) uploadFile(path, field, filePath string, opts UploadOptions, rebase bool) (int, error) {
+	if opts.SHA1 != "" {
+		if err := verifyFileDigest(filePath, opts.SHA1); err != nil {
+			return 0, err
+		}
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	// The multipart body is written as the request is sent. If the request
+	// fails, closing the reader unblocks the writer.
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile(field, filepath.Base(filePath))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	taskID, err := c.doAsyncStream("POST", path, uploadQuery(opts.Fix, rebase), form.FormDataContentType(), pr)
+	pr.Close()
+	return taskID, err
+}
+
+func uploadQuery(fix, rebase bool) url.Values {
+	query := url.Values{}
+	if fix {
+		query.Set("fix", "true")
+	}
+	if rebase {
+		query.Set("rebase", "true")
+	}
+	return query
+}
+
+// verifyFileDigest checks a file against an expected digest in BOSH form: a
+// SHA1 hex string, "sha1:<hex>", "sha256:<hex>", or several separated by ";".
+func verifyFileDigest(path, expected string) error {
+	type check struct {
+		algorithm string
+		hash      hash.Hash
+		want      string
+	}
+
+	var checks []check
+	for _, digest := range strings.Split(expected, ";") {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(digest), ":")
+		if !ok {
+			algorithm, value = "sha1", algorithm
+		}
+		switch algorithm {
+		case "sha1":
+			checks = append(checks, check{algorithm, sha1.New(), strings.ToLower(value)})
+		case "sha256":
+			checks = append(checks, check{algorithm, sha256.New(), strings.ToLower(value)})
+		default:
+			return fmt.Errorf("unsupported digest algorithm %q", algorithm)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writers := make([]io.Writer, len(checks))
+	for i, c := range checks {
+		writers[i] = c.hash
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return err
+	}
+
+	for _, c := range checks {
+		if got := hex.EncodeToString(c.hash.Sum(nil)); got != c.want {
+			return fmt.Errorf("%s digest mismatch for %s: expected %s, got %s", c.algorithm, filepath.Base(path), c.want, got)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// ABOUTME: Tests for release and stemcell uploads.
+// ABOUTME: Verifies URL upload bodies, multipart file streaming, and digest checks.
+
+package bosh
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+)
+
+func newUploadTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&auth.Credentials{Environment: server.URL, CACert: testCA(server), Client: "admin", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func writeTarball(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "release.tgz")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write tarball: %v", err)
+	}
+	return path
+}
+
+func TestClient_UploadReleaseFromURL(t *testing.T) {
+	client := newUploadTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/releases" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.RawQuery != "fix=true&rebase=true" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type: %s", ct)
+		}
+		var fields map[string]string
+		json.NewDecoder(r.Body).Decode(&fields)
+		if fields["location"] != "https://example.com/cf.tgz" || fields["sha1"] != "abc123" {
+			t.Errorf("unexpected body: %v", fields)
+		}
+		w.Header().Set("Location", "/tasks/10")
+		w.WriteHeader(http.StatusFound)
+	})
+
+	taskID, err := client.UploadReleaseFromURL("https://example.com/cf.tgz", UploadOptions{SHA1: "abc123", Fix: true, Rebase: true})
+	if err != nil {
+		t.Fatalf("UploadReleaseFromURL failed: %v", err)
+	}
+	if taskID != 10 {
+		t.Errorf("expected task 10, got %d", taskID)
+	}
+}
+
+func TestClient_UploadStemcellFromURL_IgnoresRebase(t *testing.T) {
+	client := newUploadTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stemcells" || r.URL.RawQuery != "" {
+			t.Errorf("unexpected request: %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		w.Header().Set("Location", "/tasks/11")
+		w.WriteHeader(http.StatusFound)
+	})
+
+	if _, err := client.UploadStemcellFromURL("https://example.com/stemcell.tgz", UploadOptions{Rebase: true}); err != nil {
+		t.Fatalf("UploadStemcellFromURL failed: %v", err)
+	}
+}
+
+func TestClient_UploadReleaseFile_StreamsMultipart(t *testing.T) {
+	content := strings.Repeat("release-bytes", 100000)
+	path := writeTarball(t, content)
+	sum := sha1.Sum([]byte(content))
+
+	client := newUploadTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		file, header, err := r.FormFile("release")
+		if err != nil {
+			t.Errorf("expected release form file: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		if header.Filename != "release.tgz" || string(data) != content {
+			t.Errorf("unexpected upload %s of %d bytes", header.Filename, len(data))
+		}
+		w.Header().Set("Location", "/tasks/12")
+		w.WriteHeader(http.StatusFound)
+	})
+
+	taskID, err := client.UploadReleaseFile(path, UploadOptions{SHA1: hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatalf("UploadReleaseFile failed: %v", err)
+	}
+	if taskID != 12 {
+		t.Errorf("expected task 12, got %d", taskID)
+	}
+}
+
+func TestClient_UploadFile_DirectorError(t *testing.T) {
+	path := writeTarball(t, strings.Repeat("x", 1<<20))
+
+	client := newUploadTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := client.UploadStemcellFile(path, UploadOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestClient_UploadFile_DigestMismatch(t *testing.T) {
+	path := writeTarball(t, "release-bytes")
+
+	client := newUploadTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a tarball that fails verification must not be uploaded")
+	})
+
+	_, err := client.UploadReleaseFile(path, UploadOptions{SHA1: "0000"})
+	if err == nil || !strings.Contains(err.Error(), "sha1 digest mismatch") {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+}
+
+func TestVerifyFileDigest(t *testing.T) {
+	path := writeTarball(t, "release-bytes")
+	sha1Sum := sha1.Sum([]byte("release-bytes"))
+	sha256Sum := sha256.Sum256([]byte("release-bytes"))
+	sha1Hex := hex.EncodeToString(sha1Sum[:])
+	sha256Hex := hex.EncodeToString(sha256Sum[:])
+
+	tests := []struct {
+		digest  string
+		wantErr bool
+	}{
+		{sha1Hex, false},
+		{strings.ToUpper(sha1Hex), false},
+		{"sha1:" + sha1Hex, false},
+		{"sha256:" + sha256Hex, false},
+		{"sha1:" + sha1Hex + ";sha256:" + sha256Hex, false},
+		{"sha256:" + sha1Hex, true},
+		{"sha1:" + sha1Hex + ";sha256:0000", true},
+		{"md5:abc", true},
+	}
+
+	for _, tt := range tests {
+		err := verifyFileDigest(path, tt.digest)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("verifyFileDigest(%q) error = %v, wantErr %v", tt.digest, err, tt.wantErr)
+		}
+	}
+}
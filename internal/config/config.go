@@ -11,9 +11,28 @@ import (
 
 // Config holds server configuration.
 type Config struct {
-	TokenTTL          int      `yaml:"token_ttl"`
-	ConfirmOperations []string `yaml:"confirm_operations"`
-	BlockedOperations []string `yaml:"blocked_operations"`
+	TokenTTL          int       `yaml:"token_ttl"`
+	ConfirmOperations []string  `yaml:"confirm_operations"`
+	BlockedOperations []string  `yaml:"blocked_operations"`
+	Redaction         Redaction `yaml:"redaction"`
+	// DefaultEnvironment is the ~/.bosh/config environment used when a
+	// call names none and no BOSH_* environment variables are set.
+	DefaultEnvironment string `yaml:"default_environment"`
+	// StrictEnvironment makes a default_environment that doesn't exist an
+	// error instead of falling back to Ops Manager. A requested environment
+	// that doesn't exist is always an error.
+	StrictEnvironment bool `yaml:"strict_environment"`
+	// WriteBackTokens saves bosh log-in access tokens refreshed by the
+	// server to ~/.bosh/config, as the BOSH CLI does.
+	WriteBackTokens bool `yaml:"write_back_tokens"`
+}
+
+// Redaction configures the masking of secrets in tool output. Redaction is
+// always on; AllowOptOut lets callers ask for unredacted output per call.
+type Redaction struct {
+	SensitiveKeys []string `yaml:"sensitive_keys"` // Extra keys to redact
+	AllowedKeys   []string `yaml:"allowed_keys"`   // Keys never redacted
+	AllowOptOut   bool     `yaml:"allow_opt_out"`  // Honor redact: false on tool calls
 }
 
 // DefaultConfirmOperations lists operations requiring confirmation by default.
@@ -22,6 +41,14 @@ var DefaultConfirmOperations = []string{
 	"recreate",
 	"stop",
 	"cck",
+	"cleanup",
+	"delete_disk",
+	"attach_disk",
+	"update_config",
+	"delete_config",
+	"delete_release",
+	"delete_stemcell",
+	"upload_file",
 }
 
 // Load reads configuration from file or returns defaults.
@@ -55,6 +82,10 @@ func Load(path string) *Config {
 	if len(fileCfg.BlockedOperations) > 0 {
 		cfg.BlockedOperations = fileCfg.BlockedOperations
 	}
+	cfg.Redaction = fileCfg.Redaction
+	cfg.DefaultEnvironment = fileCfg.DefaultEnvironment
+	cfg.StrictEnvironment = fileCfg.StrictEnvironment
+	cfg.WriteBackTokens = fileCfg.WriteBackTokens
 
 	return cfg
 }
@@ -24,6 +24,10 @@ func TestConfig_Defaults(t *testing.T) {
 		t.Error("expected recreate to require confirmation by default")
 	}
 
+	if !cfg.RequiresConfirmation("delete_config") {
+		t.Error("expected delete_config to require confirmation by default")
+	}
+
 	if cfg.RequiresConfirmation("restart") {
 		t.Error("expected restart to NOT require confirmation by default")
 	}
@@ -68,3 +72,63 @@ blocked_operations:
 		t.Error("expected cck to be blocked")
 	}
 }
+
+func TestConfig_Redaction(t *testing.T) {
+	if Load("").Redaction.AllowOptOut {
+		t.Error("expected redaction opt-out to be disallowed by default")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte(`
+redaction:
+  sensitive_keys:
+    - license_key
+  allowed_keys:
+    - token_ttl
+  allow_opt_out: true
+`)
+	if err := os.WriteFile(configPath, content, 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg := Load(configPath)
+
+	if !cfg.Redaction.AllowOptOut {
+		t.Error("expected redaction opt-out to be allowed")
+	}
+	if len(cfg.Redaction.SensitiveKeys) != 1 || cfg.Redaction.SensitiveKeys[0] != "license_key" {
+		t.Errorf("unexpected sensitive keys: %v", cfg.Redaction.SensitiveKeys)
+	}
+	if len(cfg.Redaction.AllowedKeys) != 1 || cfg.Redaction.AllowedKeys[0] != "token_ttl" {
+		t.Errorf("unexpected allowed keys: %v", cfg.Redaction.AllowedKeys)
+	}
+}
+
+func TestConfig_EnvironmentResolution(t *testing.T) {
+	cfg := Load("")
+	if cfg.DefaultEnvironment != "" || cfg.StrictEnvironment || cfg.WriteBackTokens {
+		t.Errorf("expected no default environment, lenient resolution, and no write-back, got %q/%v/%v", cfg.DefaultEnvironment, cfg.StrictEnvironment, cfg.WriteBackTokens)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte(`
+default_environment: sandbox
+strict_environment: true
+write_back_tokens: true
+`)
+	if err := os.WriteFile(configPath, content, 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg = Load(configPath)
+
+	if cfg.DefaultEnvironment != "sandbox" {
+		t.Errorf("expected default environment sandbox, got %q", cfg.DefaultEnvironment)
+	}
+	if !cfg.StrictEnvironment {
+		t.Error("expected strict environment resolution")
+	}
+	if !cfg.WriteBackTokens {
+		t.Error("expected token write-back")
+	}
+}
@@ -0,0 +1,211 @@
+// ABOUTME: Reads credential metadata from CredHub, the Director's config server.
+// ABOUTME: Secret values are fetched only to parse certificates and are never returned.
+
+package credhub
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TokenSource provides bearer tokens for CredHub requests.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Client reads credential metadata from a CredHub server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	tokens     TokenSource
+}
+
+// Metadata describes one credential version without its value.
+type Metadata struct {
+	ID               string               `json:"id"`
+	Name             string               `json:"name"`
+	Type             string               `json:"type"`
+	VersionCreatedAt string               `json:"version_created_at"`
+	Certificate      *CertificateMetadata `json:"certificate,omitempty"`
+}
+
+// CertificateMetadata describes the public certificate of a certificate credential.
+type CertificateMetadata struct {
+	Subject    string    `json:"subject"`
+	Issuer     string    `json:"issuer"`
+	NotBefore  time.Time `json:"not_before"`
+	NotAfter   time.Time `json:"not_after"`
+	SANs       []string  `json:"sans,omitempty"`
+	IsCA       bool      `json:"is_ca"`
+	SelfSigned bool      `json:"self_signed"`
+}
+
+// APIError is returned when CredHub responds with an error status.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("CredHub error %d: %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound returns true if err is a CredHub 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient creates a CredHub client for the server at serverURL, e.g.
+// https://10.0.0.6:8844/api/ as advertised in the Director's info.
+func NewClient(serverURL string, transport http.RoundTripper, tokens TokenSource) *Client {
+	baseURL := strings.TrimSuffix(serverURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/api")
+	return &Client{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+		tokens: tokens,
+	}
+}
+
+// credential is a credential version as CredHub returns it. Value is kept
+// raw so that only certificates are ever decoded.
+type credential struct {
+	ID               string          `json:"id"`
+	Name             string          `json:"name"`
+	Type             string          `json:"type"`
+	VersionCreatedAt string          `json:"version_created_at"`
+	Value            json.RawMessage `json:"value"`
+}
+
+// GetMetadataByID returns the metadata of a credential version. BOSH
+// variable IDs are CredHub version IDs, so this describes exactly the
+// version a deployment uses.
+func (c *Client) GetMetadataByID(id string) (*Metadata, error) {
+	body, err := c.doRequest("/api/v1/data/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var cred credential
+	if err := json.Unmarshal(body, &cred); err != nil {
+		return nil, err
+	}
+	return metadata(cred)
+}
+
+// GetMetadata returns the metadata of the current version of a credential.
+func (c *Client) GetMetadata(name string) (*Metadata, error) {
+	body, err := c.doRequest("/api/v1/data", url.Values{"name": {name}, "current": {"true"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []credential `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Body: "credential not found: " + name}
+	}
+	return metadata(resp.Data[0])
+}
+
+func metadata(cred credential) (*Metadata, error) {
+	m := &Metadata{
+		ID:               cred.ID,
+		Name:             cred.Name,
+		Type:             cred.Type,
+		VersionCreatedAt: cred.VersionCreatedAt,
+	}
+	if cred.Type != "certificate" {
+		return m, nil
+	}
+
+	var value struct {
+		Certificate string `json:"certificate"`
+	}
+	if err := json.Unmarshal(cred.Value, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode certificate %s: %w", cred.Name, err)
+	}
+	cert, err := ParseCertificate(value.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %w", cred.Name, err)
+	}
+	m.Certificate = cert
+	return m, nil
+}
+
+// ParseCertificate returns the metadata of the first certificate in a PEM block.
+func ParseCertificate(certPEM string) (*CertificateMetadata, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &CertificateMetadata{
+		Subject:    cert.Subject.String(),
+		Issuer:     cert.Issuer.String(),
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+		IsCA:       cert.IsCA,
+		SelfSigned: bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil,
+	}
+	meta.SANs = append(meta.SANs, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		meta.SANs = append(meta.SANs, ip.String())
+	}
+	return meta, nil
+}
+
+func (c *Client) doRequest(path string, query url.Values) ([]byte, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.tokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("UAA authentication failed: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return body, nil
+}
@@ -0,0 +1,362 @@
+// ABOUTME: Masks secrets in tool output: values under sensitive keys, PEM blocks, and token patterns.
+// ABOUTME: Works on decoded JSON values and on free text such as manifests, configs, and task output.
+
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Mask replaces redacted values.
+const Mask = "<redacted>"
+
+// DefaultSensitiveWords are key segments whose values are secret, e.g.
+// admin_password, clientSecret, or uaa.token.
+var DefaultSensitiveWords = []string{
+	"password",
+	"passwd",
+	"passphrase",
+	"secret",
+	"token",
+	"credential",
+	"credentials",
+}
+
+// DefaultSensitiveKeys are whole keys, after normalizing to lower case with
+// underscores, whose values are secret.
+var DefaultSensitiveKeys = []string{
+	"private_key",
+	"access_key",
+	"access_key_id",
+	"secret_access_key",
+	"api_key",
+	"apikey",
+	"auth_key",
+	"encryption_key",
+	"session_key",
+	"ssh_key",
+	"signing_key",
+	"key_pair",
+}
+
+// DefaultAllowedKeys are keys that look sensitive but hold no secret.
+var DefaultAllowedKeys = []string{
+	"confirmation_token",
+	"token_ttl",
+	"token_type",
+	"expires_in_seconds",
+}
+
+// Options configures a Redactor. Keys are matched after normalizing to
+// lower case with underscores, so clientSecret and client-secret match
+// client_secret.
+type Options struct {
+	SensitiveKeys []string // Additional keys to redact (denylist)
+	AllowedKeys   []string // Keys never redacted, overriding the defaults and denylist
+}
+
+// Redactor masks secrets in values and text.
+type Redactor struct {
+	words   map[string]bool
+	keys    map[string]bool
+	allowed map[string]bool
+}
+
+// New creates a Redactor with the default rules plus opts.
+func New(opts Options) *Redactor {
+	r := &Redactor{
+		words:   make(map[string]bool),
+		keys:    make(map[string]bool),
+		allowed: make(map[string]bool),
+	}
+	for _, w := range DefaultSensitiveWords {
+		r.words[w] = true
+	}
+	for _, k := range append(append([]string{}, DefaultSensitiveKeys...), opts.SensitiveKeys...) {
+		r.keys[normalizeKey(k)] = true
+	}
+	for _, k := range append(append([]string{}, DefaultAllowedKeys...), opts.AllowedKeys...) {
+		r.allowed[normalizeKey(k)] = true
+	}
+	return r
+}
+
+// SensitiveKey returns whether values under key are redacted.
+func (r *Redactor) SensitiveKey(key string) bool {
+	normalized := normalizeKey(key)
+	if normalized == "" || r.allowed[normalized] {
+		return false
+	}
+	if r.keys[normalized] {
+		return true
+	}
+	for _, segment := range strings.Split(normalized, "_") {
+		if r.words[segment] {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitivePath returns whether a flattened path such as
+// vm_types[name=small].cloud_properties.vcenter_password passes through a
+// sensitive key.
+func (r *Redactor) SensitivePath(path string) bool {
+	for _, segment := range strings.Split(path, ".") {
+		if i := strings.Index(segment, "["); i >= 0 {
+			segment = segment[:i]
+		}
+		if r.SensitiveKey(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns a copy of a decoded JSON value with secrets masked: every
+// string under a sensitive key, and secrets found in any string. An object
+// with a sensitive "path", such as a YAML diff change, has every string
+// other than its path and op masked, since its values sit under that key.
+func (r *Redactor) Value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		if path, ok := v["path"].(string); ok && r.SensitivePath(path) {
+			for key, value := range v {
+				if key == "path" || key == "op" {
+					out[key] = value
+				} else {
+					out[key] = maskAll(value)
+				}
+			}
+			return out
+		}
+		for key, value := range v {
+			if r.SensitiveKey(key) {
+				out[key] = maskAll(value)
+			} else {
+				out[key] = r.Value(value)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = r.Value(value)
+		}
+		return out
+	case string:
+		return r.String(v)
+	default:
+		return v
+	}
+}
+
+// maskAll masks every non-empty string in v, keeping its shape. Numbers and
+// booleans are kept so results still match their output schemas.
+func maskAll(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[key] = maskAll(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = maskAll(value)
+		}
+		return out
+	case string:
+		if v == "" || isVariableReference(v) {
+			return v
+		}
+		return Mask
+	default:
+		return v
+	}
+}
+
+var (
+	pemBlock = regexp.MustCompile(`-----BEGIN ([A-Z0-9 ]+)-----[\s\S]*?-----END [A-Z0-9 ]+-----`)
+
+	// secretPatterns match well-known credential formats wherever they appear.
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                             // AWS access key ID
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),                            // GitHub token
+		regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),                          // Slack token
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`), // JWT
+	}
+
+	// bearerToken matches an HTTP authorization value.
+	bearerToken = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`)
+
+	// urlPassword matches the password in a URL's user info.
+	urlPassword = regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`)
+
+	// quotedPair matches a quoted key and quoted value anywhere in a line,
+	// as in inline JSON ("key":"value") and Ruby hashes ("key"=>"value",
+	// :key => 'value') found in task output and debug logs.
+	quotedPair = regexp.MustCompile(`(?:"([A-Za-z0-9_.\-]+)"|'([A-Za-z0-9_.\-]+)'|:([A-Za-z0-9_]+))\s*(?::|=>)\s*("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`)
+
+	// keyValueLine matches "key: value" (YAML) and "key=value" (env) lines,
+	// with optional list markers, quotes, and export.
+	keyValueLine = regexp.MustCompile(`^(\s*(?:-\s+)?(?:export\s+)?["']?([A-Za-z0-9_.\-]+)["']?\s*(?::|=)\s*)(.*)$`)
+)
+
+// String masks secrets in free text: PEM blocks, well-known token formats,
+// URL passwords, the values of sensitive keys in JSON lines, inline JSON and
+// Ruby hashes, and YAML or key=value lines, including YAML block scalars.
+func (r *Redactor) String(s string) string {
+	s = pemBlock.ReplaceAllString(s, "-----BEGIN $1-----"+Mask+"-----END $1-----")
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, Mask)
+	}
+	s = bearerToken.ReplaceAllString(s, "$1 "+Mask)
+	s = urlPassword.ReplaceAllString(s, "${1}"+Mask+"@")
+
+	if !strings.ContainsAny(s, ":=") {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = r.jsonLine(line)
+	}
+	s = r.quotedPairs(strings.Join(lines, "\n"))
+
+	lines = strings.Split(s, "\n")
+	blockIndent := -1 // indentation of the sensitive key whose block is being masked
+	blockScalar := false
+	for i, line := range lines {
+		if blockIndent >= 0 {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if indentation(line) > blockIndent {
+				// Keep nested keys for context but mask their values; block
+				// scalar lines are all content.
+				if m := keyValueLine.FindStringSubmatch(line); m != nil && !blockScalar {
+					lines[i] = maskValue(m[1], m[3])
+				} else {
+					lines[i] = line[:indentation(line)] + Mask
+				}
+				continue
+			}
+			blockIndent = -1
+		}
+
+		m := keyValueLine.FindStringSubmatch(line)
+		if m == nil || !r.SensitiveKey(m[2]) {
+			continue
+		}
+		value := strings.TrimSpace(m[3])
+		switch {
+		case value == "":
+			// A nested mapping or list; mask the scalars beneath it.
+			blockIndent, blockScalar = indentation(line), false
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			blockIndent, blockScalar = indentation(line), true
+		default:
+			lines[i] = maskValue(m[1], m[3])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonLine redacts a line holding a JSON object or array structurally, so
+// nested values under a sensitive key are masked too. Other lines, and
+// JSON lines with nothing to mask, are returned unchanged.
+func (r *Redactor) jsonLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return line
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return line
+	}
+	masked := r.Value(value)
+	if reflect.DeepEqual(masked, value) {
+		return line
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(masked); err != nil {
+		return line
+	}
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	return indent + strings.TrimSuffix(buf.String(), "\n")
+}
+
+// quotedPairs masks quoted values of sensitive keys anywhere in s.
+func (r *Redactor) quotedPairs(s string) string {
+	return quotedPair.ReplaceAllStringFunc(s, func(match string) string {
+		m := quotedPair.FindStringSubmatch(match)
+		key := m[1] + m[2] + m[3]
+		value := m[4]
+		inner := value[1 : len(value)-1]
+		if !r.SensitiveKey(key) || inner == "" || inner == Mask || isVariableReference(inner) {
+			return match
+		}
+		quote := value[:1]
+		return match[:len(match)-len(value)] + quote + Mask + quote
+	})
+}
+
+// maskValue masks the value of a key-value line unless it holds no secret:
+// empty, null, already masked, or a BOSH variable reference. A trailing
+// comma is kept.
+func maskValue(prefix, value string) string {
+	value = strings.TrimSpace(value)
+	switch bare := strings.Trim(value, `"',`); {
+	case bare == "", bare == "null", bare == "~", bare == Mask, isVariableReference(bare):
+		return prefix + value
+	}
+	if strings.HasSuffix(value, ",") {
+		return prefix + Mask + ","
+	}
+	return prefix + Mask
+}
+
+// isVariableReference reports whether s is a BOSH variable reference such
+// as ((admin_password)), which names a secret without revealing it.
+func isVariableReference(s string) bool {
+	return strings.HasPrefix(s, "((") && strings.HasSuffix(s, "))")
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t-"))
+}
+
+// normalizeKey lower-cases key and separates words with underscores, so
+// clientSecret, client-secret, and client.secret all become client_secret.
+func normalizeKey(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, c := range runes {
+		switch {
+		case c == '-' || c == '.' || c == ' ' || c == '_':
+			b.WriteByte('_')
+		case unicode.IsUpper(c):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
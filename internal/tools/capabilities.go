@@ -0,0 +1,217 @@
+// ABOUTME: Implements bosh_info and gates tools on the features and version a Director supports.
+// ABOUTME: Director info is cached per environment; unsupported tools are hidden and refuse calls.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// infoCacheTTL is how long Director info is reused, so an upgraded Director
+// is noticed without restarting the server.
+const infoCacheTTL = 10 * time.Minute
+
+// infoFetchTimeout bounds how long listing tools waits for Director info.
+const infoFetchTimeout = 5 * time.Second
+
+// toolRequirement is what a Director must support for a tool to work.
+type toolRequirement struct {
+	Feature    string // Director feature that must be enabled, e.g. config_server
+	MinVersion string // Oldest Director version with the API the tool uses
+}
+
+// toolRequirements lists the tools that don't work on every Director.
+// bosh_variables isn't gated: only its CredHub metadata needs the config
+// server, and it says so when asked for it.
+var toolRequirements = map[string]toolRequirement{
+	"bosh_cert_expiry":   {Feature: "config_server"},
+	"bosh_configs":       {MinVersion: "263"},
+	"bosh_config_diff":   {MinVersion: "263"},
+	"bosh_update_config": {MinVersion: "263"},
+}
+
+// UnsupportedTool is a tool the Director can't serve, and why.
+type UnsupportedTool struct {
+	Tool   string `json:"tool"`
+	Reason string `json:"reason"`
+}
+
+// infoCache caches Director info keyed by environment.
+type infoCache struct {
+	mu      sync.Mutex
+	entries map[string]*infoEntry
+}
+
+type infoEntry struct {
+	info      *bosh.Info
+	expiresAt time.Time
+}
+
+func newInfoCache() *infoCache {
+	return &infoCache{entries: make(map[string]*infoEntry)}
+}
+
+// directorInfo returns the Director info for an environment, fetching it
+// when it isn't cached or has expired. Errors are not cached.
+func (r *Registry) directorInfo(ctx context.Context, environment string) (*bosh.Info, error) {
+	r.infos.mu.Lock()
+	entry, ok := r.infos.entries[environment]
+	r.infos.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.info, nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return nil, err
+	}
+	info, err := client.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	r.infos.mu.Lock()
+	defer r.infos.mu.Unlock()
+	r.infos.entries[environment] = &infoEntry{info: info, expiresAt: time.Now().Add(infoCacheTTL)}
+	return info, nil
+}
+
+// unsupportedReason returns why a Director can't serve a tool, or "" if it can.
+func unsupportedReason(tool string, info *bosh.Info) string {
+	req, ok := toolRequirements[tool]
+	if !ok {
+		return ""
+	}
+	if req.Feature != "" && !info.FeatureEnabled(req.Feature) {
+		return fmt.Sprintf("the Director does not have %s enabled", req.Feature)
+	}
+	if req.MinVersion != "" {
+		version, _, _ := strings.Cut(info.Version, " ")
+		if version != "" && compareVersions(version, req.MinVersion) < 0 {
+			return fmt.Sprintf("the Director is version %s; %s requires %s or later", version, tool, req.MinVersion)
+		}
+	}
+	return ""
+}
+
+// unsupportedTools returns the tools with requirements the Director doesn't meet.
+func unsupportedTools(info *bosh.Info) []UnsupportedTool {
+	unsupported := []UnsupportedTool{}
+	for tool := range toolRequirements {
+		if reason := unsupportedReason(tool, info); reason != "" {
+			unsupported = append(unsupported, UnsupportedTool{Tool: tool, Reason: reason})
+		}
+	}
+	sort.Slice(unsupported, func(i, j int) bool { return unsupported[i].Tool < unsupported[j].Tool })
+	return unsupported
+}
+
+// requireSupport wraps a handler so it refuses calls the environment's
+// Director can't serve. If the Director's info can't be fetched, the call
+// goes ahead and reports whatever error the Director gives.
+func (r *Registry) requireSupport(tool string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if _, ok := toolRequirements[tool]; !ok {
+		return handler
+	}
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if result := r.checkSupport(ctx, tool, request.GetString("environment", "")); result != nil {
+			return result, nil
+		}
+		return handler(ctx, request)
+	}
+}
+
+// requireSupportRegistry is requireSupport for registry method expressions,
+// so fan-out calls check each environment's Director.
+func requireSupportRegistry(tool string, handler registryHandler) registryHandler {
+	if _, ok := toolRequirements[tool]; !ok {
+		return handler
+	}
+	return func(r *Registry, ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if result := r.checkSupport(ctx, tool, request.GetString("environment", "")); result != nil {
+			return result, nil
+		}
+		return handler(r, ctx, request)
+	}
+}
+
+func (r *Registry) checkSupport(ctx context.Context, tool, environment string) *mcp.CallToolResult {
+	info, err := r.directorInfo(ctx, environment)
+	if err != nil {
+		return nil
+	}
+	if reason := unsupportedReason(tool, info); reason != "" {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not supported: %s", tool, reason))
+	}
+	return nil
+}
+
+// ToolFilter hides tools the default environment's Director can't serve.
+// Director info is fetched on first use; if it can't be fetched within
+// infoFetchTimeout, every tool is listed. Tools stay callable against other
+// environments through their handlers' checks.
+func (r *Registry) ToolFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	type fetched struct {
+		info *bosh.Info
+		err  error
+	}
+	// Buffered so a slow fetch can finish, and fill the cache, after we give up.
+	done := make(chan fetched, 1)
+	go func() {
+		info, err := r.directorInfo(ctx, "")
+		done <- fetched{info, err}
+	}()
+
+	var info *bosh.Info
+	select {
+	case f := <-done:
+		if f.err != nil {
+			return tools
+		}
+		info = f.info
+	case <-time.After(infoFetchTimeout):
+		return tools
+	case <-ctx.Done():
+		return tools
+	}
+
+	filtered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if unsupportedReason(tool.Name, info) == "" {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+func (r *Registry) handleBoshInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	info, err := client.GetInfo()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get director info: %v", err)), nil
+	}
+
+	r.infos.mu.Lock()
+	r.infos.entries[environment] = &infoEntry{info: info, expiresAt: time.Now().Add(infoCacheTTL)}
+	r.infos.mu.Unlock()
+
+	return toolResult(InfoResult{
+		Info:             *info,
+		UnsupportedTools: unsupportedTools(info),
+	})
+}
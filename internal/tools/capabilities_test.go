@@ -0,0 +1,150 @@
+// ABOUTME: Tests for bosh_info and Director capability gating.
+// ABOUTME: Verifies unsupported tools are hidden from listings and refuse calls.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// startInfoDirector serves /info with info and counts how often it is fetched.
+func startInfoDirector(t *testing.T, info bosh.Info, requests *int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		*requests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	}))
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	return server
+}
+
+func TestUnsupportedReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    string
+		info    bosh.Info
+		wantErr string
+	}{
+		{"no requirements", "bosh_vms", bosh.Info{Version: "250.0.0"}, ""},
+		{"feature enabled", "bosh_cert_expiry", bosh.Info{Features: map[string]bosh.Feature{"config_server": {Status: true}}}, ""},
+		{"feature disabled", "bosh_cert_expiry", bosh.Info{Features: map[string]bosh.Feature{"config_server": {Status: false}}}, "config_server"},
+		{"feature missing", "bosh_cert_expiry", bosh.Info{}, "config_server"},
+		{"new enough", "bosh_configs", bosh.Info{Version: "280.0.14 (00000000)"}, ""},
+		{"too old", "bosh_configs", bosh.Info{Version: "262.3.0 (00000000)"}, "requires 263"},
+		{"unknown version", "bosh_configs", bosh.Info{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := unsupportedReason(tt.tool, &tt.info)
+			if tt.wantErr == "" && reason != "" {
+				t.Errorf("expected %s to be supported, got %q", tt.tool, reason)
+			}
+			if tt.wantErr != "" && !strings.Contains(reason, tt.wantErr) {
+				t.Errorf("expected reason containing %q, got %q", tt.wantErr, reason)
+			}
+		})
+	}
+}
+
+func TestToolFilter_HidesUnsupportedTools(t *testing.T) {
+	requests := 0
+	server := startInfoDirector(t, bosh.Info{Version: "262.0.0 (00000000)"}, &requests)
+	defer server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+	tools := []mcp.Tool{{Name: "bosh_vms"}, {Name: "bosh_variables"}, {Name: "bosh_cert_expiry"}, {Name: "bosh_configs"}}
+
+	filtered := registry.ToolFilter(context.Background(), tools)
+	if len(filtered) != 2 || filtered[0].Name != "bosh_vms" || filtered[1].Name != "bosh_variables" {
+		t.Errorf("expected only bosh_vms and bosh_variables, got %v", filtered)
+	}
+
+	registry.ToolFilter(context.Background(), tools)
+	if requests != 1 {
+		t.Errorf("expected director info to be cached, got %d requests", requests)
+	}
+}
+
+func TestToolFilter_ListsEverythingWithoutDirector(t *testing.T) {
+	requests := 0
+	server := startInfoDirector(t, bosh.Info{}, &requests)
+	server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+	tools := []mcp.Tool{{Name: "bosh_vms"}, {Name: "bosh_cert_expiry"}}
+
+	if filtered := registry.ToolFilter(context.Background(), tools); len(filtered) != len(tools) {
+		t.Errorf("expected all tools, got %v", filtered)
+	}
+}
+
+func TestRequireSupport_RefusesUnsupportedTool(t *testing.T) {
+	requests := 0
+	server := startInfoDirector(t, bosh.Info{Version: "280.0.0"}, &requests)
+	defer server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+	called := false
+	handler := registry.requireSupport("bosh_cert_expiry", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = true
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	result, _ := handler(context.Background(), mcp.CallToolRequest{})
+	if called || !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "config_server") {
+		t.Errorf("expected bosh_variables to be refused, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshInfo(t *testing.T) {
+	requests := 0
+	info := bosh.Info{
+		Name:    "bosh",
+		UUID:    "d1f6-uuid",
+		Version: "280.0.14 (00000000)",
+		CPI:     "vsphere_cpi",
+		Features: map[string]bosh.Feature{
+			"config_server": {Status: true},
+			"local_dns":     {Status: true, Extras: map[string]any{"domain_name": "bosh"}},
+		},
+	}
+	info.UserAuthentication.Type = "uaa"
+	info.UserAuthentication.Options.URL = "https://10.0.0.6:8443"
+	server := startInfoDirector(t, info, &requests)
+	defer server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	result, _ := registry.handleBoshInfo(context.Background(), mcp.CallToolRequest{})
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	got := result.StructuredContent.(InfoResult)
+	if got.Info.UUID != "d1f6-uuid" || got.Info.UserAuthentication.Options.URL != "https://10.0.0.6:8443" || !got.Info.FeatureEnabled("local_dns") {
+		t.Errorf("unexpected info: %+v", got.Info)
+	}
+	if len(got.UnsupportedTools) != 0 {
+		t.Errorf("expected every tool to be supported, got %v", got.UnsupportedTools)
+	}
+}
@@ -0,0 +1,154 @@
+// ABOUTME: Implements bosh_cert_expiry, which scans deployments' certificate variables in CredHub.
+// ABOUTME: Lists certificates expiring soon and whether a newer version awaits a deploy.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/credhub"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultExpiryDays is how far ahead bosh_cert_expiry looks by default.
+const defaultExpiryDays = 30
+
+// ExpiringCertificate is a deployed certificate expiring within the scan
+// window. NewerVersionID is set when CredHub already holds a newer version
+// that the deployment hasn't picked up yet; a deploy will roll it out.
+type ExpiringCertificate struct {
+	Deployment     string     `json:"deployment"`
+	Name           string     `json:"name"`
+	ID             string     `json:"id"`
+	Subject        string     `json:"subject"`
+	Issuer         string     `json:"issuer"`
+	IsCA           bool       `json:"is_ca"`
+	NotAfter       time.Time  `json:"not_after"`
+	DaysRemaining  int        `json:"days_remaining"`
+	Expired        bool       `json:"expired"`
+	NewerVersionID string     `json:"newer_version_id,omitempty"`
+	NewerNotAfter  *time.Time `json:"newer_not_after,omitempty"`
+}
+
+func (r *Registry) handleBoshCertExpiry(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	deployment := request.GetString("deployment", "")
+	days := request.GetInt("days", defaultExpiryDays)
+
+	if days < 0 {
+		return mcp.NewToolResultError("days must not be negative"), nil
+	}
+
+	opts, err := parseListOptions(request, reflect.TypeOf(ExpiringCertificate{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	deployments := []string{deployment}
+	if deployment == "" {
+		all, err := client.ListDeployments()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list deployments: %v", err)), nil
+		}
+		deployments = deployments[:0]
+		for _, d := range all {
+			deployments = append(deployments, d.Name)
+		}
+	}
+
+	credhubClient, err := r.credhubClient(ctx, environment, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	now := time.Now()
+	result := CertExpiryResult{Days: days, Certificates: []ExpiringCertificate{}}
+	for _, name := range deployments {
+		variables, err := client.ListVariables(name)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to list variables: %v", name, err))
+			continue
+		}
+
+		details := make([]VariableDetails, len(variables))
+		for i, v := range variables {
+			details[i] = VariableDetails{ID: v.ID, Name: v.Name}
+		}
+		addCredHubMetadata(credhubClient, details)
+
+		for _, v := range details {
+			if v.Error != "" {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s: %s", name, v.Name, v.Error))
+			}
+			if v.Certificate != nil {
+				result.Scanned++
+			}
+		}
+
+		expiring := expiringCertificates(name, details, now, days)
+		for i := range expiring {
+			addNewerVersion(credhubClient, &expiring[i])
+		}
+		result.Certificates = append(result.Certificates, expiring...)
+	}
+
+	sort.SliceStable(result.Certificates, func(i, j int) bool {
+		return result.Certificates[i].NotAfter.Before(result.Certificates[j].NotAfter)
+	})
+	sortItems(result.Certificates, opts.Sort)
+	result.Certificates, result.Page = paginate(result.Certificates, opts)
+
+	return listResult(result, "certificates", opts, result.Page)
+}
+
+// expiringCertificates returns the certificate variables of a deployment
+// that expire within days of now, including those already expired.
+func expiringCertificates(deployment string, variables []VariableDetails, now time.Time, days int) []ExpiringCertificate {
+	cutoff := now.Add(time.Duration(days) * 24 * time.Hour)
+
+	var expiring []ExpiringCertificate
+	for _, v := range variables {
+		cert := v.Certificate
+		if cert == nil || cert.NotAfter.After(cutoff) {
+			continue
+		}
+		expiring = append(expiring, ExpiringCertificate{
+			Deployment:    deployment,
+			Name:          v.Name,
+			ID:            v.ID,
+			Subject:       cert.Subject,
+			Issuer:        cert.Issuer,
+			IsCA:          cert.IsCA,
+			NotAfter:      cert.NotAfter,
+			DaysRemaining: int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24)),
+			Expired:       !cert.NotAfter.After(now),
+		})
+	}
+	return expiring
+}
+
+// addNewerVersion records the current CredHub version of cert when it is
+// newer than the deployed one and expires later. Lookup errors are ignored
+// since the deployed version's expiry is still reported.
+func addNewerVersion(client *credhub.Client, cert *ExpiringCertificate) {
+	current, err := client.GetMetadata(cert.Name)
+	if err != nil || current.ID == cert.ID || current.Certificate == nil {
+		return
+	}
+	if !current.Certificate.NotAfter.After(cert.NotAfter) {
+		return
+	}
+	notAfter := current.Certificate.NotAfter
+	cert.NewerVersionID = current.ID
+	cert.NewerNotAfter = &notAfter
+}
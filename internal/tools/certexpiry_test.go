@@ -0,0 +1,87 @@
+// ABOUTME: Tests for bosh_cert_expiry against a fake Director, UAA, and CredHub.
+// ABOUTME: Verifies the expiry window, ordering, and detection of undeployed newer versions.
+
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/credhub"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestExpiringCertificates(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	variables := []VariableDetails{
+		{ID: "1", Name: "/bosh/cf/expired", Certificate: &credhub.CertificateMetadata{NotAfter: now.Add(-36 * time.Hour)}},
+		{ID: "2", Name: "/bosh/cf/soon", Certificate: &credhub.CertificateMetadata{NotAfter: now.Add(10 * 24 * time.Hour)}},
+		{ID: "3", Name: "/bosh/cf/later", Certificate: &credhub.CertificateMetadata{NotAfter: now.Add(90 * 24 * time.Hour)}},
+		{ID: "4", Name: "/bosh/cf/password", Type: "password"},
+	}
+
+	expiring := expiringCertificates("cf", variables, now, 30)
+	if len(expiring) != 2 {
+		t.Fatalf("expected 2 expiring certificates, got %+v", expiring)
+	}
+	if !expiring[0].Expired || expiring[0].DaysRemaining != -2 {
+		t.Errorf("expected expired certificate 2 days ago, got %+v", expiring[0])
+	}
+	if expiring[1].Expired || expiring[1].DaysRemaining != 10 || expiring[1].Deployment != "cf" {
+		t.Errorf("expected certificate expiring in 10 days, got %+v", expiring[1])
+	}
+}
+
+func TestHandleBoshCertExpiry(t *testing.T) {
+	now := time.Now()
+	certificate := func(id, name string, notAfter time.Time) map[string]any {
+		return map[string]any{"id": id, "name": name, "type": "certificate", "version_created_at": "2026-01-01T00:00:00Z",
+			"value": map[string]string{"certificate": testCertificatePEM(t, name, notAfter)}}
+	}
+
+	director := &credhubTestDirector{
+		variables: map[string][]bosh.Variable{
+			"cf":       {{ID: "r1", Name: "/bosh/cf/router_tls"}, {ID: "p1", Name: "/bosh/cf/admin_password"}},
+			"postgres": {{ID: "d1", Name: "/bosh/postgres/db_tls"}, {ID: "c1", Name: "/bosh/postgres/ca"}},
+		},
+		credentials: map[string]map[string]any{
+			"r1": certificate("r1", "/bosh/cf/router_tls", now.Add(20*24*time.Hour)),
+			"r2": certificate("r2", "/bosh/cf/router_tls", now.Add(400*24*time.Hour)),
+			"p1": {"id": "p1", "name": "/bosh/cf/admin_password", "type": "password", "value": "hunter2"},
+			"d1": certificate("d1", "/bosh/postgres/db_tls", now.Add(5*24*time.Hour)),
+			"c1": certificate("c1", "/bosh/postgres/ca", now.Add(1000*24*time.Hour)),
+		},
+		current: map[string]string{"/bosh/cf/router_tls": "r2", "/bosh/postgres/db_tls": "d1"},
+	}
+	server := director.start(t)
+	defer server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"days": 30}
+	result, _ := registry.handleBoshCertExpiry(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+
+	report := result.StructuredContent.(CertExpiryResult)
+	if report.Scanned != 3 {
+		t.Errorf("expected 3 certificates scanned, got %d", report.Scanned)
+	}
+	if len(report.Certificates) != 2 {
+		t.Fatalf("expected 2 expiring certificates, got %+v", report.Certificates)
+	}
+
+	// Soonest first
+	db, router := report.Certificates[0], report.Certificates[1]
+	if db.Deployment != "postgres" || db.Name != "/bosh/postgres/db_tls" || db.NewerVersionID != "" {
+		t.Errorf("expected postgres certificate without a newer version, got %+v", db)
+	}
+	if router.Deployment != "cf" || router.NewerVersionID != "r2" || router.NewerNotAfter == nil {
+		t.Errorf("expected router certificate with newer version r2 awaiting deploy, got %+v", router)
+	}
+}
@@ -0,0 +1,235 @@
+// ABOUTME: Implements the cleanup planner and the confirmed bosh_cleanup operation.
+// ABOUTME: Plans mirror Director clean-up semantics; confirmation tokens are bound to the plan digest.
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// cleanupKeepVersions is how many unused versions of each release and
+// stemcell the Director keeps when cleaning up without remove_all.
+const cleanupKeepVersions = 2
+
+func (r *Registry) handleBoshCleanupPlan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	removeAll := request.GetBool("all", false)
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	plan, err := buildCleanupPlan(client, removeAll)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return toolResult(plan)
+}
+
+func (r *DeploymentRegistry) handleBoshCleanup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	confirmToken := request.GetString("confirm", "")
+	removeAll := request.GetBool("all", false)
+
+	if r.config.IsBlocked("cleanup") {
+		return mcp.NewToolResultError("cleanup is blocked by configuration"), nil
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	plan, err := buildCleanupPlan(client, removeAll)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// The token is bound to the Director and the plan digest, so it is
+	// rejected on another environment, or if anything that would be
+	// removed has changed since the user confirmed.
+	resource := creds.Environment + "#" + plan.Digest
+	if r.config.RequiresConfirmation("cleanup") {
+		if confirmToken == "" {
+			token := r.tokenStore.Generate("cleanup", resource)
+			return toolResult(CleanupResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Plan:                 &plan,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to clean up %s on %s. This permanently deletes the resources in the plan. Only proceed with the confirm token if the user explicitly approves.", plan.Summary, resolvedEnvironment(creds)),
+			})
+		}
+
+		if !r.tokenStore.Validate(confirmToken, "cleanup", resource) {
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another environment or the cleanup plan changed since it was confirmed; request a new token"), nil
+		}
+	}
+
+	taskID, err := client.Cleanup(removeAll)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to start cleanup: %v", err)), nil
+	}
+
+	timeout := time.Duration(request.GetInt("timeout", 600)) * time.Second
+	task, err := client.WaitForTask(taskID, timeout, 2*time.Second)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
+	}
+
+	result := CleanupResult{
+		Plan:   &plan,
+		TaskID: task.ID,
+		State:  task.State,
+	}
+	if task.State == "done" || task.State == "error" {
+		output, err := client.GetTaskOutput(task.ID, "result")
+		if err == nil && output != "" {
+			result.Output = output
+		}
+	}
+
+	return toolResult(result)
+}
+
+// buildCleanupPlan fetches everything clean-up considers and plans it.
+func buildCleanupPlan(client *bosh.Client, removeAll bool) (CleanupPlanResult, error) {
+	releases, err := client.ListReleases()
+	if err != nil {
+		return CleanupPlanResult{}, fmt.Errorf("failed to list releases: %v", err)
+	}
+	stemcells, err := client.ListStemcells()
+	if err != nil {
+		return CleanupPlanResult{}, fmt.Errorf("failed to list stemcells: %v", err)
+	}
+	deployments, err := client.ListDeployments()
+	if err != nil {
+		return CleanupPlanResult{}, fmt.Errorf("failed to list deployments: %v", err)
+	}
+	disks, err := client.ListOrphanedDisks()
+	if err != nil {
+		return CleanupPlanResult{}, fmt.Errorf("failed to list orphaned disks: %v", err)
+	}
+	vms, err := client.ListOrphanedVMs()
+	if err != nil {
+		return CleanupPlanResult{}, fmt.Errorf("failed to list orphaned VMs: %v", err)
+	}
+
+	return planCleanup(releases, stemcells, deployments, disks, vms, removeAll), nil
+}
+
+// planCleanup lists what the Director removes on clean-up. Without removeAll,
+// the two newest unused versions of each release and stemcell and all
+// orphaned disks are kept. Orphaned VMs are always removed.
+func planCleanup(releases []bosh.Release, stemcells []bosh.Stemcell, deployments []bosh.Deployment, disks []bosh.OrphanedDisk, vms []bosh.OrphanedVM, removeAll bool) CleanupPlanResult {
+	keep := cleanupKeepVersions
+	if removeAll {
+		keep = 0
+	}
+
+	usedReleases := make(map[bosh.NameVersion]bool)
+	usedStemcells := make(map[bosh.NameVersion]bool)
+	for _, d := range deployments {
+		for _, rel := range d.Releases {
+			usedReleases[rel] = true
+		}
+		for _, sc := range d.Stemcells {
+			usedStemcells[sc] = true
+		}
+	}
+
+	unusedReleases := []bosh.NameVersion{}
+	for _, rel := range releases {
+		nv := bosh.NameVersion{Name: rel.Name, Version: rel.Version}
+		if !usedReleases[nv] {
+			unusedReleases = append(unusedReleases, nv)
+		}
+	}
+	unusedStemcells := []bosh.NameVersion{}
+	for _, sc := range stemcells {
+		nv := bosh.NameVersion{Name: sc.Name, Version: sc.Version}
+		if len(sc.Deployments) == 0 && !usedStemcells[nv] {
+			unusedStemcells = append(unusedStemcells, nv)
+		}
+	}
+
+	plan := CleanupPlanResult{
+		RemoveAll:     removeAll,
+		Releases:      exceptNewest(unusedReleases, keep),
+		Stemcells:     exceptNewest(unusedStemcells, keep),
+		OrphanedDisks: []bosh.OrphanedDisk{},
+		OrphanedVMs:   append([]bosh.OrphanedVM{}, vms...),
+	}
+	if removeAll {
+		plan.OrphanedDisks = append(plan.OrphanedDisks, disks...)
+	}
+	sort.Slice(plan.OrphanedDisks, func(i, j int) bool { return plan.OrphanedDisks[i].DiskCID < plan.OrphanedDisks[j].DiskCID })
+	sort.Slice(plan.OrphanedVMs, func(i, j int) bool { return plan.OrphanedVMs[i].CID < plan.OrphanedVMs[j].CID })
+
+	for _, disk := range plan.OrphanedDisks {
+		plan.ReclaimedDiskMB += disk.Size
+	}
+	plan.KeptOrphanedDisks = len(disks) - len(plan.OrphanedDisks)
+
+	plan.Summary = fmt.Sprintf("%d release versions, %d stemcells, %d orphaned disks (%d MB), %d orphaned VMs",
+		len(plan.Releases), len(plan.Stemcells), len(plan.OrphanedDisks), plan.ReclaimedDiskMB, len(plan.OrphanedVMs))
+	plan.Digest = cleanupDigest(plan)
+
+	return plan
+}
+
+// exceptNewest returns items other than the keep newest versions of each
+// name, sorted by name and then oldest version first.
+func exceptNewest(items []bosh.NameVersion, keep int) []bosh.NameVersion {
+	byName := make(map[string][]bosh.NameVersion)
+	for _, item := range items {
+		byName[item.Name] = append(byName[item.Name], item)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	removed := []bosh.NameVersion{}
+	for _, name := range names {
+		versions := byName[name]
+		sort.Slice(versions, func(i, j int) bool {
+			return compareVersions(versions[i].Version, versions[j].Version) < 0
+		})
+		if len(versions) > keep {
+			removed = append(removed, versions[:len(versions)-keep]...)
+		}
+	}
+	return removed
+}
+
+// cleanupDigest identifies the exact set of resources a plan removes.
+func cleanupDigest(plan CleanupPlanResult) string {
+	data, _ := json.Marshal(struct {
+		RemoveAll     bool
+		Releases      []bosh.NameVersion
+		Stemcells     []bosh.NameVersion
+		OrphanedDisks []bosh.OrphanedDisk
+		OrphanedVMs   []bosh.OrphanedVM
+	}{plan.RemoveAll, plan.Releases, plan.Stemcells, plan.OrphanedDisks, plan.OrphanedVMs})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,187 @@
+// ABOUTME: Tests for the cleanup planner and confirmed cleanup operation.
+// ABOUTME: Verifies Director clean-up semantics and that tokens are bound to the plan.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPlanCleanup(t *testing.T) {
+	releases := []bosh.Release{
+		{Name: "cf", Version: "1.1"}, {Name: "cf", Version: "1.2"}, {Name: "cf", Version: "1.10"},
+		{Name: "cf", Version: "1.3"}, {Name: "cf", Version: "1.4"},
+		{Name: "redis", Version: "3.0"},
+	}
+	stemcells := []bosh.Stemcell{
+		{Name: "jammy", Version: "1.100", Deployments: []string{"cf"}},
+		{Name: "jammy", Version: "1.90"},
+		{Name: "jammy", Version: "1.80"},
+		{Name: "jammy", Version: "1.70"},
+	}
+	deployments := []bosh.Deployment{{
+		Name:     "cf",
+		Releases: []bosh.NameVersion{{Name: "cf", Version: "1.10"}},
+	}}
+	disks := []bosh.OrphanedDisk{{DiskCID: "disk-2", Size: 2048}, {DiskCID: "disk-1", Size: 1024}}
+	vms := []bosh.OrphanedVM{{CID: "vm-1"}}
+
+	plan := planCleanup(releases, stemcells, deployments, disks, vms, false)
+
+	// Unused cf versions are 1.1-1.4; the two newest (1.3, 1.4) are kept
+	if len(plan.Releases) != 2 || plan.Releases[0].Version != "1.1" || plan.Releases[1].Version != "1.2" {
+		t.Errorf("unexpected releases: %+v", plan.Releases)
+	}
+	if len(plan.Stemcells) != 1 || plan.Stemcells[0].Version != "1.70" {
+		t.Errorf("unexpected stemcells: %+v", plan.Stemcells)
+	}
+	if len(plan.OrphanedDisks) != 0 || plan.KeptOrphanedDisks != 2 || plan.ReclaimedDiskMB != 0 {
+		t.Errorf("expected orphaned disks kept without --all: %+v", plan)
+	}
+	if len(plan.OrphanedVMs) != 1 {
+		t.Errorf("expected orphaned VMs to always be removed: %+v", plan.OrphanedVMs)
+	}
+
+	all := planCleanup(releases, stemcells, deployments, disks, vms, true)
+	if len(all.Releases) != 5 || len(all.Stemcells) != 3 {
+		t.Errorf("expected all unused releases and stemcells with --all, got %d and %d", len(all.Releases), len(all.Stemcells))
+	}
+	if all.ReclaimedDiskMB != 3072 || all.OrphanedDisks[0].DiskCID != "disk-1" {
+		t.Errorf("unexpected orphaned disks with --all: %+v", all.OrphanedDisks)
+	}
+	if all.Digest == plan.Digest {
+		t.Error("expected different plans to have different digests")
+	}
+}
+
+func TestHandleBoshCleanup_TokenBoundToPlan(t *testing.T) {
+	stemcells := []bosh.Stemcell{{Name: "jammy", Version: "1.1"}}
+	var cleanupBody string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/cleanup":
+			body, _ := io.ReadAll(r.Body)
+			cleanupBody = string(body)
+			w.Header().Set("Location", "/tasks/55")
+			w.WriteHeader(http.StatusFound)
+		case r.URL.Path == "/tasks/55":
+			json.NewEncoder(w).Encode(bosh.Task{ID: 55, State: "done"})
+		case r.URL.Path == "/tasks/55/output":
+			w.Write([]byte("Deleted 1 stemcell"))
+		case r.URL.Path == "/stemcells":
+			json.NewEncoder(w).Encode(stemcells)
+		case r.URL.Path == "/disks":
+			json.NewEncoder(w).Encode([]bosh.OrphanedDisk{{DiskCID: "disk-1", Size: 512}})
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"all": true}
+
+	result, _ := deploymentRegistry.handleBoshCleanup(context.Background(), request)
+	confirmation := result.StructuredContent.(CleanupResult)
+	if !confirmation.RequiresConfirmation || confirmation.Plan.ReclaimedDiskMB != 512 {
+		t.Fatalf("expected confirmation with plan, got %+v", confirmation)
+	}
+
+	// A stemcell uploaded after confirmation changes the plan
+	stemcells = append(stemcells, bosh.Stemcell{Name: "jammy", Version: "1.0"})
+	request.Params.Arguments = map[string]interface{}{"all": true, "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshCleanup(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "plan changed") {
+		t.Fatalf("expected token to be rejected after plan changed, got %v", result.Content)
+	}
+	if cleanupBody != "" {
+		t.Fatal("cleanup must not run with a stale token")
+	}
+
+	request.Params.Arguments = map[string]interface{}{"all": true}
+	result, _ = deploymentRegistry.handleBoshCleanup(context.Background(), request)
+	token := result.StructuredContent.(CleanupResult).ConfirmationToken
+
+	request.Params.Arguments = map[string]interface{}{"all": true, "confirm": token}
+	result, _ = deploymentRegistry.handleBoshCleanup(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("expected cleanup to run, got %v", result.Content)
+	}
+	done := result.StructuredContent.(CleanupResult)
+	if done.TaskID != 55 || done.State != "done" || done.Output != "Deleted 1 stemcell" {
+		t.Errorf("unexpected cleanup result: %+v", done)
+	}
+	if cleanupBody != `{"config":{"remove_all":true}}` {
+		t.Errorf("unexpected cleanup body: %s", cleanupBody)
+	}
+}
+
+func TestHandleBoshCleanup_Blocked(t *testing.T) {
+	cfg := config.Load("")
+	cfg.BlockedOperations = []string{"cleanup"}
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), cfg)
+
+	result, _ := deploymentRegistry.handleBoshCleanup(context.Background(), mcp.CallToolRequest{})
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "blocked") {
+		t.Errorf("expected blocked error, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshCleanup_TokenBoundToEnvironment(t *testing.T) {
+	newDirector := func() *httptest.Server {
+		return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("cleanup must not run: %s %s", r.Method, r.URL.Path)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/disks" {
+				json.NewEncoder(w).Encode([]bosh.OrphanedDisk{{DiskCID: "disk-1", Size: 512}})
+				return
+			}
+			w.Write([]byte("[]"))
+		}))
+	}
+	sandbox := newDirector()
+	defer sandbox.Close()
+	prod := newDirector()
+	defer prod.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "prod": prod.URL})
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider(configPath)), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"environment": "sandbox", "all": true}
+	result, _ := deploymentRegistry.handleBoshCleanup(context.Background(), request)
+	confirmation := result.StructuredContent.(CleanupResult)
+	if !confirmation.RequiresConfirmation || !strings.Contains(confirmation.Message, "sandbox") {
+		t.Fatalf("expected confirmation naming sandbox, got %+v", confirmation)
+	}
+
+	// The same plan on another director needs its own confirmation.
+	request.Params.Arguments = map[string]interface{}{"environment": "prod", "all": true, "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshCleanup(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "another environment") {
+		t.Errorf("expected token for sandbox to be rejected on prod, got %v", result.Content)
+	}
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Provides MCP argument completions for environments, deployments, jobs, and tasks.
+// ABOUTME: Answers completion requests for prompt arguments from the shared lookup cache.
+
+package tools
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCompletionValues is the MCP limit on values in a single completion response.
+const maxCompletionValues = 100
+
+// environmentNames returns the named environments from the BOSH config file.
+func (r *Registry) environmentNames() ([]string, error) {
+	return r.lookups.get("environments", r.authProvider.Environments)
+}
+
+// jobNames returns the unique job (instance group) names in a deployment.
+func (r *Registry) jobNames(ctx context.Context, environment, deployment string) ([]string, error) {
+	return r.lookups.get("jobs|"+environment+"|"+deployment, func() ([]string, error) {
+		client, err := r.GetClient(ctx, environment)
+		if err != nil {
+			return nil, err
+		}
+		instances, err := client.ListInstances(deployment)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		names := []string{}
+		for _, inst := range instances {
+			if !seen[inst.Job] {
+				seen[inst.Job] = true
+				names = append(names, inst.Job)
+			}
+		}
+		sort.Strings(names)
+		return names, nil
+	})
+}
+
+// recentTaskIDs returns the IDs of the most recent tasks, newest first.
+func (r *Registry) recentTaskIDs(ctx context.Context, environment string) ([]string, error) {
+	return r.lookups.get("tasks|"+environment, func() ([]string, error) {
+		client, err := r.GetClient(ctx, environment)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := client.ListTasks(bosh.TaskFilter{Limit: 50})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, strconv.Itoa(task.ID))
+		}
+		return ids, nil
+	})
+}
+
+// Completer answers MCP completion requests for the arguments of the
+// server's prompts.
+type Completer struct {
+	registry *Registry
+}
+
+// Completer returns a completion provider backed by this registry.
+func (r *Registry) Completer() *Completer {
+	return &Completer{registry: r}
+}
+
+// CompletePromptArgument provides completions for a prompt argument.
+func (c *Completer) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	return c.complete(ctx, argument, context), nil
+}
+
+// complete returns candidate values for the named argument.
+// Lookup failures yield an empty completion rather than an error.
+func (c *Completer) complete(ctx context.Context, argument mcp.CompleteArgument, context mcp.CompleteContext) *mcp.Completion {
+	environment := context.Arguments["environment"]
+
+	var candidates []string
+	var err error
+
+	switch argument.Name {
+	case "environment":
+		candidates, err = c.registry.environmentNames()
+	case "deployment":
+		candidates, err = c.registry.deploymentNames(ctx, environment)
+	case "job":
+		if deployment := context.Arguments["deployment"]; deployment != "" {
+			candidates, err = c.registry.jobNames(ctx, environment, deployment)
+		}
+	case "task_id":
+		candidates, err = c.registry.recentTaskIDs(ctx, environment)
+	}
+
+	if err != nil {
+		candidates = nil
+	}
+
+	values := matchCompletions(candidates, argument.Value)
+	completion := &mcp.Completion{
+		Values: values,
+		Total:  len(values),
+	}
+	if len(values) > maxCompletionValues {
+		completion.Values = values[:maxCompletionValues]
+		completion.HasMore = true
+	}
+
+	return completion
+}
+
+// matchCompletions returns candidates starting with value, followed by
+// candidates containing it. Matching is case-insensitive.
+func matchCompletions(candidates []string, value string) []string {
+	needle := strings.ToLower(value)
+	prefix := []string{}
+	contains := []string{}
+
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		switch {
+		case strings.HasPrefix(lower, needle):
+			prefix = append(prefix, candidate)
+		case strings.Contains(lower, needle):
+			contains = append(contains, candidate)
+		}
+	}
+
+	return append(prefix, contains...)
+}
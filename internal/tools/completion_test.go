@@ -0,0 +1,114 @@
+// ABOUTME: Tests for argument completions of prompt arguments.
+// ABOUTME: Uses httptest to mock the BOSH Director and a temp BOSH config file.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newCompletionTestDirector serves deployments, instances, and tasks, and
+// counts deployment lookups.
+func newCompletionTestDirector(t *testing.T, deploymentCalls *int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/deployments":
+			*deploymentCalls++
+			json.NewEncoder(w).Encode([]bosh.Deployment{{Name: "cf"}, {Name: "redis"}, {Name: "cf-mysql"}})
+		case "/deployments/cf/instances":
+			json.NewEncoder(w).Encode([]bosh.Instance{{Job: "router", ID: "r1"}, {Job: "router", ID: "r2"}, {Job: "api", ID: "a1"}})
+		case "/tasks":
+			json.NewEncoder(w).Encode([]bosh.Task{{ID: 142}, {ID: 141}, {ID: 98}})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestCompleter_Arguments(t *testing.T) {
+	deploymentCalls := 0
+	sandbox := newCompletionTestDirector(t, &deploymentCalls)
+	defer sandbox.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "staging": sandbox.URL, "prod": sandbox.URL})
+	completer := NewRegistry(auth.NewProvider(configPath)).Completer()
+
+	tests := []struct {
+		name     string
+		argument mcp.CompleteArgument
+		context  map[string]string
+		want     []string
+	}{
+		{"environments", mcp.CompleteArgument{Name: "environment", Value: "s"}, nil, []string{"sandbox", "staging"}},
+		{"deployments, prefix matches first", mcp.CompleteArgument{Name: "deployment", Value: "my"}, map[string]string{"environment": "sandbox"}, []string{"cf-mysql"}},
+		{"deployments", mcp.CompleteArgument{Name: "deployment", Value: "cf"}, map[string]string{"environment": "sandbox"}, []string{"cf", "cf-mysql"}},
+		{"jobs of the deployment", mcp.CompleteArgument{Name: "job", Value: ""}, map[string]string{"environment": "sandbox", "deployment": "cf"}, []string{"api", "router"}},
+		{"jobs need a deployment", mcp.CompleteArgument{Name: "job", Value: ""}, map[string]string{"environment": "sandbox"}, []string{}},
+		{"task IDs", mcp.CompleteArgument{Name: "task_id", Value: "14"}, map[string]string{"environment": "sandbox"}, []string{"142", "141"}},
+		{"unknown environment", mcp.CompleteArgument{Name: "deployment", Value: ""}, map[string]string{"environment": "missing"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completion, err := completer.CompletePromptArgument(context.Background(), "diagnose_deployment", tt.argument, mcp.CompleteContext{Arguments: tt.context})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(completion.Values) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, completion.Values)
+			}
+			for i := range tt.want {
+				if completion.Values[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, completion.Values)
+				}
+			}
+		})
+	}
+
+	if deploymentCalls != 1 {
+		t.Errorf("expected deployments to be looked up once and cached, got %d calls", deploymentCalls)
+	}
+}
+
+func TestCompleter_ReachableThroughPrompts(t *testing.T) {
+	deploymentCalls := 0
+	director := newCompletionTestDirector(t, &deploymentCalls)
+	defer director.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", director.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(director))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+	s := server.NewMCPServer("test", "0.0.0",
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(registry.Completer()),
+	)
+	registry.RegisterPrompts(s)
+
+	message := `{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":{` +
+		`"ref":{"type":"ref/prompt","name":"diagnose_deployment"},` +
+		`"argument":{"name":"deployment","value":"re"}}}`
+	response, ok := s.HandleMessage(context.Background(), json.RawMessage(message)).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a completion response, got %+v", response)
+	}
+	result, ok := response.Result.(mcp.CompleteResult)
+	if !ok || len(result.Completion.Values) != 1 || result.Completion.Values[0] != "redis" {
+		t.Errorf("expected redis, got %+v", response.Result)
+	}
+}
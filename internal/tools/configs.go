@@ -0,0 +1,318 @@
+// ABOUTME: Implements generic config tools (list and history, diff, update, delete).
+// ABOUTME: Updates show the Director's diff in the confirmation response and are bound to it.
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigName is the name the BOSH CLI uses when none is given.
+const defaultConfigName = "default"
+
+func (r *Registry) handleBoshConfigs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	id := request.GetString("id", "")
+	filter := bosh.ConfigFilter{
+		Type:    request.GetString("type", ""),
+		Name:    request.GetString("name", ""),
+		History: request.GetBool("history", false),
+		Limit:   request.GetInt("limit", 0),
+	}
+
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.Config{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	// A single version is returned with its content
+	if id != "" {
+		config, err := client.GetConfig(id)
+		if err != nil {
+			if bosh.IsNotFound(err) {
+				return mcp.NewToolResultError(fmt.Sprintf("config '%s' not found", id)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get config: %v", err)), nil
+		}
+		return toolResult(ConfigsResult{Configs: []bosh.Config{*config}})
+	}
+
+	configs, err := client.ListConfigs(filter)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list configs: %v", err)), nil
+	}
+
+	// Content can be large; fetch a version by id to see it
+	for i := range configs {
+		configs[i].Content = ""
+	}
+
+	sortItems(configs, opts.Sort)
+	configs, page := paginate(configs, opts)
+
+	result := ConfigsResult{
+		Configs: configs,
+		Page:    page,
+	}
+
+	return listResult(result, "configs", opts, page)
+}
+
+func (r *Registry) handleBoshConfigDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	configType := request.GetString("type", "")
+	name := request.GetString("name", defaultConfigName)
+	content := request.GetString("content", "")
+
+	if configType == "" || content == "" {
+		return mcp.NewToolResultError("type and content are required"), nil
+	}
+	if err := validateConfigContent(content); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	diff, err := diffProposedConfig(client, configType, name, content)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return toolResult(diff)
+}
+
+func (r *DeploymentRegistry) handleBoshUpdateConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	configType := request.GetString("type", "")
+	name := request.GetString("name", defaultConfigName)
+	content := request.GetString("content", "")
+	remove := request.GetBool("delete", false)
+	confirmToken := request.GetString("confirm", "")
+
+	if configType == "" {
+		return mcp.NewToolResultError("type is required"), nil
+	}
+	if remove && content != "" {
+		return mcp.NewToolResultError("specify either content or delete, not both"), nil
+	}
+	if !remove {
+		if content == "" {
+			return mcp.NewToolResultError("content is required unless delete is set"), nil
+		}
+		if err := validateConfigContent(content); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	// Deleting a config is its own operation, so it can be blocked or
+	// confirmed separately from updates.
+	operation := "update_config"
+	if remove {
+		operation = "delete_config"
+	}
+	if r.config.IsBlocked(operation) {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is blocked by configuration", operation)), nil
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	var diff ConfigDiffResult
+	if remove {
+		diff, err = diffDeletedConfig(client, configType, name)
+	} else {
+		diff, err = diffProposedConfig(client, configType, name, content)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	target := configType + "/" + name
+
+	if !diff.Changed {
+		return toolResult(ConfigUpdateResult{
+			Operation: operation,
+			Type:      configType,
+			Name:      name,
+			Diff:      &diff,
+			Message:   fmt.Sprintf("config '%s' is unchanged; nothing to do", target),
+		})
+	}
+
+	// The token is bound to the Director, the content and the version it
+	// was diffed against, so it is rejected on another environment or if
+	// the config changes before confirmation.
+	resource := configTokenResource(creds.Environment, target, diff.LatestID, content, remove)
+	if r.config.RequiresConfirmation(operation) {
+		if confirmToken == "" {
+			token := r.tokenStore.Generate(operation, resource)
+			verb := "update"
+			if remove {
+				verb = "delete"
+			}
+			return toolResult(ConfigUpdateResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            operation,
+				Type:                 configType,
+				Name:                 name,
+				Diff:                 &diff,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Show the user the diff and ask them to confirm they want to %s config '%s' on %s (%d lines added, %d removed). This affects every deployment that uses it on its next deploy. Only proceed with the confirm token if the user explicitly approves.", verb, target, resolvedEnvironment(creds), diff.Added, diff.Removed),
+			})
+		}
+
+		if !r.tokenStore.Validate(confirmToken, operation, resource) {
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another environment or the config changed since it was confirmed; request a new token"), nil
+		}
+	}
+
+	result := ConfigUpdateResult{
+		Operation: operation,
+		Type:      configType,
+		Name:      name,
+		Diff:      &diff,
+	}
+
+	if remove {
+		if err := client.DeleteConfig(configType, name); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to delete config: %v", err)), nil
+		}
+		result.Message = fmt.Sprintf("deleted config '%s'", target)
+		return toolResult(result)
+	}
+
+	config, err := client.UpdateConfig(configType, name, content, diff.LatestID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to update config: %v", err)), nil
+	}
+	config.Content = ""
+	result.Config = config
+	result.Message = fmt.Sprintf("updated config '%s' to version %s", target, config.ID)
+
+	return toolResult(result)
+}
+
+// diffProposedConfig diffs content against the latest config of the given
+// type and name using the Director's diff.
+func diffProposedConfig(client *bosh.Client, configType, name, content string) (ConfigDiffResult, error) {
+	latest, err := latestConfig(client, configType, name)
+	if err != nil {
+		return ConfigDiffResult{}, err
+	}
+
+	lines, err := client.DiffConfig(configType, name, content)
+	if err != nil {
+		return ConfigDiffResult{}, fmt.Errorf("failed to diff config: %v", err)
+	}
+
+	diff := newConfigDiffResult(configType, name, lines)
+	if latest != nil {
+		diff.LatestID = latest.ID
+	}
+	return diff, nil
+}
+
+// diffDeletedConfig shows every line of the latest config as removed.
+func diffDeletedConfig(client *bosh.Client, configType, name string) (ConfigDiffResult, error) {
+	latest, err := latestConfig(client, configType, name)
+	if err != nil {
+		return ConfigDiffResult{}, err
+	}
+	if latest == nil {
+		return ConfigDiffResult{}, fmt.Errorf("config '%s/%s' not found", configType, name)
+	}
+
+	config, err := client.GetConfig(latest.ID)
+	if err != nil {
+		return ConfigDiffResult{}, fmt.Errorf("failed to get config: %v", err)
+	}
+
+	var lines []bosh.ConfigDiffLine
+	for _, line := range strings.Split(strings.TrimRight(config.Content, "\n"), "\n") {
+		lines = append(lines, bosh.ConfigDiffLine{Line: line, Change: "removed"})
+	}
+
+	diff := newConfigDiffResult(configType, name, lines)
+	diff.LatestID = latest.ID
+	return diff, nil
+}
+
+// latestConfig returns the current version of a config, or nil if none exists.
+func latestConfig(client *bosh.Client, configType, name string) (*bosh.Config, error) {
+	configs, err := client.ListConfigs(bosh.ConfigFilter{Type: configType, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %v", err)
+	}
+	for _, config := range configs {
+		if config.Type == configType && config.Name == name {
+			return &config, nil
+		}
+	}
+	return nil, nil
+}
+
+func newConfigDiffResult(configType, name string, lines []bosh.ConfigDiffLine) ConfigDiffResult {
+	diff := ConfigDiffResult{
+		Type: configType,
+		Name: name,
+		Diff: []bosh.ConfigDiffLine{},
+	}
+	for _, line := range lines {
+		switch line.Change {
+		case "added":
+			diff.Added++
+		case "removed":
+			diff.Removed++
+		}
+		diff.Diff = append(diff.Diff, line)
+	}
+	diff.Changed = diff.Added > 0 || diff.Removed > 0
+	return diff
+}
+
+// validateConfigContent rejects content that isn't a YAML document before it
+// reaches the Director.
+func validateConfigContent(content string) error {
+	var doc any
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return fmt.Errorf("content is not valid YAML: %v", err)
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return fmt.Errorf("content must be a YAML mapping")
+	}
+	return nil
+}
+
+// configTokenResource identifies a config change on a Director for
+// confirmation tokens.
+func configTokenResource(director, target, latestID, content string, remove bool) string {
+	if remove {
+		return fmt.Sprintf("%s#%s@%s:delete", director, target, latestID)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf("%s#%s@%s:%s", director, target, latestID, hex.EncodeToString(sum[:8]))
+}
@@ -0,0 +1,242 @@
+// ABOUTME: Tests for generic config tools.
+// ABOUTME: Verifies the diff is shown on confirmation and tokens are bound to the diffed version.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// configTestDirector serves a single runtime config whose latest version can
+// be changed by the test.
+type configTestDirector struct {
+	latestID string
+	diff     string
+	updates  []map[string]string
+	deletes  []string
+}
+
+func (d *configTestDirector) start(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/configs":
+			json.NewEncoder(w).Encode([]bosh.Config{{ID: d.latestID, Type: "runtime", Name: "dns", Content: "addons: []", Current: true}})
+		case r.Method == http.MethodGet && r.URL.Path == "/configs/"+d.latestID:
+			json.NewEncoder(w).Encode(bosh.Config{ID: d.latestID, Type: "runtime", Name: "dns", Content: "addons:\n- name: dns\n"})
+		case r.Method == http.MethodPost && r.URL.Path == "/configs/diff":
+			w.Write([]byte(d.diff))
+		case r.Method == http.MethodPost && r.URL.Path == "/configs":
+			var fields map[string]string
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &fields)
+			d.updates = append(d.updates, fields)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(bosh.Config{ID: "9", Type: fields["type"], Name: fields["name"], Content: fields["content"], Current: true})
+		case r.Method == http.MethodDelete && r.URL.Path == "/configs":
+			d.deletes = append(d.deletes, r.URL.RawQuery)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	return server
+}
+
+func TestHandleBoshUpdateConfig_ShowsDiffAndBindsToken(t *testing.T) {
+	director := &configTestDirector{
+		latestID: "5",
+		diff:     `{"diff":[["addons:",null],["- name: dns","removed"],["- name: syslog","added"]]}`,
+	}
+	server := director.start(t)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+	args := map[string]interface{}{"type": "runtime", "name": "dns", "content": "addons:\n- name: syslog\n"}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, _ := deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	confirmation := result.StructuredContent.(ConfigUpdateResult)
+	if !confirmation.RequiresConfirmation || confirmation.Diff == nil {
+		t.Fatalf("expected confirmation with diff, got %v", result.Content)
+	}
+	if confirmation.Diff.Added != 1 || confirmation.Diff.Removed != 1 || confirmation.Diff.LatestID != "5" {
+		t.Errorf("unexpected diff: %+v", confirmation.Diff)
+	}
+	if len(director.updates) != 0 {
+		t.Fatal("config must not be updated before confirmation")
+	}
+
+	// Someone else updates the config after the diff was shown
+	director.latestID = "6"
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "content": args["content"], "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "config changed") {
+		t.Fatalf("expected stale token to be rejected, got %v", result.Content)
+	}
+
+	request.Params.Arguments = args
+	result, _ = deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	token := result.StructuredContent.(ConfigUpdateResult).ConfirmationToken
+
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "content": args["content"], "confirm": token}
+	result, _ = deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(director.updates) != 1 || director.updates[0]["expected_latest_id"] != "6" || director.updates[0]["name"] != "dns" {
+		t.Errorf("unexpected updates: %v", director.updates)
+	}
+	if updated := result.StructuredContent.(ConfigUpdateResult); updated.Config == nil || updated.Config.ID != "9" {
+		t.Errorf("expected updated config version, got %+v", updated)
+	}
+}
+
+func TestHandleBoshUpdateConfig_Unchanged(t *testing.T) {
+	director := &configTestDirector{latestID: "5", diff: `{"diff":[]}`}
+	server := director.start(t)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "content": "addons: []"}
+	result, _ := deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+
+	unchanged := result.StructuredContent.(ConfigUpdateResult)
+	if unchanged.RequiresConfirmation || unchanged.Diff.Changed || len(director.updates) != 0 {
+		t.Errorf("expected no-op for unchanged config, got %+v", unchanged)
+	}
+}
+
+func TestHandleBoshUpdateConfig_Delete(t *testing.T) {
+	director := &configTestDirector{latestID: "5"}
+	server := director.start(t)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "delete": true}
+	result, _ := deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	confirmation := result.StructuredContent.(ConfigUpdateResult)
+	if confirmation.Operation != "delete_config" || confirmation.Diff.Removed != 2 || confirmation.Diff.Added != 0 {
+		t.Fatalf("expected every line removed, got %+v", confirmation.Diff)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "delete": true, "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(director.deletes) != 1 || director.deletes[0] != "name=dns&type=runtime" {
+		t.Errorf("unexpected deletes: %v", director.deletes)
+	}
+}
+
+func TestHandleBoshUpdateConfig_DeleteBlockedSeparately(t *testing.T) {
+	director := &configTestDirector{latestID: "5", diff: `{"diff":[["- name: syslog","added"]]}`}
+	server := director.start(t)
+	defer server.Close()
+
+	cfg := config.Load("")
+	cfg.BlockedOperations = []string{"delete_config"}
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), cfg)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "delete": true}
+	result, _ := deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "delete_config is blocked") {
+		t.Errorf("expected delete to be blocked, got %v", result.Content)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"type": "runtime", "name": "dns", "content": "addons:\n- name: syslog\n"}
+	result, _ = deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	if result.IsError || !result.StructuredContent.(ConfigUpdateResult).RequiresConfirmation {
+		t.Errorf("expected update to still be allowed, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshUpdateConfig_TokenBoundToEnvironment(t *testing.T) {
+	sandboxDirector := &configTestDirector{latestID: "5"}
+	sandbox := sandboxDirector.start(t)
+	defer sandbox.Close()
+	prodDirector := &configTestDirector{latestID: "5"}
+	prod := prodDirector.start(t)
+	defer prod.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "prod": prod.URL})
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider(configPath)), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"environment": "sandbox", "type": "runtime", "name": "dns", "delete": true}
+	result, _ := deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	confirmation := result.StructuredContent.(ConfigUpdateResult)
+	if !confirmation.RequiresConfirmation || !strings.Contains(confirmation.Message, "sandbox") {
+		t.Fatalf("expected confirmation naming sandbox, got %+v", confirmation)
+	}
+
+	// The same config version on another director needs its own confirmation.
+	request.Params.Arguments = map[string]interface{}{"environment": "prod", "type": "runtime", "name": "dns", "delete": true, "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshUpdateConfig(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "another environment") {
+		t.Errorf("expected token for sandbox to be rejected on prod, got %v", result.Content)
+	}
+	if len(prodDirector.deletes) != 0 {
+		t.Errorf("expected no deletes on prod, got %v", prodDirector.deletes)
+	}
+}
+
+func TestHandleBoshConfigDiff_RejectsInvalidYAML(t *testing.T) {
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"type": "cloud", "content": "vm_types: [unclosed"}
+	result, _ := registry.handleBoshConfigDiff(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "not valid YAML") {
+		t.Errorf("expected YAML error, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshConfigs_OmitsContentFromList(t *testing.T) {
+	director := &configTestDirector{latestID: "5"}
+	server := director.start(t)
+	defer server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	result, _ := registry.handleBoshConfigs(context.Background(), mcp.CallToolRequest{})
+	configs := result.StructuredContent.(ConfigsResult).Configs
+	if len(configs) != 1 || configs[0].Content != "" {
+		t.Errorf("expected listed config without content, got %+v", configs)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"id": "5"}
+	result, _ = registry.handleBoshConfigs(context.Background(), request)
+	configs = result.StructuredContent.(ConfigsResult).Configs
+	if len(configs) != 1 || configs[0].Content == "" {
+		t.Errorf("expected config version with content, got %+v", configs)
+	}
+}
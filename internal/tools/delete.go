@@ -0,0 +1,233 @@
+// ABOUTME: Implements bosh_delete_release and bosh_delete_stemcell.
+// ABOUTME: Refuses to delete releases or stemcells deployments still use unless forced.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func (r *DeploymentRegistry) handleBoshDeleteRelease(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	version := request.GetString("version", "")
+	environment := request.GetString("environment", "")
+	force := request.GetBool("force", false)
+
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	if r.config.IsBlocked("delete_release") {
+		return mcp.NewToolResultError("delete_release is blocked by configuration"), nil
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	releases, err := client.ListReleases()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list releases: %v", err)), nil
+	}
+	found := false
+	for _, rel := range releases {
+		if rel.Name == name && (version == "" || rel.Version == version) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return mcp.NewToolResultError(fmt.Sprintf("release '%s' not found", releaseTarget(name, version))), nil
+	}
+
+	deployments, err := client.ListDeployments()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list deployments: %v", err)), nil
+	}
+	usedBy := releaseUsers(deployments, name, version)
+
+	return r.deleteArtifact(request, creds, client, "delete_release", "release", name, version, usedBy, force, func() (int, error) {
+		return client.DeleteRelease(name, version, force)
+	})
+}
+
+func (r *DeploymentRegistry) handleBoshDeleteStemcell(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	version := request.GetString("version", "")
+	environment := request.GetString("environment", "")
+	force := request.GetBool("force", false)
+
+	if name == "" || version == "" {
+		return mcp.NewToolResultError("name and version are required"), nil
+	}
+
+	if r.config.IsBlocked("delete_stemcell") {
+		return mcp.NewToolResultError("delete_stemcell is blocked by configuration"), nil
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	stemcells, err := client.ListStemcells()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list stemcells: %v", err)), nil
+	}
+	var stemcell *bosh.Stemcell
+	for i := range stemcells {
+		if stemcells[i].Name == name && stemcells[i].Version == version {
+			stemcell = &stemcells[i]
+			break
+		}
+	}
+	if stemcell == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("stemcell '%s/%s' not found", name, version)), nil
+	}
+
+	deployments, err := client.ListDeployments()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list deployments: %v", err)), nil
+	}
+	usedBy := stemcellUsers(*stemcell, deployments)
+
+	return r.deleteArtifact(request, creds, client, "delete_stemcell", "stemcell", name, version, usedBy, force, func() (int, error) {
+		return client.DeleteStemcell(name, version, force)
+	})
+}
+
+// deleteArtifact refuses to delete a release or stemcell that is in use
+// unless forced, asks for confirmation, then runs remove and waits for the task.
+func (r *DeploymentRegistry) deleteArtifact(request mcp.CallToolRequest, creds *auth.Credentials, client *bosh.Client, operation, kind, name, version string, usedBy []string, force bool, remove func() (int, error)) (*mcp.CallToolResult, error) {
+	confirmToken := request.GetString("confirm", "")
+	target := releaseTarget(name, version)
+
+	if len(usedBy) > 0 && !force {
+		return mcp.NewToolResultError(fmt.Sprintf("%s '%s' is used by deployments: %s; delete or upgrade them first, or set force", kind, target, strings.Join(usedBy, ", "))), nil
+	}
+
+	// The Director and forcing are part of the resource so a token can't be
+	// used on another environment, or for a forced delete after a plain one.
+	resource := creds.Environment + "#" + target
+	if force {
+		resource += ":force"
+	}
+
+	if r.config.RequiresConfirmation(operation) {
+		if confirmToken == "" {
+			token := r.tokenStore.Generate(operation, resource)
+			message := fmt.Sprintf("STOP: Ask the user to confirm they want to delete %s '%s' on %s. This action is irreversible. Only proceed with the confirm token if the user explicitly approves.", kind, target, resolvedEnvironment(creds))
+			if len(usedBy) > 0 {
+				message = fmt.Sprintf("STOP: Ask the user to confirm they want to force delete %s '%s' on %s, which is still used by deployments: %s. Those deployments will fail to recreate or scale VMs. Only proceed with the confirm token if the user explicitly approves.", kind, target, resolvedEnvironment(creds), strings.Join(usedBy, ", "))
+			}
+			return toolResult(DeleteArtifactResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            operation,
+				Name:                 name,
+				Version:              version,
+				UsedBy:               usedBy,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              message,
+			})
+		}
+
+		if !r.tokenStore.Validate(confirmToken, operation, resource) {
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another environment or without force; request a new token"), nil
+		}
+	}
+
+	taskID, err := remove()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete %s: %v", kind, err)), nil
+	}
+
+	timeout := time.Duration(request.GetInt("timeout", 600)) * time.Second
+	task, err := client.WaitForTask(taskID, timeout, 2*time.Second)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
+	}
+
+	result := DeleteArtifactResult{
+		Operation: operation,
+		Name:      name,
+		Version:   version,
+		UsedBy:    usedBy,
+		TaskID:    task.ID,
+		State:     task.State,
+	}
+	if task.State == "done" || task.State == "error" {
+		output, err := client.GetTaskOutput(task.ID, "result")
+		if err == nil && output != "" {
+			result.Output = output
+		}
+	}
+
+	return toolResult(result)
+}
+
+// releaseUsers returns the deployments using a release version, or any
+// version of the release if version is empty.
+func releaseUsers(deployments []bosh.Deployment, name, version string) []string {
+	var users []string
+	for _, d := range deployments {
+		for _, rel := range d.Releases {
+			if rel.Name == name && (version == "" || rel.Version == version) {
+				users = append(users, d.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// stemcellUsers returns the deployments using a stemcell, from both the
+// stemcell's own list and each deployment's stemcells.
+func stemcellUsers(stemcell bosh.Stemcell, deployments []bosh.Deployment) []string {
+	seen := make(map[string]bool)
+	var users []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			users = append(users, name)
+		}
+	}
+
+	for _, name := range stemcell.Deployments {
+		add(name)
+	}
+	for _, d := range deployments {
+		for _, sc := range d.Stemcells {
+			if sc.Name == stemcell.Name && sc.Version == stemcell.Version {
+				add(d.Name)
+			}
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+func releaseTarget(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "/" + version
+}
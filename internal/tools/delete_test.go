@@ -0,0 +1,208 @@
+// ABOUTME: Tests for release and stemcell deletion tools.
+// ABOUTME: Verifies in-use releases and stemcells are refused unless forced.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newDeleteTestDirector serves releases, stemcells, and deployments using
+// cf/2.0 and jammy/1.2, and records delete requests.
+func newDeleteTestDirector(t *testing.T, deletes *[]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodDelete:
+			*deletes = append(*deletes, r.URL.Path+"?"+r.URL.RawQuery)
+			w.Header().Set("Location", "/tasks/90")
+			w.WriteHeader(http.StatusFound)
+		case r.URL.Path == "/releases":
+			json.NewEncoder(w).Encode([]bosh.Release{{Name: "cf", Version: "1.0"}, {Name: "cf", Version: "2.0"}})
+		case r.URL.Path == "/stemcells":
+			json.NewEncoder(w).Encode([]bosh.Stemcell{{Name: "jammy", Version: "1.1"}, {Name: "jammy", Version: "1.2"}})
+		case r.URL.Path == "/deployments":
+			json.NewEncoder(w).Encode([]bosh.Deployment{{
+				Name:      "cf",
+				Releases:  []bosh.NameVersion{{Name: "cf", Version: "2.0"}},
+				Stemcells: []bosh.NameVersion{{Name: "jammy", Version: "1.2"}},
+			}})
+		case r.URL.Path == "/tasks/90":
+			json.NewEncoder(w).Encode(bosh.Task{ID: 90, State: "done"})
+		case r.URL.Path == "/tasks/90/output":
+			w.Write([]byte(""))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	return server
+}
+
+func callWithConfirmation(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, _ := handler(context.Background(), request)
+	if result.IsError {
+		return result
+	}
+
+	confirmation, ok := result.StructuredContent.(DeleteArtifactResult)
+	if !ok || !confirmation.RequiresConfirmation {
+		t.Fatalf("expected confirmation, got %v", result.Content)
+	}
+
+	confirmed := map[string]interface{}{"confirm": confirmation.ConfirmationToken}
+	for key, value := range args {
+		confirmed[key] = value
+	}
+	request.Params.Arguments = confirmed
+	result, _ = handler(context.Background(), request)
+	return result
+}
+
+func TestHandleBoshDeleteRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       map[string]interface{}
+		wantError  string
+		wantDelete string
+	}{
+		{"unused version", map[string]interface{}{"name": "cf", "version": "1.0"}, "", "/releases/cf?version=1.0"},
+		{"used version", map[string]interface{}{"name": "cf", "version": "2.0"}, "used by deployments: cf", ""},
+		{"all versions of used release", map[string]interface{}{"name": "cf"}, "used by deployments: cf", ""},
+		{"forced", map[string]interface{}{"name": "cf", "version": "2.0", "force": true}, "", "/releases/cf?force=true&version=2.0"},
+		{"unknown", map[string]interface{}{"name": "cf", "version": "9.9"}, "not found", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletes []string
+			server := newDeleteTestDirector(t, &deletes)
+			defer server.Close()
+
+			deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+			result := callWithConfirmation(t, deploymentRegistry.handleBoshDeleteRelease, tt.args)
+
+			if tt.wantError != "" {
+				if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, tt.wantError) {
+					t.Errorf("expected error containing %q, got %v", tt.wantError, result.Content)
+				}
+				if len(deletes) != 0 {
+					t.Errorf("expected no delete, got %v", deletes)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("unexpected error: %v", result.Content)
+			}
+			if len(deletes) != 1 || deletes[0] != tt.wantDelete {
+				t.Errorf("expected delete %s, got %v", tt.wantDelete, deletes)
+			}
+		})
+	}
+}
+
+func TestHandleBoshDeleteStemcell(t *testing.T) {
+	var deletes []string
+	server := newDeleteTestDirector(t, &deletes)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	result := callWithConfirmation(t, deploymentRegistry.handleBoshDeleteStemcell, map[string]interface{}{"name": "jammy", "version": "1.2"})
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "used by deployments: cf") {
+		t.Errorf("expected in-use stemcell to be refused, got %v", result.Content)
+	}
+
+	result = callWithConfirmation(t, deploymentRegistry.handleBoshDeleteStemcell, map[string]interface{}{"name": "jammy", "version": "1.1"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(deletes) != 1 || deletes[0] != "/stemcells/jammy/1.1?" {
+		t.Errorf("unexpected deletes: %v", deletes)
+	}
+}
+
+func TestHandleBoshDeleteRelease_ForceTokenNotReusable(t *testing.T) {
+	var deletes []string
+	server := newDeleteTestDirector(t, &deletes)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"name": "cf", "version": "1.0"}
+	result, _ := deploymentRegistry.handleBoshDeleteRelease(context.Background(), request)
+	token := result.StructuredContent.(DeleteArtifactResult).ConfirmationToken
+
+	request.Params.Arguments = map[string]interface{}{"name": "cf", "version": "1.0", "force": true, "confirm": token}
+	result, _ = deploymentRegistry.handleBoshDeleteRelease(context.Background(), request)
+	if !result.IsError || len(deletes) != 0 {
+		t.Errorf("expected token for unforced delete to be rejected for a forced delete, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshDeleteRelease_TokenBoundToEnvironment(t *testing.T) {
+	var deletes []string
+	sandbox := newDeleteTestDirector(t, &deletes)
+	defer sandbox.Close()
+	prod := newDeleteTestDirector(t, &deletes)
+	defer prod.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "prod": prod.URL})
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider(configPath)), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"environment": "sandbox", "name": "cf", "version": "1.0"}
+	result, _ := deploymentRegistry.handleBoshDeleteRelease(context.Background(), request)
+	confirmation := result.StructuredContent.(DeleteArtifactResult)
+	if !confirmation.RequiresConfirmation || !strings.Contains(confirmation.Message, "sandbox") {
+		t.Fatalf("expected confirmation naming sandbox, got %+v", confirmation)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"environment": "prod", "name": "cf", "version": "1.0", "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshDeleteRelease(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "another environment") || len(deletes) != 0 {
+		t.Errorf("expected token for sandbox to be rejected on prod, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshDeleteArtifacts_Blocked(t *testing.T) {
+	cfg := config.Load("")
+	cfg.BlockedOperations = []string{"delete_release", "delete_stemcell"}
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), cfg)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"name": "cf", "version": "1.0"}
+
+	for name, handler := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"delete_release":  deploymentRegistry.handleBoshDeleteRelease,
+		"delete_stemcell": deploymentRegistry.handleBoshDeleteStemcell,
+	} {
+		result, _ := handler(context.Background(), request)
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "blocked") {
+			t.Errorf("expected %s to be blocked, got %v", name, result.Content)
+		}
+	}
+}
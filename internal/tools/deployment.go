@@ -5,12 +5,14 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/malston/bosh-mcp-server/internal/confirm"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
 	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/malston/bosh-mcp-server/internal/confirm"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -51,16 +53,14 @@ func (r *DeploymentRegistry) handleBoshDeleteDeployment(ctx context.Context, req
 		if confirmToken == "" {
 			// Generate confirmation token
 			token := r.tokenStore.Generate("delete_deployment", deployment)
-			result := map[string]interface{}{
-				"requires_confirmation": true,
-				"confirmation_token":    token,
-				"operation":             "delete_deployment",
-				"deployment":            deployment,
-				"expires_in_seconds":    r.config.TokenTTL,
-				"message":               fmt.Sprintf("STOP: Ask the user to confirm they want to delete deployment '%s'. This action is irreversible. Only proceed with the confirm token if the user explicitly approves.", deployment),
-			}
-			jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+			return toolResult(OperationResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            "delete_deployment",
+				Deployment:           deployment,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to delete deployment '%s'. This action is irreversible. Only proceed with the confirm token if the user explicitly approves.", deployment),
+			})
 		}
 
 		// Validate confirmation token
@@ -69,15 +69,16 @@ func (r *DeploymentRegistry) handleBoshDeleteDeployment(ctx context.Context, req
 		}
 	}
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
 
 	taskID, err := client.DeleteDeployment(deployment, force)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to delete deployment: %v", err)), nil
+		return r.deploymentError(ctx, environment, deployment, "delete deployment", err), nil
 	}
+	r.lookups.invalidate("deployments|" + environment)
 
 	// Wait for task completion
 	timeout := time.Duration(request.GetInt("timeout", 600)) * time.Second
@@ -86,28 +87,25 @@ func (r *DeploymentRegistry) handleBoshDeleteDeployment(ctx context.Context, req
 		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task_id":    task.ID,
-		"state":      task.State,
-		"deployment": deployment,
+	result := OperationResult{
+		TaskID:     task.ID,
+		State:      task.State,
+		Deployment: deployment,
 	}
 
 	// Include output for completed tasks
 	if task.State == "done" || task.State == "error" {
 		output, err := client.GetTaskOutput(task.ID, "result")
 		if err == nil && output != "" {
-			result["output"] = output
+			result.Output = output
 		}
 	}
 
-	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *DeploymentRegistry) handleBoshRecreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	deployment := request.GetString("deployment", "")
-	job := request.GetString("job", "")
-	index := request.GetString("index", "")
 	environment := request.GetString("environment", "")
 	confirmToken := request.GetString("confirm", "")
 
@@ -115,44 +113,50 @@ func (r *DeploymentRegistry) handleBoshRecreate(ctx context.Context, request mcp
 		return mcp.NewToolResultError("deployment is required"), nil
 	}
 
-	resource := deployment
-	if job != "" {
-		resource = deployment + "/" + job
+	job, instance, err := parseInstanceTarget(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts, err := parseJobStateOptions(request, "recreate")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	target := instanceTargetName(deployment, job, instance)
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	resource := jobStateResource(creds.Environment, target, "recreate", opts)
 
 	if r.config.RequiresConfirmation("recreate") {
 		if confirmToken == "" {
 			token := r.tokenStore.Generate("recreate", resource)
-			target := deployment
-			if job != "" {
-				target = deployment + "/" + job
-			}
-			result := map[string]interface{}{
-				"requires_confirmation": true,
-				"confirmation_token":    token,
-				"operation":             "recreate",
-				"deployment":            deployment,
-				"job":                   job,
-				"expires_in_seconds":    r.config.TokenTTL,
-				"message":               fmt.Sprintf("STOP: Ask the user to confirm they want to recreate VMs for '%s'. This will cause downtime. Only proceed with the confirm token if the user explicitly approves.", target),
-			}
-			jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+			return toolResult(OperationResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            "recreate",
+				Deployment:           deployment,
+				Job:                  job,
+				Instance:             instance,
+				Options:              jobStateOptionNames(false, opts),
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to recreate VMs for '%s' on %s%s. This will cause downtime. Only proceed with the confirm token if the user explicitly approves.", target, resolvedEnvironment(creds), jobStateOptionsNote(false, opts)),
+			})
 		}
 
 		if !r.tokenStore.Validate(confirmToken, "recreate", resource) {
-			return mcp.NewToolResultError("invalid or expired confirmation token"), nil
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another environment or with other options; request a new token"), nil
 		}
 	}
 
-	client, err := r.GetClient(environment)
+	taskID, err := client.Recreate(deployment, job, instance, opts)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
-	}
-
-	taskID, err := client.Recreate(deployment, job, index)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to recreate: %v", err)), nil
+		return r.deploymentError(ctx, environment, deployment, "recreate", err), nil
 	}
 
 	// Wait for task completion
@@ -162,27 +166,25 @@ func (r *DeploymentRegistry) handleBoshRecreate(ctx context.Context, request mcp
 		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task_id":    task.ID,
-		"state":      task.State,
-		"deployment": deployment,
+	result := OperationResult{
+		TaskID:     task.ID,
+		State:      task.State,
+		Deployment: deployment,
 	}
 
 	// Include output for completed tasks
 	if task.State == "done" || task.State == "error" {
 		output, err := client.GetTaskOutput(task.ID, "result")
 		if err == nil && output != "" {
-			result["output"] = output
+			result.Output = output
 		}
 	}
 
-	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *DeploymentRegistry) handleBoshStop(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	deployment := request.GetString("deployment", "")
-	job := request.GetString("job", "")
 	environment := request.GetString("environment", "")
 	confirmToken := request.GetString("confirm", "")
 
@@ -190,44 +192,55 @@ func (r *DeploymentRegistry) handleBoshStop(ctx context.Context, request mcp.Cal
 		return mcp.NewToolResultError("deployment is required"), nil
 	}
 
-	resource := deployment
-	if job != "" {
-		resource = deployment + "/" + job
+	job, instance, err := parseInstanceTarget(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts, err := parseJobStateOptions(request, "stop")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	hard := request.GetBool("hard", false)
+	state := "stopped"
+	if hard {
+		state = "detached"
+	}
+	target := instanceTargetName(deployment, job, instance)
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
+	resource := jobStateResource(creds.Environment, target, state, opts)
 
 	if r.config.RequiresConfirmation("stop") {
 		if confirmToken == "" {
 			token := r.tokenStore.Generate("stop", resource)
-			target := deployment
-			if job != "" {
-				target = deployment + "/" + job
-			}
-			result := map[string]interface{}{
-				"requires_confirmation": true,
-				"confirmation_token":    token,
-				"operation":             "stop",
-				"deployment":            deployment,
-				"job":                   job,
-				"expires_in_seconds":    r.config.TokenTTL,
-				"message":               fmt.Sprintf("STOP: Ask the user to confirm they want to stop '%s'. This will cause downtime. Only proceed with the confirm token if the user explicitly approves.", target),
-			}
-			jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+			return toolResult(OperationResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            "stop",
+				Deployment:           deployment,
+				Job:                  job,
+				Instance:             instance,
+				Options:              jobStateOptionNames(hard, opts),
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to stop '%s' on %s%s. This will cause downtime. Only proceed with the confirm token if the user explicitly approves.", target, resolvedEnvironment(creds), jobStateOptionsNote(hard, opts)),
+			})
 		}
 
 		if !r.tokenStore.Validate(confirmToken, "stop", resource) {
-			return mcp.NewToolResultError("invalid or expired confirmation token"), nil
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another environment or with other options; request a new token"), nil
 		}
 	}
 
-	client, err := r.GetClient(environment)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
-	}
-
-	taskID, err := client.ChangeJobState(deployment, job, "stopped")
+	taskID, err := client.ChangeJobState(deployment, job, instance, state, opts)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to stop: %v", err)), nil
+		return r.deploymentError(ctx, environment, deployment, "stop", err), nil
 	}
 
 	// Wait for task completion
@@ -237,43 +250,46 @@ func (r *DeploymentRegistry) handleBoshStop(ctx context.Context, request mcp.Cal
 		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task_id":    task.ID,
-		"state":      task.State,
-		"deployment": deployment,
+	result := OperationResult{
+		TaskID:     task.ID,
+		State:      task.State,
+		Deployment: deployment,
 	}
 
 	// Include output for completed tasks
 	if task.State == "done" || task.State == "error" {
 		output, err := client.GetTaskOutput(task.ID, "result")
 		if err == nil && output != "" {
-			result["output"] = output
+			result.Output = output
 		}
 	}
 
-	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *DeploymentRegistry) handleBoshStart(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	deployment := request.GetString("deployment", "")
-	job := request.GetString("job", "")
 	environment := request.GetString("environment", "")
 
 	if deployment == "" {
 		return mcp.NewToolResultError("deployment is required"), nil
 	}
 
+	job, instance, err := parseInstanceTarget(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	// start doesn't require confirmation by default
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
 
-	taskID, err := client.ChangeJobState(deployment, job, "started")
+	taskID, err := client.ChangeJobState(deployment, job, instance, "started", bosh.JobStateOptions{})
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to start: %v", err)), nil
+		return r.deploymentError(ctx, environment, deployment, "start", err), nil
 	}
 
 	// Wait for task completion
@@ -283,43 +299,51 @@ func (r *DeploymentRegistry) handleBoshStart(ctx context.Context, request mcp.Ca
 		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task_id":    task.ID,
-		"state":      task.State,
-		"deployment": deployment,
+	result := OperationResult{
+		TaskID:     task.ID,
+		State:      task.State,
+		Deployment: deployment,
 	}
 
 	// Include output for completed tasks
 	if task.State == "done" || task.State == "error" {
 		output, err := client.GetTaskOutput(task.ID, "result")
 		if err == nil && output != "" {
-			result["output"] = output
+			result.Output = output
 		}
 	}
 
-	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *DeploymentRegistry) handleBoshRestart(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	deployment := request.GetString("deployment", "")
-	job := request.GetString("job", "")
 	environment := request.GetString("environment", "")
 
 	if deployment == "" {
 		return mcp.NewToolResultError("deployment is required"), nil
 	}
 
+	job, instance, err := parseInstanceTarget(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	opts, err := parseJobStateOptions(request, "restart")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	// restart doesn't require confirmation by default
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
 
-	taskID, err := client.ChangeJobState(deployment, job, "restart")
+	taskID, err := client.ChangeJobState(deployment, job, instance, "restart", opts)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to restart: %v", err)), nil
+		return r.deploymentError(ctx, environment, deployment, "restart", err), nil
 	}
 
 	// Wait for task completion
@@ -329,22 +353,136 @@ func (r *DeploymentRegistry) handleBoshRestart(ctx context.Context, request mcp.
 		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task_id":    task.ID,
-		"state":      task.State,
-		"deployment": deployment,
+	result := OperationResult{
+		TaskID:     task.ID,
+		State:      task.State,
+		Deployment: deployment,
 	}
 
 	// Include output for completed tasks
 	if task.State == "done" || task.State == "error" {
 		output, err := client.GetTaskOutput(task.ID, "result")
 		if err == nil && output != "" {
-			result["output"] = output
+			result.Output = output
+		}
+	}
+
+	return toolResult(result)
+}
+
+// parseInstanceTarget returns the job and instance (index or ID) targeted by
+// the job, index, and instance_id arguments. Job may also be given as
+// job/index or job/id.
+func parseInstanceTarget(request mcp.CallToolRequest) (job, instance string, err error) {
+	job = request.GetString("job", "")
+	index := request.GetString("index", "")
+	instanceID := request.GetString("instance_id", "")
+
+	if index != "" && instanceID != "" {
+		return "", "", fmt.Errorf("specify either index or instance_id, not both")
+	}
+	instance = index + instanceID
+
+	if name, fromJob, ok := strings.Cut(job, "/"); ok {
+		if instance != "" {
+			return "", "", fmt.Errorf("specify the instance in job or in index/instance_id, not both")
+		}
+		job, instance = name, fromJob
+	}
+	if instance != "" && job == "" {
+		return "", "", fmt.Errorf("job is required to target an instance")
+	}
+
+	return job, instance, nil
+}
+
+// instanceTargetName describes the deployment, job, or instance an operation
+// targets, e.g. cf/router/0.
+func instanceTargetName(deployment, job, instance string) string {
+	target := deployment
+	if job != "" {
+		target += "/" + job
+	}
+	if instance != "" {
+		target += "/" + instance
+	}
+	return target
+}
+
+// parseJobStateOptions reads skip_drain, canaries, max_in_flight, and for
+// recreate, fix.
+func parseJobStateOptions(request mcp.CallToolRequest, operation string) (bosh.JobStateOptions, error) {
+	opts := bosh.JobStateOptions{
+		SkipDrain:   request.GetBool("skip_drain", false),
+		Canaries:    request.GetString("canaries", ""),
+		MaxInFlight: request.GetString("max_in_flight", ""),
+	}
+	if operation == "recreate" {
+		opts.Fix = request.GetBool("fix", false)
+	}
+
+	for name, value := range map[string]string{"canaries": opts.Canaries, "max_in_flight": opts.MaxInFlight} {
+		if value != "" && !instanceCountPattern.MatchString(value) {
+			return opts, fmt.Errorf("%s must be a number or percentage, e.g. 2 or 25%%", name)
 		}
 	}
+	return opts, nil
+}
+
+// instanceCountPattern matches canaries and max_in_flight values.
+var instanceCountPattern = regexp.MustCompile(`^[0-9]+%?$`)
+
+// jobStateResource identifies a job state change for confirmation tokens,
+// including the Director and its options, so a token can't be reused on
+// another environment or for e.g. a hard stop.
+func jobStateResource(director, target, state string, opts bosh.JobStateOptions) string {
+	return director + "#" + target + "?" + opts.Query(state).Encode()
+}
+
+// jobStateOptionNames lists the non-default options of a job state change.
+func jobStateOptionNames(hard bool, opts bosh.JobStateOptions) []string {
+	var names []string
+	if hard {
+		names = append(names, "hard")
+	}
+	if opts.SkipDrain {
+		names = append(names, "skip_drain")
+	}
+	if opts.Fix {
+		names = append(names, "fix")
+	}
+	if opts.Canaries != "" {
+		names = append(names, "canaries="+opts.Canaries)
+	}
+	if opts.MaxInFlight != "" {
+		names = append(names, "max_in_flight="+opts.MaxInFlight)
+	}
+	return names
+}
 
-	jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+// jobStateOptionsNote describes the effect of non-default options for a
+// confirmation message.
+func jobStateOptionsNote(hard bool, opts bosh.JobStateOptions) string {
+	var notes []string
+	if hard {
+		notes = append(notes, "deleting the VMs (persistent disks are kept)")
+	}
+	if opts.SkipDrain {
+		notes = append(notes, "skipping drain scripts")
+	}
+	if opts.Fix {
+		notes = append(notes, "recreating unresponsive VMs")
+	}
+	if opts.Canaries != "" {
+		notes = append(notes, opts.Canaries+" canaries")
+	}
+	if opts.MaxInFlight != "" {
+		notes = append(notes, "max in flight "+opts.MaxInFlight)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(notes, ", ")
 }
 
 // RegisterDeploymentTools registers deployment operation tools.
@@ -363,6 +501,7 @@ func (r *DeploymentRegistry) RegisterDeploymentTools(s *server.MCPServer) {
 			mcp.Description("Named BOSH environment (optional)")),
 		mcp.WithNumber("timeout",
 			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[OperationResult](),
 	), r.handleBoshDeleteDeployment)
 
 	// bosh_recreate
@@ -375,12 +514,23 @@ func (r *DeploymentRegistry) RegisterDeploymentTools(s *server.MCPServer) {
 			mcp.Description("Job name to recreate (optional, all if not specified)")),
 		mcp.WithString("index",
 			mcp.Description("Instance index to recreate (optional)")),
+		mcp.WithString("instance_id",
+			mcp.Description("Instance ID (UUID) to recreate, instead of index (optional)")),
 		mcp.WithString("confirm",
 			mcp.Description("Confirmation token (required for destructive operation)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
 		mcp.WithNumber("timeout",
 			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		mcp.WithBoolean("fix",
+			mcp.Description("Recreate unresponsive VMs and ignore their errors")),
+		mcp.WithBoolean("skip_drain",
+			mcp.Description("Skip drain scripts")),
+		mcp.WithString("canaries",
+			mcp.Description("Number or percentage of canary instances, overriding the manifest (e.g. 1 or 10%)")),
+		mcp.WithString("max_in_flight",
+			mcp.Description("Number or percentage of instances changed at once, overriding the manifest (e.g. 2 or 25%)")),
+		outputSchema[OperationResult](),
 	), r.handleBoshRecreate)
 
 	// bosh_stop
@@ -391,12 +541,25 @@ func (r *DeploymentRegistry) RegisterDeploymentTools(s *server.MCPServer) {
 			mcp.Description("Name of the deployment")),
 		mcp.WithString("job",
 			mcp.Description("Job name to stop (optional, all if not specified)")),
+		mcp.WithString("index",
+			mcp.Description("Instance index to stop (optional)")),
+		mcp.WithString("instance_id",
+			mcp.Description("Instance ID (UUID) to stop, instead of index (optional)")),
 		mcp.WithString("confirm",
 			mcp.Description("Confirmation token (required for destructive operation)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
 		mcp.WithNumber("timeout",
 			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		mcp.WithBoolean("hard",
+			mcp.Description("Delete the VMs after stopping, keeping persistent disks")),
+		mcp.WithBoolean("skip_drain",
+			mcp.Description("Skip drain scripts")),
+		mcp.WithString("canaries",
+			mcp.Description("Number or percentage of canary instances, overriding the manifest (e.g. 1 or 10%)")),
+		mcp.WithString("max_in_flight",
+			mcp.Description("Number or percentage of instances changed at once, overriding the manifest (e.g. 2 or 25%)")),
+		outputSchema[OperationResult](),
 	), r.handleBoshStop)
 
 	// bosh_start
@@ -407,10 +570,15 @@ func (r *DeploymentRegistry) RegisterDeploymentTools(s *server.MCPServer) {
 			mcp.Description("Name of the deployment")),
 		mcp.WithString("job",
 			mcp.Description("Job name to start (optional, all if not specified)")),
+		mcp.WithString("index",
+			mcp.Description("Instance index to start (optional)")),
+		mcp.WithString("instance_id",
+			mcp.Description("Instance ID (UUID) to start, instead of index (optional)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
 		mcp.WithNumber("timeout",
 			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[OperationResult](),
 	), r.handleBoshStart)
 
 	// bosh_restart
@@ -421,9 +589,198 @@ func (r *DeploymentRegistry) RegisterDeploymentTools(s *server.MCPServer) {
 			mcp.Description("Name of the deployment")),
 		mcp.WithString("job",
 			mcp.Description("Job name to restart (optional, all if not specified)")),
+		mcp.WithString("index",
+			mcp.Description("Instance index to restart (optional)")),
+		mcp.WithString("instance_id",
+			mcp.Description("Instance ID (UUID) to restart, instead of index (optional)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
 		mcp.WithNumber("timeout",
 			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		mcp.WithBoolean("skip_drain",
+			mcp.Description("Skip drain scripts")),
+		mcp.WithString("canaries",
+			mcp.Description("Number or percentage of canary instances, overriding the manifest (e.g. 1 or 10%)")),
+		mcp.WithString("max_in_flight",
+			mcp.Description("Number or percentage of instances changed at once, overriding the manifest (e.g. 2 or 25%)")),
+		outputSchema[OperationResult](),
 	), r.handleBoshRestart)
+
+	// bosh_ignore
+	s.AddTool(mcp.NewTool("bosh_ignore",
+		mcp.WithDescription("Ignore an instance so deploys and other deployment-wide operations skip it, e.g. to fence off a broken VM while deploying the rest"),
+		mcp.WithString("deployment",
+			mcp.Required(),
+			mcp.Description("Name of the deployment")),
+		mcp.WithString("instance",
+			mcp.Required(),
+			mcp.Description("Instance to ignore, as group/id or group/index")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[IgnoreResult](),
+	), r.handleBoshIgnore)
+
+	// bosh_unignore
+	s.AddTool(mcp.NewTool("bosh_unignore",
+		mcp.WithDescription("Stop ignoring an instance so deploys manage it again"),
+		mcp.WithString("deployment",
+			mcp.Required(),
+			mcp.Description("Name of the deployment")),
+		mcp.WithString("instance",
+			mcp.Required(),
+			mcp.Description("Instance to unignore, as group/id or group/index")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[IgnoreResult](),
+	), r.handleBoshUnignore)
+
+	// bosh_cleanup
+	s.AddTool(mcp.NewTool("bosh_cleanup",
+		mcp.WithDescription("Clean up unused releases, stemcells, orphaned disks, and orphaned VMs (requires confirmation of the plan from bosh_cleanup_plan)"),
+		mcp.WithBoolean("all",
+			mcp.Description("Remove all unused releases and stemcells and all orphaned disks, instead of keeping the two newest unused versions and orphaned disks")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for destructive operation)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[CleanupResult](),
+	), r.handleBoshCleanup)
+
+	// bosh_delete_disk
+	s.AddTool(mcp.NewTool("bosh_delete_disk",
+		mcp.WithDescription("Permanently delete an orphaned persistent disk"),
+		mcp.WithString("disk_cid",
+			mcp.Required(),
+			mcp.Description("CID of the orphaned disk to delete")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for destructive operation)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[DiskOperationResult](),
+	), r.handleBoshDeleteDisk)
+
+	// bosh_attach_disk
+	s.AddTool(mcp.NewTool("bosh_attach_disk",
+		mcp.WithDescription("Attach a persistent disk, such as an orphaned disk being recovered, to an instance stopped with --hard. The instance's current persistent disk is orphaned."),
+		mcp.WithString("deployment",
+			mcp.Required(),
+			mcp.Description("Name of the deployment")),
+		mcp.WithString("instance",
+			mcp.Required(),
+			mcp.Description("Instance to attach the disk to, as job/id")),
+		mcp.WithString("disk_cid",
+			mcp.Required(),
+			mcp.Description("CID of the disk to attach")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for destructive operation)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[DiskOperationResult](),
+	), r.handleBoshAttachDisk)
+
+	// bosh_update_config
+	s.AddTool(mcp.NewTool("bosh_update_config",
+		mcp.WithDescription("Update or delete a config (cloud, runtime, cpi, or other type). The confirmation response shows the diff against the latest version."),
+		mcp.WithString("type",
+			mcp.Required(),
+			mcp.Description("Config type, e.g. cloud, runtime, or cpi")),
+		mcp.WithString("name",
+			mcp.Description("Config name (default: default)")),
+		mcp.WithString("content",
+			mcp.Description("New config YAML (required unless delete is set)")),
+		mcp.WithBoolean("delete",
+			mcp.Description("Delete the config instead of updating it")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for destructive operation)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[ConfigUpdateResult](),
+	), r.requireSupport("bosh_update_config", r.handleBoshUpdateConfig))
+
+	// bosh_upload_release
+	s.AddTool(mcp.NewTool("bosh_upload_release",
+		mcp.WithDescription("Upload a release from a URL the Director downloads, or from a local tarball on the server's host. A path can name any file the server's user can read, and its contents are sent to the Director, so path uploads need a confirmation token."),
+		mcp.WithString("url",
+			mcp.Description("URL of the release tarball for the Director to fetch")),
+		mcp.WithString("path",
+			mcp.Description("Path to a local release tarball to stream to the Director, for air-gapped setups")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for path uploads)")),
+		mcp.WithString("sha1",
+			mcp.Description("Expected SHA1 of the tarball, or sha256:<digest>")),
+		mcp.WithBoolean("fix",
+			mcp.Description("Replace an already-uploaded release with the same version, e.g. to repair corrupt packages")),
+		mcp.WithBoolean("rebase",
+			mcp.Description("Rebase the release onto the latest uploaded version")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 1800)")),
+		outputSchema[UploadResult](),
+	), r.handleBoshUploadRelease)
+
+	// bosh_upload_stemcell
+	s.AddTool(mcp.NewTool("bosh_upload_stemcell",
+		mcp.WithDescription("Upload a stemcell from a URL the Director downloads, or from a local tarball on the server's host. A path can name any file the server's user can read, and its contents are sent to the Director, so path uploads need a confirmation token."),
+		mcp.WithString("url",
+			mcp.Description("URL of the stemcell tarball for the Director to fetch")),
+		mcp.WithString("path",
+			mcp.Description("Path to a local stemcell tarball to stream to the Director, for air-gapped setups")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for path uploads)")),
+		mcp.WithString("sha1",
+			mcp.Description("Expected SHA1 of the tarball, or sha256:<digest>")),
+		mcp.WithBoolean("fix",
+			mcp.Description("Replace an already-uploaded stemcell with the same version")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 1800)")),
+		outputSchema[UploadResult](),
+	), r.handleBoshUploadStemcell)
+
+	// bosh_delete_release
+	s.AddTool(mcp.NewTool("bosh_delete_release",
+		mcp.WithDescription("Delete a release version, or every version of a release. Refuses releases still used by a deployment unless forced."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the release")),
+		mcp.WithString("version",
+			mcp.Description("Version to delete (optional, all versions if not specified)")),
+		mcp.WithBoolean("force",
+			mcp.Description("Delete even if deployments still use it")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for destructive operation)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[DeleteArtifactResult](),
+	), r.handleBoshDeleteRelease)
+
+	// bosh_delete_stemcell
+	s.AddTool(mcp.NewTool("bosh_delete_stemcell",
+		mcp.WithDescription("Delete a stemcell version. Refuses stemcells still used by a deployment unless forced."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the stemcell")),
+		mcp.WithString("version",
+			mcp.Required(),
+			mcp.Description("Version to delete")),
+		mcp.WithBoolean("force",
+			mcp.Description("Delete even if deployments still use it")),
+		mcp.WithString("confirm",
+			mcp.Description("Confirmation token (required for destructive operation)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		mcp.WithNumber("timeout",
+			mcp.Description("Timeout in seconds to wait for completion (default: 600)")),
+		outputSchema[DeleteArtifactResult](),
+	), r.handleBoshDeleteStemcell)
 }
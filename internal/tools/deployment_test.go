@@ -63,6 +63,7 @@ func TestHandleBoshDeleteDeployment_WithValidToken(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -143,6 +144,7 @@ func TestHandleBoshStart_NoConfirmationRequired(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -189,6 +191,7 @@ func TestHandleBoshRestart_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -231,3 +234,185 @@ func TestHandleBoshRecreate_MissingDeployment(t *testing.T) {
 		t.Fatal("expected error for missing deployment")
 	}
 }
+
+func TestParseInstanceTarget(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         map[string]interface{}
+		wantJob      string
+		wantInstance string
+		wantErr      bool
+	}{
+		{"deployment", map[string]interface{}{}, "", "", false},
+		{"job", map[string]interface{}{"job": "router"}, "router", "", false},
+		{"index", map[string]interface{}{"job": "router", "index": "1"}, "router", "1", false},
+		{"instance id", map[string]interface{}{"job": "router", "instance_id": "6a1f-uuid"}, "router", "6a1f-uuid", false},
+		{"job with id", map[string]interface{}{"job": "router/6a1f-uuid"}, "router", "6a1f-uuid", false},
+		{"index and id", map[string]interface{}{"job": "router", "index": "1", "instance_id": "6a1f-uuid"}, "", "", true},
+		{"instance twice", map[string]interface{}{"job": "router/0", "index": "1"}, "", "", true},
+		{"instance without job", map[string]interface{}{"instance_id": "6a1f-uuid"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.args
+
+			job, instance, err := parseInstanceTarget(request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if job != tt.wantJob || instance != tt.wantInstance {
+				t.Errorf("expected %s/%s, got %s/%s", tt.wantJob, tt.wantInstance, job, instance)
+			}
+		})
+	}
+}
+
+func TestHandleBoshStop_InstanceID(t *testing.T) {
+	var paths []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			paths = append(paths, r.URL.Path)
+			w.Header().Set("Location", "/tasks/123")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 123, "state": "done"})
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	// A token to stop the whole job can't be used to stop one instance
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router"}
+	result, _ := deploymentRegistry.handleBoshStop(context.Background(), request)
+	jobToken := result.StructuredContent.(OperationResult).ConfirmationToken
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router", "instance_id": "6a1f-uuid", "confirm": jobToken}
+	result, _ = deploymentRegistry.handleBoshStop(context.Background(), request)
+	if !result.IsError {
+		t.Fatal("expected job token to be rejected for an instance")
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router", "instance_id": "6a1f-uuid"}
+	result, _ = deploymentRegistry.handleBoshStop(context.Background(), request)
+	confirmation := result.StructuredContent.(OperationResult)
+	if confirmation.Instance != "6a1f-uuid" || !strings.Contains(confirmation.Message, "cf/router/6a1f-uuid") {
+		t.Errorf("expected confirmation for the instance, got %+v", confirmation)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router", "instance_id": "6a1f-uuid", "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshStop(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(paths) != 1 || paths[0] != "/deployments/cf/jobs/router/6a1f-uuid" {
+		t.Errorf("unexpected requests: %v", paths)
+	}
+}
+
+func TestHandleBoshStop_HardStopNeedsItsOwnToken(t *testing.T) {
+	var queries []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			queries = append(queries, r.URL.RawQuery)
+			w.Header().Set("Location", "/tasks/123")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 123, "state": "done"})
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	// A token for a soft stop can't be used for a hard stop
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router"}
+	result, _ := deploymentRegistry.handleBoshStop(context.Background(), request)
+	softToken := result.StructuredContent.(OperationResult).ConfirmationToken
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router", "hard": true, "confirm": softToken}
+	result, _ = deploymentRegistry.handleBoshStop(context.Background(), request)
+	if !result.IsError {
+		t.Fatal("expected soft stop token to be rejected for a hard stop")
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router", "hard": true, "skip_drain": true}
+	result, _ = deploymentRegistry.handleBoshStop(context.Background(), request)
+	confirmation := result.StructuredContent.(OperationResult)
+	if !strings.Contains(confirmation.Message, "deleting the VMs") || !strings.Contains(confirmation.Message, "skipping drain") {
+		t.Errorf("expected confirmation to describe hard stop and skip drain, got %q", confirmation.Message)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "job": "router", "hard": true, "skip_drain": true, "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshStop(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(queries) != 1 || queries[0] != "skip_drain=true&state=detached" {
+		t.Errorf("unexpected requests: %v", queries)
+	}
+}
+
+func TestHandleBoshStopAndRecreate_TokenBoundToEnvironment(t *testing.T) {
+	newDirector := func() *httptest.Server {
+		return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("no request expected: %s %s", r.Method, r.URL.Path)
+		}))
+	}
+	sandbox := newDirector()
+	defer sandbox.Close()
+	prod := newDirector()
+	defer prod.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "prod": prod.URL})
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider(configPath)), config.Load(""))
+
+	handlers := map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"stop":     deploymentRegistry.handleBoshStop,
+		"recreate": deploymentRegistry.handleBoshRecreate,
+	}
+	for name, handler := range handlers {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"environment": "sandbox", "deployment": "cf", "job": "router"}
+		result, _ := handler(context.Background(), request)
+		confirmation := result.StructuredContent.(OperationResult)
+		if !confirmation.RequiresConfirmation || !strings.Contains(confirmation.Message, "sandbox") {
+			t.Fatalf("%s: expected confirmation naming sandbox, got %+v", name, confirmation)
+		}
+
+		// The same operation on another director needs its own confirmation.
+		request.Params.Arguments = map[string]interface{}{"environment": "prod", "deployment": "cf", "job": "router", "confirm": confirmation.ConfirmationToken}
+		result, _ = handler(context.Background(), request)
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "another environment") {
+			t.Errorf("%s: expected token for sandbox to be rejected on prod, got %v", name, result.Content)
+		}
+	}
+}
+
+func TestHandleBoshRestart_InvalidMaxInFlight(t *testing.T) {
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "max_in_flight": "all"}
+	result, _ := deploymentRegistry.handleBoshRestart(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "max_in_flight") {
+		t.Errorf("expected max_in_flight error, got %v", result.Content)
+	}
+}
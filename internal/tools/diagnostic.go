@@ -5,8 +5,8 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/malston/bosh-mcp-server/internal/bosh"
@@ -21,27 +21,38 @@ func (r *Registry) handleBoshVMs(ctx context.Context, request mcp.CallToolReques
 		return mcp.NewToolResultError("deployment is required"), nil
 	}
 
-	client, err := r.GetClient(environment)
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.VM{}))
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
+	filter := parseInstanceFilter(request)
 
-	vms, err := client.ListVMs(deployment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list VMs: %v", err)), nil
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"deployment": deployment,
-		"vms":        vms,
+	var vms []bosh.VM
+	if request.GetBool("vitals", false) {
+		vms, err = client.ListVMsWithVitals(ctx, deployment)
+	} else {
+		vms, err = client.ListVMs(deployment)
 	}
-
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		return r.deploymentError(ctx, environment, deployment, "list VMs", err), nil
+	}
+
+	vms = filterSlice(vms, filter.matchVM)
+	sortItems(vms, opts.Sort)
+	vms, page := paginate(vms, opts)
+
+	result := VMsResult{
+		Deployment: deployment,
+		VMs:        vms,
+		Page:       page,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return listResult(result, "vms", opts, page)
 }
 
 func (r *Registry) handleBoshInstances(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -52,27 +63,33 @@ func (r *Registry) handleBoshInstances(ctx context.Context, request mcp.CallTool
 		return mcp.NewToolResultError("deployment is required"), nil
 	}
 
-	client, err := r.GetClient(environment)
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.Instance{}))
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
+	filter := parseInstanceFilter(request)
 
-	instances, err := client.ListInstances(deployment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list instances: %v", err)), nil
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"deployment": deployment,
-		"instances":  instances,
+	instances, err := client.ListInstancesWithProcesses(ctx, deployment)
+	if err != nil {
+		return r.deploymentError(ctx, environment, deployment, "list instances", err), nil
 	}
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	instances = filterSlice(instances, filter.matchInstance)
+	sortItems(instances, opts.Sort)
+	instances, page := paginate(instances, opts)
+
+	result := InstancesResult{
+		Deployment: deployment,
+		Instances:  instances,
+		Page:       page,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return listResult(result, "instances", opts, page)
 }
 
 func (r *Registry) handleBoshTasks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -84,7 +101,12 @@ func (r *Registry) handleBoshTasks(ctx context.Context, request mcp.CallToolRequ
 		Limit:      request.GetInt("limit", 0),
 	}
 
-	client, err := r.GetClient(environment)
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.Task{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -94,16 +116,15 @@ func (r *Registry) handleBoshTasks(ctx context.Context, request mcp.CallToolRequ
 		return mcp.NewToolResultError(fmt.Sprintf("failed to list tasks: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"tasks": tasks,
-	}
+	sortItems(tasks, opts.Sort)
+	tasks, page := paginate(tasks, opts)
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	result := TasksResult{
+		Tasks: tasks,
+		Page:  page,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return listResult(result, "tasks", opts, page)
 }
 
 func (r *Registry) handleBoshTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -116,7 +137,7 @@ func (r *Registry) handleBoshTask(ctx context.Context, request mcp.CallToolReque
 
 	includeOutput := request.GetBool("output", false)
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -126,23 +147,18 @@ func (r *Registry) handleBoshTask(ctx context.Context, request mcp.CallToolReque
 		return mcp.NewToolResultError(fmt.Sprintf("failed to get task: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task": task,
+	result := TaskResult{
+		Task: task,
 	}
 
 	if includeOutput {
 		output, err := client.GetTaskOutput(id, "result")
 		if err == nil {
-			result["output"] = output
+			result.Output = output
 		}
 	}
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *Registry) handleBoshTaskWait(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -157,7 +173,7 @@ func (r *Registry) handleBoshTaskWait(ctx context.Context, request mcp.CallToolR
 	timeoutSecs := request.GetInt("timeout", 600) // default 10 minutes
 	timeout := time.Duration(timeoutSecs) * time.Second
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -167,22 +183,17 @@ func (r *Registry) handleBoshTaskWait(ctx context.Context, request mcp.CallToolR
 		return mcp.NewToolResultError(fmt.Sprintf("failed waiting for task: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"task": task,
+	result := TaskResult{
+		Task: task,
 	}
 
 	// Include output for completed tasks
 	if task.State == "done" || task.State == "error" {
 		output, err := client.GetTaskOutput(id, "result")
 		if err == nil {
-			result["output"] = output
+			result.Output = output
 		}
 	}
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
@@ -32,6 +32,7 @@ func TestHandleBoshVMs_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -180,6 +181,7 @@ func TestHandleBoshInstances_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -309,6 +311,7 @@ func TestHandleBoshTasks_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -407,6 +410,7 @@ func TestHandleBoshTask_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -539,6 +543,7 @@ func TestHandleBoshTaskWait_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -0,0 +1,226 @@
+// ABOUTME: Implements orphaned disk tools (list, delete, attach).
+// ABOUTME: Delete and attach go through the blocked-operation and confirmation checks.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func (r *Registry) handleBoshOrphanedDisks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+	deployment := request.GetString("deployment", "")
+
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.OrphanedDisk{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	disks, err := client.ListOrphanedDisks()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list orphaned disks: %v", err)), nil
+	}
+
+	if deployment != "" {
+		disks = filterSlice(disks, func(d bosh.OrphanedDisk) bool { return d.Deployment == deployment })
+	}
+	sortItems(disks, opts.Sort)
+	disks, page := paginate(disks, opts)
+
+	result := OrphanedDisksResult{
+		Disks: disks,
+		Page:  page,
+	}
+
+	return listResult(result, "disks", opts, page)
+}
+
+func (r *DeploymentRegistry) handleBoshDeleteDisk(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	diskCID := request.GetString("disk_cid", "")
+	environment := request.GetString("environment", "")
+	confirmToken := request.GetString("confirm", "")
+
+	if diskCID == "" {
+		return mcp.NewToolResultError("disk_cid is required"), nil
+	}
+
+	if r.config.IsBlocked("delete_disk") {
+		return mcp.NewToolResultError("delete_disk is blocked by configuration"), nil
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	// Only orphaned disks can be deleted, so an attached disk is never
+	// destroyed by a mistyped CID.
+	disk, err := findOrphanedDisk(client, diskCID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list orphaned disks: %v", err)), nil
+	}
+	if disk == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("disk '%s' is not an orphaned disk; use bosh_orphaned_disks to list disks that can be deleted", diskCID)), nil
+	}
+
+	// Disk CIDs are only unique per Director, so the token is bound to it.
+	resource := creds.Environment + "#" + diskCID
+	if r.config.RequiresConfirmation("delete_disk") {
+		if confirmToken == "" {
+			token := r.tokenStore.Generate("delete_disk", resource)
+			return toolResult(DiskOperationResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            "delete_disk",
+				DiskCID:              diskCID,
+				Disk:                 disk,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to delete orphaned disk '%s' (%d MB, from %s/%s) on %s. The data on it cannot be recovered. Only proceed with the confirm token if the user explicitly approves.", diskCID, disk.Size, disk.Deployment, disk.Instance, resolvedEnvironment(creds)),
+			})
+		}
+
+		if !r.tokenStore.Validate(confirmToken, "delete_disk", resource) {
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another environment; request a new token"), nil
+		}
+	}
+
+	taskID, err := client.DeleteOrphanedDisk(diskCID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete disk: %v", err)), nil
+	}
+
+	result, err := waitForDiskTask(client, request, taskID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
+	}
+	result.DiskCID = diskCID
+	result.Disk = disk
+
+	return toolResult(result)
+}
+
+func (r *DeploymentRegistry) handleBoshAttachDisk(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deployment := request.GetString("deployment", "")
+	instance := request.GetString("instance", "")
+	diskCID := request.GetString("disk_cid", "")
+	environment := request.GetString("environment", "")
+	confirmToken := request.GetString("confirm", "")
+
+	if deployment == "" || instance == "" || diskCID == "" {
+		return mcp.NewToolResultError("deployment, instance, and disk_cid are required"), nil
+	}
+	job, instanceID, ok := strings.Cut(instance, "/")
+	if !ok || job == "" || instanceID == "" {
+		return mcp.NewToolResultError("instance must be in the form job/id"), nil
+	}
+
+	if r.config.IsBlocked("attach_disk") {
+		return mcp.NewToolResultError("attach_disk is blocked by configuration"), nil
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	// Disks being recovered are usually orphaned; include their origin so the
+	// user can check it is the right one.
+	disk, err := findOrphanedDisk(client, diskCID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list orphaned disks: %v", err)), nil
+	}
+
+	resource := creds.Environment + "#" + deployment + "/" + instance + ":" + diskCID
+	if r.config.RequiresConfirmation("attach_disk") {
+		if confirmToken == "" {
+			token := r.tokenStore.Generate("attach_disk", resource)
+			return toolResult(DiskOperationResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Operation:            "attach_disk",
+				DiskCID:              diskCID,
+				Disk:                 disk,
+				Deployment:           deployment,
+				Instance:             instance,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to attach disk '%s' to '%s/%s' on %s. The instance's current persistent disk will be orphaned, and the instance must already be stopped with --hard. Only proceed with the confirm token if the user explicitly approves.", diskCID, deployment, instance, resolvedEnvironment(creds)),
+			})
+		}
+
+		if !r.tokenStore.Validate(confirmToken, "attach_disk", resource) {
+			return mcp.NewToolResultError("invalid or expired confirmation token, or it was issued for another instance or environment; request a new token"), nil
+		}
+	}
+
+	taskID, err := client.AttachDisk(diskCID, deployment, job, instanceID)
+	if err != nil {
+		return r.deploymentError(ctx, environment, deployment, "attach disk", err), nil
+	}
+
+	result, err := waitForDiskTask(client, request, taskID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
+	}
+	result.DiskCID = diskCID
+	result.Disk = disk
+	result.Deployment = deployment
+	result.Instance = instance
+
+	return toolResult(result)
+}
+
+// findOrphanedDisk returns the orphaned disk with the given CID, or nil if
+// the Director has no such orphaned disk.
+func findOrphanedDisk(client *bosh.Client, diskCID string) (*bosh.OrphanedDisk, error) {
+	disks, err := client.ListOrphanedDisks()
+	if err != nil {
+		return nil, err
+	}
+	for _, disk := range disks {
+		if disk.DiskCID == diskCID {
+			return &disk, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitForDiskTask waits for a disk task and includes its result output.
+func waitForDiskTask(client *bosh.Client, request mcp.CallToolRequest, taskID int) (DiskOperationResult, error) {
+	timeout := time.Duration(request.GetInt("timeout", 600)) * time.Second
+	task, err := client.WaitForTask(taskID, timeout, 2*time.Second)
+	if err != nil {
+		return DiskOperationResult{}, err
+	}
+
+	result := DiskOperationResult{
+		TaskID: task.ID,
+		State:  task.State,
+	}
+	if task.State == "done" || task.State == "error" {
+		output, err := client.GetTaskOutput(task.ID, "result")
+		if err == nil && output != "" {
+			result.Output = output
+		}
+	}
+	return result, nil
+}
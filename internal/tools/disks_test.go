@@ -0,0 +1,219 @@
+// ABOUTME: Tests for orphaned disk tools.
+// ABOUTME: Verifies confirmation, blocked operations, and the Director requests made.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newDiskTestDirector serves one orphaned disk and records disk task requests.
+func newDiskTestDirector(t *testing.T, requests *[]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/disks":
+			if r.URL.Query().Get("orphaned") != "true" {
+				t.Errorf("expected orphaned=true, got %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode([]bosh.OrphanedDisk{
+				{DiskCID: "disk-1", Size: 10240, Deployment: "cf", Instance: "database/uuid-1"},
+				{DiskCID: "disk-2", Size: 2048, Deployment: "redis", Instance: "redis/uuid-2"},
+			})
+		case strings.HasPrefix(r.URL.Path, "/disks/"):
+			*requests = append(*requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+			w.Header().Set("Location", "/tasks/77")
+			w.WriteHeader(http.StatusFound)
+		case r.URL.Path == "/tasks/77":
+			json.NewEncoder(w).Encode(bosh.Task{ID: 77, State: "done"})
+		case r.URL.Path == "/tasks/77/output":
+			w.Write([]byte(""))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	return server
+}
+
+func TestHandleBoshOrphanedDisks_FiltersByDeployment(t *testing.T) {
+	var requests []string
+	server := newDiskTestDirector(t, &requests)
+	defer server.Close()
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf"}
+
+	result, _ := registry.handleBoshOrphanedDisks(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	disks := result.StructuredContent.(OrphanedDisksResult).Disks
+	if len(disks) != 1 || disks[0].DiskCID != "disk-1" {
+		t.Errorf("expected only disk-1, got %+v", disks)
+	}
+}
+
+func TestHandleBoshDeleteDisk_RequiresConfirmation(t *testing.T) {
+	var requests []string
+	server := newDiskTestDirector(t, &requests)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"disk_cid": "disk-1"}
+
+	result, _ := deploymentRegistry.handleBoshDeleteDisk(context.Background(), request)
+	confirmation := result.StructuredContent.(DiskOperationResult)
+	if !confirmation.RequiresConfirmation || confirmation.Disk == nil || confirmation.Disk.Deployment != "cf" {
+		t.Fatalf("expected confirmation with disk details, got %+v", confirmation)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("disk must not be deleted before confirmation: %v", requests)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"disk_cid": "disk-1", "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshDeleteDisk(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(requests) != 1 || requests[0] != "DELETE /disks/disk-1?" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+	if done := result.StructuredContent.(DiskOperationResult); done.TaskID != 77 || done.State != "done" {
+		t.Errorf("unexpected result: %+v", done)
+	}
+}
+
+func TestHandleBoshDeleteDisk_RejectsUnknownDisk(t *testing.T) {
+	var requests []string
+	server := newDiskTestDirector(t, &requests)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"disk_cid": "attached-disk"}
+
+	result, _ := deploymentRegistry.handleBoshDeleteDisk(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "not an orphaned disk") {
+		t.Errorf("expected not orphaned error, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshAttachDisk(t *testing.T) {
+	var requests []string
+	server := newDiskTestDirector(t, &requests)
+	defer server.Close()
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "database/uuid-3", "disk_cid": "disk-1"}
+
+	result, _ := deploymentRegistry.handleBoshAttachDisk(context.Background(), request)
+	token := result.StructuredContent.(DiskOperationResult).ConfirmationToken
+	if token == "" {
+		t.Fatalf("expected confirmation token, got %v", result.Content)
+	}
+
+	// The token is bound to the instance it was issued for
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "database/uuid-4", "disk_cid": "disk-1", "confirm": token}
+	result, _ = deploymentRegistry.handleBoshAttachDisk(context.Background(), request)
+	if !result.IsError {
+		t.Fatal("expected token for another instance to be rejected")
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "database/uuid-3", "disk_cid": "disk-1", "confirm": token}
+	result, _ = deploymentRegistry.handleBoshAttachDisk(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if len(requests) != 1 || requests[0] != "PUT /disks/disk-1/attachments?deployment=cf&instance_id=uuid-3&job=database" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestHandleBoshDiskOperations_TokenBoundToEnvironment(t *testing.T) {
+	var requests []string
+	sandbox := newDiskTestDirector(t, &requests)
+	defer sandbox.Close()
+	prod := newDiskTestDirector(t, &requests)
+	defer prod.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "prod": prod.URL})
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider(configPath)), config.Load(""))
+
+	tests := []struct {
+		name    string
+		handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args    func(environment, confirm string) map[string]interface{}
+	}{
+		{"delete", deploymentRegistry.handleBoshDeleteDisk, func(environment, confirm string) map[string]interface{} {
+			return map[string]interface{}{"environment": environment, "disk_cid": "disk-1", "confirm": confirm}
+		}},
+		{"attach", deploymentRegistry.handleBoshAttachDisk, func(environment, confirm string) map[string]interface{} {
+			return map[string]interface{}{"environment": environment, "deployment": "cf", "instance": "database/uuid-3", "disk_cid": "disk-1", "confirm": confirm}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.args("sandbox", "")
+			result, _ := tt.handler(context.Background(), request)
+			confirmation := result.StructuredContent.(DiskOperationResult)
+			if !confirmation.RequiresConfirmation || !strings.Contains(confirmation.Message, "sandbox") {
+				t.Fatalf("expected confirmation naming sandbox, got %+v", confirmation)
+			}
+
+			request.Params.Arguments = tt.args("prod", confirmation.ConfirmationToken)
+			result, _ = tt.handler(context.Background(), request)
+			if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "environment") {
+				t.Errorf("expected token for sandbox to be rejected on prod, got %v", result.Content)
+			}
+			if len(requests) != 0 {
+				t.Errorf("expected no disk requests, got %v", requests)
+			}
+		})
+	}
+}
+
+func TestHandleBoshDiskOperations_Blocked(t *testing.T) {
+	cfg := config.Load("")
+	cfg.BlockedOperations = []string{"delete_disk", "attach_disk"}
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), cfg)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "database/uuid-3", "disk_cid": "disk-1"}
+
+	for name, handler := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"delete_disk": deploymentRegistry.handleBoshDeleteDisk,
+		"attach_disk": deploymentRegistry.handleBoshAttachDisk,
+	} {
+		result, _ := handler(context.Background(), request)
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "blocked") {
+			t.Errorf("expected %s to be blocked, got %v", name, result.Content)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// ABOUTME: Implements bosh_env_diff, which compares two BOSH environments.
+// ABOUTME: Reports deployments, releases, stemcells, and configs that are ahead, behind, or missing.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Statuses describe the target environment relative to the source.
+const (
+	diffSame    = "same"
+	diffAhead   = "ahead"
+	diffBehind  = "behind"
+	diffMissing = "missing"
+	diffExtra   = "extra"
+	diffChanged = "changed"
+)
+
+// VersionDiff compares the version of a release or stemcell in two environments.
+type VersionDiff struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+	Status string `json:"status"`
+}
+
+// DeploymentDiff compares a deployment's releases and stemcells in two environments.
+type DeploymentDiff struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Releases  []VersionDiff `json:"releases,omitempty"`
+	Stemcells []VersionDiff `json:"stemcells,omitempty"`
+}
+
+// ConfigDiff is a normalized YAML diff of a named config in two environments.
+type ConfigDiff struct {
+	Name    string       `json:"name"`
+	Status  string       `json:"status"`
+	Changes []YAMLChange `json:"changes,omitempty"`
+}
+
+// EnvDiffSummary counts the differences found.
+type EnvDiffSummary struct {
+	Deployments    int `json:"deployments"`
+	Releases       int `json:"releases"`
+	Stemcells      int `json:"stemcells"`
+	RuntimeConfigs int `json:"runtime_configs"`
+	CloudConfig    int `json:"cloud_config"`
+}
+
+// envSnapshot holds the state of one environment used for comparison.
+type envSnapshot struct {
+	deployments    []bosh.Deployment
+	releases       []bosh.Release
+	stemcells      []bosh.Stemcell
+	runtimeConfigs []bosh.RuntimeConfig
+	cloudConfig    *bosh.CloudConfig
+}
+
+func (r *Registry) handleBoshEnvDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	source := request.GetString("source", "")
+	target := request.GetString("target", "")
+	includeSame := request.GetBool("include_same", false)
+
+	if source == "" || target == "" {
+		return mcp.NewToolResultError("source and target are required"), nil
+	}
+	if source == target {
+		return mcp.NewToolResultError("source and target must be different environments"), nil
+	}
+
+	named := r.namedRegistry()
+	environments := []string{source, target}
+	snapshots := make([]*envSnapshot, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, environment := range environments {
+		wg.Add(1)
+		go func(i int, environment string) {
+			defer wg.Done()
+			snapshots[i], errs[i] = named.snapshotEnvironment(ctx, environment)
+		}(i, environment)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read %s: %v", environments[i], err)), nil
+		}
+	}
+
+	result, err := diffEnvironments(snapshots[0], snapshots[1], includeSame)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to compare configs: %v", err)), nil
+	}
+	result.Source = source
+	result.Target = target
+
+	return toolResult(result)
+}
+
+// snapshotEnvironment reads deployments, uploaded releases and stemcells, and configs.
+func (r *Registry) snapshotEnvironment(ctx context.Context, environment string) (*envSnapshot, error) {
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return nil, fmt.Errorf("auth failed: %w", err)
+	}
+
+	snapshot := &envSnapshot{}
+	if snapshot.deployments, err = client.ListDeployments(); err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	if snapshot.releases, err = client.ListReleases(); err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	if snapshot.stemcells, err = client.ListStemcells(); err != nil {
+		return nil, fmt.Errorf("failed to list stemcells: %w", err)
+	}
+	if snapshot.runtimeConfigs, err = client.GetRuntimeConfigs(); err != nil {
+		return nil, fmt.Errorf("failed to get runtime configs: %w", err)
+	}
+	if snapshot.cloudConfig, err = client.GetCloudConfig(); err != nil {
+		return nil, fmt.Errorf("failed to get cloud config: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// diffEnvironments compares two snapshots. Unless includeSame is set, only
+// differences are listed.
+func diffEnvironments(source, target *envSnapshot, includeSame bool) (EnvDiffResult, error) {
+	result := EnvDiffResult{
+		Deployments:    []DeploymentDiff{},
+		Releases:       []VersionDiff{},
+		Stemcells:      []VersionDiff{},
+		RuntimeConfigs: []ConfigDiff{},
+	}
+
+	sourceDeployments := make(map[string]bosh.Deployment)
+	for _, d := range source.deployments {
+		sourceDeployments[d.Name] = d
+	}
+	targetDeployments := make(map[string]bosh.Deployment)
+	for _, d := range target.deployments {
+		targetDeployments[d.Name] = d
+	}
+	for _, name := range unionKeys(sourceDeployments, targetDeployments) {
+		s, inSource := sourceDeployments[name]
+		t, inTarget := targetDeployments[name]
+
+		diff := DeploymentDiff{Name: name, Status: diffSame}
+		switch {
+		case !inTarget:
+			diff.Status = diffMissing
+		case !inSource:
+			diff.Status = diffExtra
+		default:
+			diff.Releases = diffVersions(nameVersions(s.Releases), nameVersions(t.Releases), includeSame)
+			diff.Stemcells = diffVersions(nameVersions(s.Stemcells), nameVersions(t.Stemcells), includeSame)
+			if hasDifferences(diff.Releases) || hasDifferences(diff.Stemcells) {
+				diff.Status = diffChanged
+			}
+		}
+		if includeSame || diff.Status != diffSame {
+			result.Deployments = append(result.Deployments, diff)
+			if diff.Status != diffSame {
+				result.Summary.Deployments++
+			}
+		}
+	}
+
+	result.Releases = diffVersions(uploadedReleases(source.releases), uploadedReleases(target.releases), includeSame)
+	result.Summary.Releases = countDifferences(result.Releases)
+	result.Stemcells = diffVersions(uploadedStemcells(source.stemcells), uploadedStemcells(target.stemcells), includeSame)
+	result.Summary.Stemcells = countDifferences(result.Stemcells)
+
+	sourceConfigs := make(map[string]string)
+	for _, c := range source.runtimeConfigs {
+		sourceConfigs[c.Name] = c.Properties
+	}
+	targetConfigs := make(map[string]string)
+	for _, c := range target.runtimeConfigs {
+		targetConfigs[c.Name] = c.Properties
+	}
+	for _, name := range unionKeys(sourceConfigs, targetConfigs) {
+		s, inSource := sourceConfigs[name]
+		t, inTarget := targetConfigs[name]
+		diff, err := diffConfig(name, s, t, inSource, inTarget)
+		if err != nil {
+			return result, fmt.Errorf("runtime config %s: %w", name, err)
+		}
+		if includeSame || diff.Status != diffSame {
+			result.RuntimeConfigs = append(result.RuntimeConfigs, diff)
+			if diff.Status != diffSame {
+				result.Summary.RuntimeConfigs++
+			}
+		}
+	}
+
+	var s, t string
+	if source.cloudConfig != nil {
+		s = source.cloudConfig.Properties
+	}
+	if target.cloudConfig != nil {
+		t = target.cloudConfig.Properties
+	}
+	cloudDiff, err := diffConfig("default", s, t, source.cloudConfig != nil, target.cloudConfig != nil)
+	if err != nil {
+		return result, fmt.Errorf("cloud config: %w", err)
+	}
+	result.CloudConfig = cloudDiff
+	result.Summary.CloudConfig = len(cloudDiff.Changes)
+
+	return result, nil
+}
+
+// diffConfig compares one named config that may be absent on either side.
+func diffConfig(name, source, target string, inSource, inTarget bool) (ConfigDiff, error) {
+	diff := ConfigDiff{Name: name, Status: diffSame}
+	switch {
+	case !inSource && !inTarget:
+		return diff, nil
+	case !inTarget:
+		diff.Status = diffMissing
+		return diff, nil
+	case !inSource:
+		diff.Status = diffExtra
+		return diff, nil
+	}
+
+	changes, err := diffYAML(source, target)
+	if err != nil {
+		return diff, err
+	}
+	if len(changes) > 0 {
+		diff.Status = diffChanged
+		diff.Changes = changes
+	}
+	return diff, nil
+}
+
+// diffVersions compares name-to-version maps from two environments.
+func diffVersions(source, target map[string]string, includeSame bool) []VersionDiff {
+	diffs := []VersionDiff{}
+	for _, name := range unionKeys(source, target) {
+		s, inSource := source[name]
+		t, inTarget := target[name]
+
+		diff := VersionDiff{Name: name, Source: s, Target: t}
+		switch {
+		case !inTarget:
+			diff.Status = diffMissing
+		case !inSource:
+			diff.Status = diffExtra
+		default:
+			switch compareVersions(t, s) {
+			case 1:
+				diff.Status = diffAhead
+			case -1:
+				diff.Status = diffBehind
+			default:
+				diff.Status = diffSame
+			}
+		}
+
+		if includeSame || diff.Status != diffSame {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// nameVersions maps each name to its newest version.
+func nameVersions(items []bosh.NameVersion) map[string]string {
+	versions := make(map[string]string)
+	for _, item := range items {
+		if current, ok := versions[item.Name]; !ok || compareVersions(item.Version, current) > 0 {
+			versions[item.Name] = item.Version
+		}
+	}
+	return versions
+}
+
+// uploadedReleases maps each uploaded release name to its newest version.
+func uploadedReleases(releases []bosh.Release) map[string]string {
+	items := make([]bosh.NameVersion, len(releases))
+	for i, rel := range releases {
+		items[i] = bosh.NameVersion{Name: rel.Name, Version: rel.Version}
+	}
+	return nameVersions(items)
+}
+
+// uploadedStemcells maps each uploaded stemcell name to its newest version.
+func uploadedStemcells(stemcells []bosh.Stemcell) map[string]string {
+	items := make([]bosh.NameVersion, len(stemcells))
+	for i, sc := range stemcells {
+		items[i] = bosh.NameVersion{Name: sc.Name, Version: sc.Version}
+	}
+	return nameVersions(items)
+}
+
+func hasDifferences(diffs []VersionDiff) bool {
+	return countDifferences(diffs) > 0
+}
+
+func countDifferences(diffs []VersionDiff) int {
+	n := 0
+	for _, d := range diffs {
+		if d.Status != diffSame {
+			n++
+		}
+	}
+	return n
+}
+
+// unionKeys returns the sorted keys present in either map.
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+	for _, m := range []map[string]V{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,125 @@
+// ABOUTME: Tests for comparing two BOSH environments.
+// ABOUTME: Uses two mock Directors configured as named environments.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newEnvDirector(t *testing.T, deployments []bosh.Deployment, releases []bosh.Release, stemcells []bosh.Stemcell, cloudConfig string) *httptest.Server {
+	t.Helper()
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/deployments":
+			json.NewEncoder(w).Encode(deployments)
+		case "/releases":
+			json.NewEncoder(w).Encode(releases)
+		case "/stemcells":
+			json.NewEncoder(w).Encode(stemcells)
+		case "/configs":
+			if r.URL.Query().Get("type") == "cloud" {
+				json.NewEncoder(w).Encode([]bosh.CloudConfig{{Properties: cloudConfig}})
+			} else {
+				json.NewEncoder(w).Encode([]bosh.RuntimeConfig{{Name: "dns", Properties: "addons: []"}})
+			}
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestHandleBoshEnvDiff(t *testing.T) {
+	sandbox := newEnvDirector(t,
+		[]bosh.Deployment{
+			{Name: "cf", Releases: []bosh.NameVersion{{Name: "cf", Version: "1.10"}}, Stemcells: []bosh.NameVersion{{Name: "jammy", Version: "1.300"}}},
+			{Name: "redis", Releases: []bosh.NameVersion{{Name: "redis", Version: "3.0"}}},
+		},
+		[]bosh.Release{{Name: "cf", Version: "1.9"}, {Name: "cf", Version: "1.10"}},
+		[]bosh.Stemcell{{Name: "jammy", Version: "1.300"}},
+		"vm_types:\n- name: small\n  cloud_properties: {cpu: 4}\n",
+	)
+	defer sandbox.Close()
+
+	prod := newEnvDirector(t,
+		[]bosh.Deployment{
+			{Name: "cf", Releases: []bosh.NameVersion{{Name: "cf", Version: "1.9"}}, Stemcells: []bosh.NameVersion{{Name: "jammy", Version: "1.300"}}},
+			{Name: "mysql"},
+		},
+		[]bosh.Release{{Name: "cf", Version: "1.9"}},
+		[]bosh.Stemcell{{Name: "jammy", Version: "1.300"}},
+		"vm_types:\n- name: small\n  cloud_properties: {cpu: 2}\n",
+	)
+	defer prod.Close()
+
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL, "prod": prod.URL})
+	t.Setenv("BOSH_ENVIRONMENT", "")
+
+	registry := NewRegistry(auth.NewProvider(configPath))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"source": "sandbox",
+		"target": "prod",
+	}
+
+	result, err := registry.handleBoshEnvDiff(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+
+	diff := result.StructuredContent.(EnvDiffResult)
+
+	statuses := map[string]string{}
+	for _, d := range diff.Deployments {
+		statuses[d.Name] = d.Status
+	}
+	if fmt.Sprint(statuses) != "map[cf:changed mysql:extra redis:missing]" {
+		t.Errorf("unexpected deployment statuses: %v", statuses)
+	}
+	if cf := diff.Deployments[0]; len(cf.Releases) != 1 || cf.Releases[0].Status != diffBehind || len(cf.Stemcells) != 0 {
+		t.Errorf("expected cf release behind and stemcells unchanged, got %+v", cf)
+	}
+
+	if len(diff.Releases) != 1 || diff.Releases[0].Source != "1.10" || diff.Releases[0].Status != diffBehind {
+		t.Errorf("unexpected uploaded release diff: %+v", diff.Releases)
+	}
+	if len(diff.Stemcells) != 0 || len(diff.RuntimeConfigs) != 0 {
+		t.Errorf("expected identical stemcells and runtime configs to be omitted, got %+v %+v", diff.Stemcells, diff.RuntimeConfigs)
+	}
+	if diff.CloudConfig.Status != diffChanged || diff.CloudConfig.Changes[0].Path != "vm_types[name=small].cloud_properties.cpu" {
+		t.Errorf("unexpected cloud config diff: %+v", diff.CloudConfig)
+	}
+	if diff.Summary.Deployments != 3 || diff.Summary.Releases != 1 || diff.Summary.CloudConfig != 1 {
+		t.Errorf("unexpected summary: %+v", diff.Summary)
+	}
+}
+
+func TestHandleBoshEnvDiff_UnknownEnvironment(t *testing.T) {
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": "https://127.0.0.1:1"})
+	registry := NewRegistry(auth.NewProvider(configPath))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"source": "sandbox",
+		"target": "prod",
+	}
+
+	result, _ := registry.handleBoshEnvDiff(context.Background(), request)
+	if !result.IsError {
+		t.Fatal("expected error for unknown environment")
+	}
+}
@@ -0,0 +1,126 @@
+// ABOUTME: Echoes the BOSH environment each tool call resolved to in its result.
+// ABOUTME: Makes it obvious which director was hit, e.g. when env vars override a config default.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// environmentMetaKey is the result _meta key holding the resolved environment.
+const environmentMetaKey = "environment"
+
+// credentialsRecordKey is the context key for a tool call's credentialsRecord.
+type credentialsRecordKey struct{}
+
+// credentialsRecord holds the first credentials a tool call's handler
+// resolved, so the environment echoed is the one the handler used.
+type credentialsRecord struct {
+	mu    sync.Mutex
+	creds *auth.Credentials
+}
+
+// recordCredentials saves creds in ctx's credentialsRecord, if any, unless
+// the call already resolved credentials.
+func recordCredentials(ctx context.Context, creds *auth.Credentials) {
+	record, ok := ctx.Value(credentialsRecordKey{}).(*credentialsRecord)
+	if !ok {
+		return
+	}
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	if record.creds == nil {
+		record.creds = creds
+	}
+}
+
+// resolved returns the recorded credentials, or nil if none were resolved.
+func (c *credentialsRecord) resolved() *auth.Credentials {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.creds
+}
+
+// ResolvedEnvironment identifies the director a tool call used.
+type ResolvedEnvironment struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url"`
+	Source string `json:"source"`
+}
+
+func resolvedEnvironment(creds *auth.Credentials) ResolvedEnvironment {
+	return ResolvedEnvironment{Name: creds.Name, URL: creds.Environment, Source: creds.Source}
+}
+
+// String describes the environment for text output, e.g. "sandbox (https://10.0.0.5:25555)".
+func (e ResolvedEnvironment) String() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s (from %s)", e.URL, e.Source)
+	}
+	return fmt.Sprintf("%s (%s)", e.Name, e.URL)
+}
+
+// EnvironmentMiddleware adds the environment a tool call resolved to, by
+// name, URL, and credential source, to its result's _meta and as a final
+// line of text. The environment is the one the handler's own credential
+// lookup resolved, so nothing is added if it failed before resolving one.
+// Fan-out calls already tag each environment's result, and tools without
+// an environment argument name their environments themselves, so both are
+// left alone.
+func (r *Registry) EnvironmentMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		record := &credentialsRecord{}
+		result, err := next(context.WithValue(ctx, credentialsRecordKey{}, record), request)
+		if err != nil || result == nil {
+			return result, err
+		}
+		if len(request.GetStringSlice("environments", nil)) > 0 || !takesEnvironment(ctx, request.Params.Name) {
+			return result, nil
+		}
+
+		creds := record.resolved()
+		if creds == nil {
+			return result, nil
+		}
+		return withEnvironment(result, resolvedEnvironment(creds)), nil
+	}
+}
+
+// takesEnvironment returns whether the named tool has an environment
+// argument. Without a server in ctx every tool is assumed to.
+func takesEnvironment(ctx context.Context, name string) bool {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return true
+	}
+	tool := srv.GetTool(name)
+	if tool == nil {
+		return false
+	}
+	_, ok := tool.Tool.InputSchema.Properties["environment"]
+	return ok
+}
+
+// withEnvironment returns a copy of result recording env.
+func withEnvironment(result *mcp.CallToolResult, env ResolvedEnvironment) *mcp.CallToolResult {
+	tagged := *result
+
+	fields := make(map[string]any)
+	var progressToken mcp.ProgressToken
+	if result.Meta != nil {
+		maps.Copy(fields, result.Meta.AdditionalFields)
+		progressToken = result.Meta.ProgressToken
+	}
+	fields[environmentMetaKey] = env
+	tagged.Meta = &mcp.Meta{ProgressToken: progressToken, AdditionalFields: fields}
+
+	tagged.Content = append(append([]mcp.Content{}, result.Content...), mcp.NewTextContent("Environment: "+env.String()))
+	return &tagged
+}
@@ -0,0 +1,137 @@
+// ABOUTME: Tests for echoing the resolved BOSH environment in tool results.
+// ABOUTME: Verifies _meta and text tagging, and that tools without an environment argument are left alone.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func okHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return toolResult(map[string]string{"status": "ok"})
+}
+
+// resolvingHandler resolves the call's environment like a tool handler does.
+func resolvingHandler(registry *Registry) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, err := registry.credentials(ctx, request.GetString("environment", "")); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return okHandler(ctx, request)
+	}
+}
+
+func TestEnvironmentMiddleware_TagsResult(t *testing.T) {
+	configPath := writeBoshConfig(t, map[string]string{
+		"sandbox": "https://sandbox.example.com:25555",
+		"prod":    "https://prod.example.com:25555",
+	})
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	t.Setenv("OM_TARGET", "")
+
+	registry := NewRegistry(auth.NewProvider(configPath))
+	handler := registry.EnvironmentMiddleware(resolvingHandler(registry))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"environment": "https://prod.example.com:25555"}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := ResolvedEnvironment{Name: "prod", URL: "https://prod.example.com:25555", Source: auth.SourceConfig}
+	if result.Meta == nil || result.Meta.AdditionalFields[environmentMetaKey] != want {
+		t.Errorf("expected _meta environment %+v, got %+v", want, result.Meta)
+	}
+	if len(result.Content) != 2 {
+		t.Fatalf("expected the result and an environment line, got %d contents", len(result.Content))
+	}
+	if !json.Valid([]byte(result.Content[0].(mcp.TextContent).Text)) {
+		t.Errorf("expected the JSON result to be untouched, got %s", result.Content[0].(mcp.TextContent).Text)
+	}
+	if text := result.Content[1].(mcp.TextContent).Text; text != "Environment: prod (https://prod.example.com:25555)" {
+		t.Errorf("unexpected environment line: %s", text)
+	}
+}
+
+func TestEnvironmentMiddleware_EnvVars(t *testing.T) {
+	t.Setenv("BOSH_ENVIRONMENT", "https://env.example.com:25555")
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider("/nonexistent/config"))
+	result, _ := registry.EnvironmentMiddleware(resolvingHandler(registry))(context.Background(), mcp.CallToolRequest{})
+
+	text := result.Content[len(result.Content)-1].(mcp.TextContent).Text
+	if text != "Environment: https://env.example.com:25555 (from env)" {
+		t.Errorf("unexpected environment line: %s", text)
+	}
+}
+
+func TestEnvironmentMiddleware_SkipsToolsWithoutEnvironment(t *testing.T) {
+	t.Setenv("BOSH_ENVIRONMENT", "https://env.example.com:25555")
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider("/nonexistent/config"))
+	s := server.NewMCPServer("test", "0.0.0",
+		server.WithToolCapabilities(true),
+		server.WithToolHandlerMiddleware(registry.EnvironmentMiddleware),
+	)
+	s.AddTool(mcp.NewTool("with_environment", mcp.WithString("environment")), resolvingHandler(registry))
+	s.AddTool(mcp.NewTool("without_environment", mcp.WithString("source")), resolvingHandler(registry))
+
+	call := func(name string) string {
+		message := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + name + `","arguments":{}}}`
+		response := s.HandleMessage(context.Background(), json.RawMessage(message))
+		data, _ := json.Marshal(response)
+		return string(data)
+	}
+
+	if response := call("with_environment"); !strings.Contains(response, `"_meta":{"environment":{"url":"https://env.example.com:25555","source":"env"}}`) {
+		t.Errorf("expected environment in _meta, got %s", response)
+	}
+	if response := call("without_environment"); strings.Contains(response, "Environment:") || strings.Contains(response, "_meta") {
+		t.Errorf("expected no environment for a tool without an environment argument, got %s", response)
+	}
+}
+
+func TestEnvironmentMiddleware_EchoesHandlerCredentials(t *testing.T) {
+	configPath := writeBoshConfig(t, map[string]string{
+		"sandbox": "https://sandbox.example.com:25555",
+		"prod":    "https://prod.example.com:25555",
+	})
+	t.Setenv("BOSH_ENVIRONMENT", "")
+	t.Setenv("OM_TARGET", "")
+
+	registry := NewRegistry(auth.NewProvider(configPath))
+
+	// The echoed environment is the one the handler resolved, not a second
+	// lookup of the environment argument.
+	handler := registry.EnvironmentMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, err := registry.credentials(ctx, "sandbox"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return okHandler(ctx, request)
+	})
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"environment": "prod"}
+	result, _ := handler(context.Background(), request)
+	if text := result.Content[len(result.Content)-1].(mcp.TextContent).Text; text != "Environment: sandbox (https://sandbox.example.com:25555)" {
+		t.Errorf("expected the handler's environment, got %s", text)
+	}
+
+	// A call that never resolves credentials names no environment.
+	result, _ = registry.EnvironmentMiddleware(okHandler)(context.Background(), request)
+	if result.Meta != nil || len(result.Content) != 1 {
+		t.Errorf("expected no environment without resolved credentials, got %+v", result)
+	}
+}
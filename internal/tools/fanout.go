@@ -0,0 +1,224 @@
+// ABOUTME: Runs read-only tools against several BOSH environments concurrently.
+// ABOUTME: Tags each result with its environment and reports per-environment errors.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fanOutConcurrency bounds how many directors are queried at once.
+const fanOutConcurrency = 4
+
+// allEnvironments selects every environment in the BOSH config file.
+const allEnvironments = "*"
+
+// FanOutResult is returned by a read-only tool called with environments.
+type FanOutResult struct {
+	Environments []EnvironmentResult `json:"environments"`
+}
+
+// EnvironmentResult is one environment's result or error within a fan-out call.
+// Result has the tool's usual result type.
+type EnvironmentResult struct {
+	Environment string `json:"environment"`
+	URL         string `json:"url,omitempty"`
+	Result      any    `json:"result,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// registryHandler is a tool handler method expression, e.g. (*Registry).handleBoshVMs,
+// so that fan-out calls can run it against a registry that resolves named environments.
+type registryHandler func(*Registry, context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+// addFanOutTool registers a read-only tool that also accepts an environments
+// argument. Its output schema is extended to describe fan-out results.
+func (r *Registry) addFanOutTool(s *server.MCPServer, tool mcp.Tool, handler registryHandler) {
+	mcp.WithArray("environments",
+		mcp.WithStringItems(),
+		mcp.Description(`Query these named environments from ~/.bosh/config concurrently; ["*"] queries all of them`))(&tool)
+	tool.RawOutputSchema = fanOutSchema(tool.RawOutputSchema)
+
+	s.AddTool(tool, r.fanOut(requireSupportRegistry(tool.Name, handler)))
+}
+
+// fanOut calls handler once, or once per environment when environments is set.
+func (r *Registry) fanOut(handler registryHandler) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		requested := request.GetStringSlice("environments", nil)
+		if len(requested) == 0 {
+			return handler(r, ctx, request)
+		}
+		if request.GetString("environment", "") != "" {
+			return mcp.NewToolResultError("specify either environment or environments, not both"), nil
+		}
+
+		names, err := r.resolveEnvironments(requested)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		named := r.namedRegistry()
+
+		results := make([]EnvironmentResult, len(names))
+		texts := make([]string, len(names))
+		sem := make(chan struct{}, fanOutConcurrency)
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				results[i], texts[i] = named.callEnvironment(ctx, handler, request, name)
+			}(i, name)
+		}
+		wg.Wait()
+
+		result := FanOutResult{Environments: results}
+		if request.GetString("format", formatJSON) == formatJSON {
+			return toolResult(result)
+		}
+
+		var b strings.Builder
+		for i, name := range names {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			label := name
+			if results[i].URL != "" {
+				label = fmt.Sprintf("%s (%s)", name, results[i].URL)
+			}
+			fmt.Fprintf(&b, "Environment: %s\n\n%s\n", label, strings.TrimRight(texts[i], "\n"))
+		}
+		return mcp.NewToolResultStructured(result, b.String()), nil
+	}
+}
+
+// namedRegistry returns a registry that resolves environments only from the
+// BOSH config file. It has its own lookup cache since names may resolve to
+// different directors than in r; CredHub clients are cached by Director, so
+// they are shared.
+func (r *Registry) namedRegistry() *Registry {
+	return &Registry{
+		authProvider: r.authProvider,
+		lookups:      newLookupCache(lookupCacheTTL),
+		infos:        newInfoCache(),
+		credhubs:     r.credhubs,
+		namedOnly:    true,
+	}
+}
+
+// callEnvironment runs handler against one environment and returns its
+// structured result and text content.
+func (r *Registry) callEnvironment(ctx context.Context, handler registryHandler, request mcp.CallToolRequest, environment string) (EnvironmentResult, string) {
+	args := make(map[string]any, len(request.GetArguments())+1)
+	for key, value := range request.GetArguments() {
+		args[key] = value
+	}
+	delete(args, "environments")
+	args["environment"] = environment
+	request.Params.Arguments = args
+
+	envResult := EnvironmentResult{Environment: environment}
+	if creds, err := r.credentials(ctx, environment); err == nil {
+		envResult.URL = creds.Environment
+	}
+
+	result, err := handler(r, ctx, request)
+	if err != nil {
+		envResult.Error = err.Error()
+		return envResult, "error: " + envResult.Error
+	}
+
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+
+	if result.IsError {
+		envResult.Error = text
+		return envResult, "error: " + text
+	}
+
+	envResult.Result = result.StructuredContent
+	return envResult, text
+}
+
+// resolveEnvironments expands "*" to every environment in the BOSH config
+// file and removes duplicates, preserving order.
+func (r *Registry) resolveEnvironments(requested []string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, name := range requested {
+		expanded := []string{name}
+		if name == allEnvironments {
+			all, err := r.authProvider.Environments()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list environments: %v", err)
+			}
+			if len(all) == 0 {
+				return nil, fmt.Errorf("no environments found in BOSH config")
+			}
+			expanded = all
+		}
+		for _, n := range expanded {
+			if n != "" && !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("environments must name at least one environment or \"*\"")
+	}
+	return names, nil
+}
+
+// fanOutSchema adds an environments property to a tool's output schema whose
+// items carry the tool's usual result under result.
+func fanOutSchema(base json.RawMessage) json.RawMessage {
+	var schema map[string]any
+	if err := json.Unmarshal(base, &schema); err != nil {
+		return base
+	}
+
+	var item map[string]any
+	if err := json.Unmarshal(schemaFor[EnvironmentResult](), &item); err != nil {
+		return base
+	}
+	var result map[string]any
+	json.Unmarshal(base, &result)
+	if properties, ok := item["properties"].(map[string]any); ok {
+		properties["result"] = result
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	if properties == nil {
+		properties = make(map[string]any)
+		schema["properties"] = properties
+	}
+	properties["environments"] = map[string]any{
+		"type":        "array",
+		"items":       item,
+		"description": "Per-environment results when called with environments",
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return base
+	}
+	return data
+}
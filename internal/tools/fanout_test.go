@@ -0,0 +1,172 @@
+// ABOUTME: Tests for querying read-only tools across several environments.
+// ABOUTME: Uses a temporary BOSH config pointing at multiple mock Directors.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func newStemcellDirector(t *testing.T, version string) *httptest.Server {
+	t.Helper()
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stemcells" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]bosh.Stemcell{{Name: "bosh-stemcell", OperatingSystem: "ubuntu-jammy", Version: version}})
+	}))
+}
+
+// testCA returns the PEM of server's certificate, for clients to trust.
+func testCA(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+// writeBoshConfig writes a BOSH config with one environment per name/URL
+// pair, trusting the certificate every httptest TLS server shares.
+func writeBoshConfig(t *testing.T, urls map[string]string) string {
+	t.Helper()
+	probe := httptest.NewTLSServer(nil)
+	caCert := strings.ReplaceAll(strings.TrimSpace(testCA(probe)), "\n", "\n      ")
+	probe.Close()
+
+	var b strings.Builder
+	b.WriteString("environments:\n")
+	for name, url := range urls {
+		fmt.Fprintf(&b, "  %s:\n    url: %s\n    client: admin\n    client_secret: secret\n    ca_cert: |\n      %s\n", name, url, caCert)
+	}
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestFanOut_AllEnvironments(t *testing.T) {
+	sandbox := newStemcellDirector(t, "1.100")
+	defer sandbox.Close()
+	prod := newStemcellDirector(t, "1.90")
+	defer prod.Close()
+	broken := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("bad credentials"))
+	}))
+	defer broken.Close()
+
+	configPath := writeBoshConfig(t, map[string]string{
+		"sandbox": sandbox.URL,
+		"prod":    prod.URL,
+		"broken":  broken.URL,
+	})
+
+	// Env vars point at a director that must not be queried in fan-out mode
+	t.Setenv("BOSH_ENVIRONMENT", broken.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(broken))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(configPath))
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	registry.RegisterTools(s)
+	tool := s.GetTool("bosh_stemcells")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"environments": []interface{}{"*"},
+	}
+
+	result, err := tool.Handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+
+	fanOut := result.StructuredContent.(FanOutResult)
+	if len(fanOut.Environments) != 3 {
+		t.Fatalf("expected 3 environments, got %d", len(fanOut.Environments))
+	}
+
+	byName := map[string]EnvironmentResult{}
+	for _, env := range fanOut.Environments {
+		byName[env.Environment] = env
+	}
+	if !strings.Contains(byName["broken"].Error, "bad credentials") {
+		t.Errorf("expected broken environment error, got %+v", byName["broken"])
+	}
+	for name, version := range map[string]string{"sandbox": "1.100", "prod": "1.90"} {
+		stemcells, ok := byName[name].Result.(StemcellsResult)
+		if !ok || len(stemcells.Stemcells) != 1 || stemcells.Stemcells[0].Version != version {
+			t.Errorf("unexpected %s result: %+v", name, byName[name])
+		}
+	}
+
+	var schema map[string]interface{}
+	json.Unmarshal(tool.Tool.RawOutputSchema, &schema)
+	var value interface{}
+	data, _ := json.Marshal(result.StructuredContent)
+	json.Unmarshal(data, &value)
+	if problems := validateSchema("$", value, schema); len(problems) > 0 {
+		t.Errorf("fan-out result does not match schema: %v", problems)
+	}
+}
+
+func TestFanOut_TableFormatTagsEnvironments(t *testing.T) {
+	sandbox := newStemcellDirector(t, "1.100")
+	defer sandbox.Close()
+
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": sandbox.URL})
+	t.Setenv("BOSH_ENVIRONMENT", "")
+
+	registry := NewRegistry(auth.NewProvider(configPath))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"environments": []interface{}{"sandbox", "missing"},
+		"format":       "table",
+	}
+
+	result, err := registry.fanOut((*Registry).handleBoshStemcells)(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Environment: sandbox ("+sandbox.URL+")") || !strings.Contains(text, "1.100") {
+		t.Errorf("expected sandbox table, got:\n%s", text)
+	}
+	if !strings.Contains(text, "Environment: missing") || !strings.Contains(text, `"missing" not found`) {
+		t.Errorf("expected missing environment error, got:\n%s", text)
+	}
+}
+
+func TestFanOut_RejectsEnvironmentAndEnvironments(t *testing.T) {
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"environment":  "sandbox",
+		"environments": []interface{}{"prod"},
+	}
+
+	result, _ := registry.fanOut((*Registry).handleBoshStemcells)(context.Background(), request)
+	if !result.IsError {
+		t.Error("expected error when both environment and environments are set")
+	}
+}
@@ -0,0 +1,251 @@
+// ABOUTME: Renders list tool results as aligned tables, markdown tables, or CSV.
+// ABOUTME: Compact formats cut tokens for wide lists compared to indented JSON.
+
+package tools
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	formatJSON     = "json"
+	formatTable    = "table"
+	formatMarkdown = "markdown"
+	formatCSV      = "csv"
+)
+
+// outputFormats lists the values accepted by the format argument.
+var outputFormats = []string{formatJSON, formatTable, formatMarkdown, formatCSV}
+
+// defaultColumns lists the table columns for each list key, following the BOSH CLI.
+var defaultColumns = map[string][]string{
+	"vms":          {"job", "index", "id", "process_state", "az", "ips", "vm_cid", "vm_type", "active"},
+	"instances":    {"job", "index", "id", "state", "az", "ips", "processes"},
+	"vitals":       {"instance", "az", "process_state", "cpu_percent", "memory_percent", "swap_percent", "load_1m", "system_disk_percent", "ephemeral_disk_percent", "persistent_disk_percent", "alerts"},
+	"tasks":        {"id", "state", "timestamp", "user", "deployment", "description", "result"},
+	"releases":     {"name", "version", "commit_hash"},
+	"groups":       {"name", "versions"},
+	"stemcells":    {"name", "operating_system", "version", "cid", "deployments"},
+	"deployments":  {"name", "releases", "stemcells", "cloud_config"},
+	"variables":    {"id", "name"},
+	"certificates": {"deployment", "name", "not_after", "days_remaining", "issuer", "newer_version_id"},
+	"locks":        {"type", "resource", "task_id", "timeout"},
+	"disks":        {"disk_cid", "size", "deployment_name", "instance_name", "az", "orphaned_at"},
+	"configs":      {"id", "type", "name", "team", "created_at", "current"},
+}
+
+// headerWords maps field name parts to their BOSH CLI capitalization.
+var headerWords = map[string]string{
+	"id":  "ID",
+	"az":  "AZ",
+	"ip":  "IP",
+	"ips": "IPs",
+	"vm":  "VM",
+	"cid": "CID",
+	"cpi": "CPI",
+}
+
+// withFormat adds the format and sort arguments to a list tool.
+func withFormat() mcp.ToolOption {
+	return withOptions(
+		mcp.WithString("format",
+			mcp.Enum(outputFormats...),
+			mcp.Description("Text output format (default: json). table, markdown and csv use far fewer tokens for wide lists")),
+		mcp.WithString("sort",
+			mcp.Description("Sort by this field; prefix with - for descending (e.g. -timestamp)")),
+	)
+}
+
+// renderList renders the items under listKey as the given format with the given columns.
+// Scalar top-level fields (e.g. deployment) are shown above table and markdown output.
+func renderList(v any, listKey string, columns []string, format string) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", err
+	}
+
+	items, _ := obj[listKey].([]any)
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		entry, _ := item.(map[string]any)
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = formatCell(entry[column])
+		}
+		rows = append(rows, row)
+	}
+
+	var preamble []string
+	for _, key := range sortedKeys(obj) {
+		if key == listKey {
+			continue
+		}
+		switch value := obj[key].(type) {
+		case string, float64, bool:
+			preamble = append(preamble, fmt.Sprintf("%s: %s", headerName(key), formatCell(value)))
+		}
+	}
+
+	var b strings.Builder
+	switch format {
+	case formatCSV:
+		w := csv.NewWriter(&b)
+		w.Write(columns)
+		w.WriteAll(rows)
+		if err := w.Error(); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	case formatMarkdown:
+		for _, line := range preamble {
+			fmt.Fprintf(&b, "**%s\n\n", strings.Replace(line, ":", ":**", 1))
+		}
+		b.WriteString(renderMarkdown(headers(columns), rows))
+	default:
+		for _, line := range preamble {
+			b.WriteString(line + "\n")
+		}
+		if len(preamble) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(renderTable(headers(columns), rows))
+		fmt.Fprintf(&b, "\n%d %s\n", len(rows), strings.ReplaceAll(listKey, "_", " "))
+	}
+
+	return b.String(), nil
+}
+
+// renderTable renders rows as space-aligned columns like the BOSH CLI.
+func renderTable(header []string, rows [][]string) string {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		var line strings.Builder
+		for i, cell := range cells {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+
+	writeRow(header)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// renderMarkdown renders rows as a GitHub-flavored markdown table.
+func renderMarkdown(header []string, rows [][]string) string {
+	escape := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(escape(header))
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	b.WriteString("|" + strings.Join(separators, "|") + "|\n")
+	for _, row := range rows {
+		b.WriteString(escape(row))
+	}
+	return b.String()
+}
+
+// formatCell renders a JSON value compactly for a table cell.
+// Name/version pairs render as name/version and processes as name (state).
+func formatCell(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		parts := make([]string, len(value))
+		for i, elem := range value {
+			parts[i] = formatCell(elem)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		name, hasName := value["name"].(string)
+		if version, ok := value["version"].(string); ok && hasName {
+			return name + "/" + version
+		}
+		if state, ok := value["state"].(string); ok && hasName {
+			return fmt.Sprintf("%s (%s)", name, state)
+		}
+		if hasName {
+			return name
+		}
+		data, _ := json.Marshal(value)
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func headers(columns []string) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = headerName(column)
+	}
+	return names
+}
+
+// headerName converts a JSON field name to a table header, e.g. vm_cid to VM CID.
+func headerName(field string) string {
+	words := strings.Split(field, "_")
+	for i, word := range words {
+		if special, ok := headerWords[word]; ok {
+			words[i] = special
+		} else if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func sortedKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
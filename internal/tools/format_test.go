@@ -0,0 +1,162 @@
+// ABOUTME: Tests for table, markdown, and CSV rendering of list results.
+// ABOUTME: Verifies column selection, sorting, and token savings over JSON.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRenderList_Table(t *testing.T) {
+	result := VMsResult{
+		Deployment: "cf",
+		VMs: []bosh.VM{
+			{Job: "router", Index: 0, ProcessState: "running", IPs: []string{"10.0.0.1"}},
+			{Job: "diego_cell", Index: 12, ProcessState: "failing", IPs: []string{"10.0.0.2", "10.0.0.3"}},
+		},
+	}
+
+	text, err := renderList(result, "vms", []string{"job", "index", "process_state", "ips"}, formatTable)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `Deployment: cf
+
+Job         Index  Process State  IPs
+router      0      running        10.0.0.1
+diego_cell  12     failing        10.0.0.2, 10.0.0.3
+
+2 vms
+`
+	if text != expected {
+		t.Errorf("unexpected table:\n%s\nexpected:\n%s", text, expected)
+	}
+}
+
+func TestRenderList_MarkdownAndCSV(t *testing.T) {
+	result := DeploymentsResult{
+		Deployments: []bosh.Deployment{{
+			Name:     "cf",
+			Releases: []bosh.NameVersion{{Name: "cf", Version: "1.0"}, {Name: "routing", Version: "0.9"}},
+		}},
+	}
+
+	markdown, err := renderList(result, "deployments", []string{"name", "releases"}, formatMarkdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(markdown, "| Name | Releases |\n|---|---|\n| cf | cf/1.0, routing/0.9 |") {
+		t.Errorf("unexpected markdown:\n%s", markdown)
+	}
+
+	csvText, err := renderList(result, "deployments", []string{"name", "releases"}, formatCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if csvText != "name,releases\ncf,\"cf/1.0, routing/0.9\"\n" {
+		t.Errorf("unexpected csv:\n%s", csvText)
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	tasks := []bosh.Task{{ID: 2, State: "done"}, {ID: 10, State: "error"}, {ID: 1, State: "done"}}
+
+	sortItems(tasks, "-id")
+	if tasks[0].ID != 10 || tasks[2].ID != 1 {
+		t.Errorf("expected descending numeric sort, got %+v", tasks)
+	}
+
+	sortItems(tasks, "state")
+	if tasks[2].State != "error" {
+		t.Errorf("expected error last, got %+v", tasks)
+	}
+}
+
+func TestHandleBoshInstances_TableFormat(t *testing.T) {
+	instances := make([]bosh.Instance, 0, 100)
+	for i := 0; i < 100; i++ {
+		instances = append(instances, bosh.Instance{
+			Job: "diego_cell", Index: i, ID: "8c1b3f4e-5d2a-4c3b-9e1f-0a2b3c4d5e6f", AZ: "z1", State: "running",
+			IPs: []string{"10.0.1.5"}, VMCID: "vm-8c1b3f4e", VMType: "large", AgentID: "agent-1234",
+			Processes: []bosh.Process{{Name: "rep", State: "running"}, {Name: "garden", State: "running"}},
+		})
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(instances)
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	call := func(format string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"deployment": "cf",
+			"format":     format,
+			"page_size":  float64(100),
+			"sort":       "-index",
+		}
+		result, err := registry.handleBoshInstances(context.Background(), request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.IsError {
+			t.Fatalf("expected success, got error: %v", result.Content)
+		}
+		return result
+	}
+
+	jsonText := call(formatJSON).Content[0].(mcp.TextContent).Text
+	tableResult := call(formatTable)
+	tableText := tableResult.Content[0].(mcp.TextContent).Text
+
+	if !strings.Contains(tableText, "diego_cell  99") {
+		t.Errorf("expected first row sorted by descending index, got:\n%s", tableText[:200])
+	}
+	if !strings.Contains(tableText, "rep (running), garden (running)") {
+		t.Error("expected processes rendered as name (state)")
+	}
+	if len(tableText)*2 > len(jsonText) {
+		t.Errorf("expected table to be under half the size of JSON: table %d, json %d", len(tableText), len(jsonText))
+	}
+	if _, ok := tableResult.StructuredContent.(InstancesResult); !ok {
+		t.Error("expected structured content to remain typed")
+	}
+}
+
+func TestHandleBoshStemcells_InvalidFormat(t *testing.T) {
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"format": "yaml",
+	}
+
+	result, err := registry.handleBoshStemcells(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error for unknown format")
+	}
+	if !strings.Contains(result.Content[0].(mcp.TextContent).Text, `unknown format "yaml"`) {
+		t.Errorf("unexpected error: %v", result.Content)
+	}
+}
@@ -0,0 +1,303 @@
+// ABOUTME: Implements the bosh_health tool that summarizes deployment health.
+// ABOUTME: Combines instances, VMs, locks, and recent failed tasks into a per-deployment verdict.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	verdictHealthy   = "healthy"
+	verdictDegraded  = "degraded"
+	verdictUnhealthy = "unhealthy"
+	verdictUnknown   = "unknown"
+
+	severityCritical = "critical"
+	severityWarning  = "warning"
+
+	// defaultHealthWindow is how far back failed tasks count as recent errors.
+	defaultHealthWindow = 24 * time.Hour
+
+	// healthConcurrency bounds parallel deployment checks when checking all deployments.
+	healthConcurrency = 4
+
+	// healthTaskLimit is how many failed tasks are fetched when looking for recent errors.
+	healthTaskLimit = 200
+)
+
+// verdictRank orders verdicts from best to worst.
+var verdictRank = map[string]int{
+	verdictHealthy:   0,
+	verdictDegraded:  1,
+	verdictUnknown:   2,
+	verdictUnhealthy: 3,
+}
+
+func (r *Registry) handleBoshHealth(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deployment := request.GetString("deployment", "")
+	environment := request.GetString("environment", "")
+
+	windowHours := request.GetFloat("window_hours", defaultHealthWindow.Hours())
+	if windowHours <= 0 {
+		return mcp.NewToolResultError("window_hours must be positive"), nil
+	}
+	since := time.Now().Add(-time.Duration(windowHours * float64(time.Hour)))
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	deployments := []string{deployment}
+	if deployment == "" {
+		list, err := client.ListDeployments()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list deployments: %v", err)), nil
+		}
+		deployments = make([]string, len(list))
+		for i, d := range list {
+			deployments[i] = d.Name
+		}
+	}
+
+	locks, err := client.ListLocks()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list locks: %v", err)), nil
+	}
+
+	failed, err := client.ListTasks(bosh.TaskFilter{State: "error", Deployment: deployment, Limit: healthTaskLimit})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list tasks: %v", err)), nil
+	}
+
+	reports := make([]DeploymentHealth, len(deployments))
+	errs := make([]error, len(deployments))
+	sem := make(chan struct{}, healthConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range deployments {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			reports[i], errs[i] = checkDeploymentHealth(ctx, client, name, locks, failed, since)
+		}(i, name)
+	}
+	wg.Wait()
+
+	if deployment != "" && errs[0] != nil {
+		return r.deploymentError(ctx, environment, deployment, "check health", errs[0]), nil
+	}
+
+	result := HealthResult{
+		Verdict:     verdictHealthy,
+		Deployments: reports,
+	}
+	for _, report := range reports {
+		result.Verdict = worseVerdict(result.Verdict, report.Verdict)
+	}
+
+	return toolResult(result)
+}
+
+// checkDeploymentHealth gathers instance and VM state for one deployment and
+// evaluates it. On a fetch failure the report has an unknown verdict and the error.
+func checkDeploymentHealth(ctx context.Context, client *bosh.Client, deployment string, locks []bosh.Lock, failed []bosh.Task, since time.Time) (DeploymentHealth, error) {
+	unknown := func(action string, err error) (DeploymentHealth, error) {
+		return DeploymentHealth{
+			Deployment: deployment,
+			Verdict:    verdictUnknown,
+			Issues:     []HealthIssue{},
+			Error:      fmt.Sprintf("failed to %s: %v", action, err),
+		}, err
+	}
+
+	instances, err := client.ListInstancesWithProcesses(ctx, deployment)
+	if err != nil {
+		return unknown("list instances", err)
+	}
+
+	vms, err := client.ListVMs(deployment)
+	if err != nil {
+		return unknown("list VMs", err)
+	}
+
+	return evaluateHealth(deployment, instances, vms, locks, failed, since), nil
+}
+
+// evaluateHealth builds a health report from a deployment's instances and VMs,
+// the director's locks, and failed tasks.
+func evaluateHealth(deployment string, instances []bosh.Instance, vms []bosh.VM, locks []bosh.Lock, failed []bosh.Task, since time.Time) DeploymentHealth {
+	report := DeploymentHealth{
+		Deployment: deployment,
+		Instances:  len(instances),
+		Issues:     []HealthIssue{},
+	}
+
+	vmsByID := make(map[string]bosh.VM, len(vms))
+	for _, vm := range vms {
+		vmsByID[vm.ID] = vm
+	}
+
+	for _, inst := range instances {
+		name := instanceName(inst.Job, inst.ID, inst.Index)
+		vm, hasVM := vmsByID[inst.ID]
+		stopped := stoppedState(inst, vm, hasVM)
+
+		switch {
+		case bool(inst.Expects) && inst.VMCID == "":
+			report.addIssue(severityCritical, "missing_vm", name, "instance expects a VM but has none")
+			continue
+		case inst.ProcessState == "unresponsive agent" || (hasVM && vm.ProcessState == "unresponsive agent"):
+			report.addIssue(severityCritical, "unresponsive_agent", name, "agent is not responding")
+		case stopped != "":
+			report.Stopped++
+			report.addIssue(severityWarning, "stopped", name, fmt.Sprintf("instance is %s", stopped))
+			continue
+		case inst.ProcessState == "running":
+			report.Running++
+		case inst.ProcessState != "":
+			report.addIssue(severityCritical, "failing_instance", name, fmt.Sprintf("instance is %s", inst.ProcessState))
+		}
+
+		for _, p := range inst.Processes {
+			if p.State != "running" {
+				report.addIssue(severityCritical, "failing_process", name, fmt.Sprintf("process %s is %s", p.Name, p.State))
+			}
+		}
+	}
+
+	report.Issues = append(report.Issues, azImbalance(instances)...)
+
+	for _, lock := range locks {
+		if lock.Resource == deployment {
+			report.Locked = true
+			report.addIssue(severityWarning, "locked", "", fmt.Sprintf("%s lock held by task %s", lock.Type, lock.TaskID))
+		}
+	}
+
+	report.RecentErrors = []bosh.Task{}
+	for _, task := range failed {
+		if task.Deployment == deployment && task.Timestamp >= since.Unix() {
+			report.RecentErrors = append(report.RecentErrors, task)
+		}
+	}
+	if n := len(report.RecentErrors); n > 0 {
+		report.addIssue(severityWarning, "recent_errors", "", fmt.Sprintf("%d failed task(s) since %s, most recent: %s", n, since.UTC().Format(time.RFC3339), report.RecentErrors[0].Description))
+	}
+
+	report.Verdict = verdictHealthy
+	for _, issue := range report.Issues {
+		if issue.Severity == severityCritical {
+			report.Verdict = verdictUnhealthy
+			break
+		}
+		report.Verdict = verdictDegraded
+	}
+
+	return report
+}
+
+// stoppedState returns the desired state of an instance that was stopped
+// or detached on purpose, from its VM or the instance itself, or "".
+func stoppedState(inst bosh.Instance, vm bosh.VM, hasVM bool) string {
+	switch {
+	case hasVM && (vm.State == "stopped" || vm.State == "detached"):
+		return vm.State
+	case inst.State == "stopped" || inst.State == "detached":
+		return inst.State
+	}
+	return ""
+}
+
+// azImbalance reports instance groups whose running instances differ by more
+// than one between the availability zones the group is placed in.
+func azImbalance(instances []bosh.Instance) []HealthIssue {
+	placed := make(map[string]map[string]int)
+	for _, inst := range instances {
+		if inst.AZ == "" {
+			continue
+		}
+		counts := placed[inst.Job]
+		if counts == nil {
+			counts = make(map[string]int)
+			placed[inst.Job] = counts
+		}
+		if _, ok := counts[inst.AZ]; !ok {
+			counts[inst.AZ] = 0
+		}
+		if inst.ProcessState == "running" {
+			counts[inst.AZ]++
+		}
+	}
+
+	jobs := make([]string, 0, len(placed))
+	for job := range placed {
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+
+	issues := []HealthIssue{}
+	for _, job := range jobs {
+		counts := placed[job]
+		if len(counts) < 2 {
+			continue
+		}
+		azs := make([]string, 0, len(counts))
+		for az := range counts {
+			azs = append(azs, az)
+		}
+		sort.Strings(azs)
+
+		minAZ, maxAZ := azs[0], azs[0]
+		for _, az := range azs {
+			if counts[az] < counts[minAZ] {
+				minAZ = az
+			}
+			if counts[az] > counts[maxAZ] {
+				maxAZ = az
+			}
+		}
+		if counts[maxAZ]-counts[minAZ] > 1 {
+			issues = append(issues, HealthIssue{
+				Severity: severityWarning,
+				Check:    "az_imbalance",
+				Instance: job,
+				Message:  fmt.Sprintf("%d running in %s but %d in %s", counts[maxAZ], maxAZ, counts[minAZ], minAZ),
+			})
+		}
+	}
+	return issues
+}
+
+func (h *DeploymentHealth) addIssue(severity, check, instance, message string) {
+	h.Issues = append(h.Issues, HealthIssue{
+		Severity: severity,
+		Check:    check,
+		Instance: instance,
+		Message:  message,
+	})
+}
+
+// instanceName formats an instance like the BOSH CLI, e.g. router/6f1c...
+func instanceName(job, id string, index int) string {
+	if id == "" {
+		return fmt.Sprintf("%s/%d", job, index)
+	}
+	return job + "/" + id
+}
+
+func worseVerdict(a, b string) string {
+	if verdictRank[b] > verdictRank[a] {
+		return b
+	}
+	return a
+}
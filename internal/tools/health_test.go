@@ -0,0 +1,205 @@
+// ABOUTME: Tests for the bosh_health deployment health summary.
+// ABOUTME: Covers each health check and checking all deployments in one call.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func issueChecks(issues []HealthIssue) []string {
+	checks := make([]string, len(issues))
+	for i, issue := range issues {
+		checks[i] = issue.Check
+	}
+	return checks
+}
+
+func TestEvaluateHealth_Healthy(t *testing.T) {
+	instances := []bosh.Instance{
+		{Job: "router", ID: "r1", AZ: "z1", State: "started", ProcessState: "running", VMCID: "vm-1", Expects: true},
+		{Job: "router", ID: "r2", AZ: "z2", State: "started", ProcessState: "running", VMCID: "vm-2", Expects: true},
+	}
+	vms := []bosh.VM{{ID: "r1", State: "started"}, {ID: "r2", State: "started"}}
+
+	report := evaluateHealth("cf", instances, vms, nil, nil, time.Now().Add(-time.Hour))
+	if report.Verdict != verdictHealthy {
+		t.Errorf("expected healthy, got %s with issues %+v", report.Verdict, report.Issues)
+	}
+	if report.Instances != 2 || report.Running != 2 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+}
+
+func TestEvaluateHealth_DetectsProblems(t *testing.T) {
+	now := time.Now()
+	instances := []bosh.Instance{
+		{Job: "api", ID: "a1", AZ: "z1", State: "started", ProcessState: "failing", VMCID: "vm-1", Expects: true,
+			Processes: []bosh.Process{{Name: "cloud_controller_ng", State: "failing"}, {Name: "nginx", State: "running"}}},
+		{Job: "api", ID: "a2", AZ: "z2", State: "started", ProcessState: "unresponsive agent", VMCID: "vm-2", Expects: true},
+		{Job: "api", ID: "a3", AZ: "z1", State: "stopped", ProcessState: "stopped", VMCID: "vm-3", Expects: true},
+		{Job: "api", ID: "a4", AZ: "z1", State: "", Expects: true},
+		{Job: "cell", ID: "c1", AZ: "z1", State: "started", ProcessState: "running", VMCID: "vm-5", Expects: true},
+		{Job: "cell", ID: "c2", AZ: "z1", State: "started", ProcessState: "running", VMCID: "vm-6", Expects: true},
+		{Job: "cell", ID: "c3", AZ: "z2", State: "started", ProcessState: "failing", VMCID: "vm-7", Expects: true},
+	}
+	vms := []bosh.VM{
+		{ID: "a1", State: "started"},
+		{ID: "a2", State: "started"},
+		{ID: "a3", State: "stopped"},
+		{ID: "c1", State: "started"},
+		{ID: "c2", State: "started"},
+		{ID: "c3", State: "started"},
+	}
+	locks := []bosh.Lock{{Type: "deployment", Resource: "cf", TaskID: "99"}}
+	failed := []bosh.Task{
+		{ID: 98, Deployment: "cf", Description: "run errand smoke_tests", Timestamp: now.Add(-time.Hour).Unix()},
+		{ID: 50, Deployment: "cf", Description: "old deploy", Timestamp: now.Add(-72 * time.Hour).Unix()},
+		{ID: 97, Deployment: "other", Description: "deploy", Timestamp: now.Unix()},
+	}
+
+	report := evaluateHealth("cf", instances, vms, locks, failed, now.Add(-24*time.Hour))
+
+	if report.Verdict != verdictUnhealthy {
+		t.Errorf("expected unhealthy, got %s", report.Verdict)
+	}
+	expected := []string{"failing_instance", "failing_process", "unresponsive_agent", "stopped", "missing_vm", "failing_instance", "az_imbalance", "locked", "recent_errors"}
+	if got := issueChecks(report.Issues); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected checks:\n got %v\nwant %v", got, expected)
+	}
+	if report.Running != 2 || report.Stopped != 1 || !report.Locked {
+		t.Errorf("unexpected summary: %+v", report)
+	}
+	if len(report.RecentErrors) != 1 || report.RecentErrors[0].ID != 98 {
+		t.Errorf("expected only task 98 as a recent error, got %+v", report.RecentErrors)
+	}
+	if report.Issues[0].Instance != "api/a1" {
+		t.Errorf("expected instance name api/a1, got %s", report.Issues[0].Instance)
+	}
+}
+
+func TestEvaluateHealth_DegradedOnWarningsOnly(t *testing.T) {
+	instances := []bosh.Instance{{Job: "router", ID: "r1", State: "started", ProcessState: "running", VMCID: "vm-1", Expects: true}}
+	locks := []bosh.Lock{{Type: "deployment", Resource: "cf", TaskID: "7"}}
+
+	report := evaluateHealth("cf", instances, nil, locks, nil, time.Now())
+	if report.Verdict != verdictDegraded {
+		t.Errorf("expected degraded, got %s", report.Verdict)
+	}
+}
+
+func TestHandleBoshHealth_AllDeployments(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/deployments":
+			json.NewEncoder(w).Encode([]bosh.Deployment{{Name: "cf"}, {Name: "redis"}, {Name: "broken"}})
+		case "/locks":
+			json.NewEncoder(w).Encode([]bosh.Lock{})
+		case "/tasks":
+			if r.URL.Query().Get("state") != "error" {
+				t.Errorf("expected failed task filter, got %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode([]bosh.Task{})
+		case "/deployments/cf/instances":
+			json.NewEncoder(w).Encode([]bosh.Instance{{Job: "router", ID: "r1", State: "started", ProcessState: "running", VMCID: "vm-1", Expects: true}})
+		case "/deployments/redis/instances":
+			json.NewEncoder(w).Encode([]bosh.Instance{{Job: "redis", ID: "x1", State: "started", ProcessState: "failing", VMCID: "vm-2", Expects: true}})
+		case "/deployments/cf/vms", "/deployments/redis/vms":
+			json.NewEncoder(w).Encode([]bosh.VM{})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("boom"))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	result, err := registry.handleBoshHealth(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+
+	health := result.StructuredContent.(HealthResult)
+	if health.Verdict != verdictUnhealthy {
+		t.Errorf("expected overall unhealthy, got %s", health.Verdict)
+	}
+	if len(health.Deployments) != 3 {
+		t.Fatalf("expected 3 deployment reports, got %d", len(health.Deployments))
+	}
+
+	verdicts := map[string]string{}
+	for _, d := range health.Deployments {
+		verdicts[d.Deployment] = d.Verdict
+	}
+	if verdicts["cf"] != verdictHealthy || verdicts["redis"] != verdictUnhealthy || verdicts["broken"] != verdictUnknown {
+		t.Errorf("unexpected verdicts: %v", verdicts)
+	}
+	if !strings.Contains(health.Deployments[2].Error, "boom") {
+		t.Errorf("expected fetch error on broken deployment, got %q", health.Deployments[2].Error)
+	}
+}
+
+func TestHandleBoshHealth_FullFormatInstances(t *testing.T) {
+	// Task result output as a Director sends it for instances?format=full.
+	instances := `{"agent_id":"a1","job_name":"router","index":0,"id":"r1","job_state":"running","state":"started","az":"z1","ips":["10.0.1.5"],"vm_cid":"vm-1","expects_vm":true,"processes":[{"name":"gorouter","state":"running"}]}
+{"agent_id":"a2","job_name":"router","index":1,"id":"r2","job_state":"running","state":"started","az":"z2","ips":["10.0.1.6"],"vm_cid":"vm-2","expects_vm":true,"processes":[{"name":"gorouter","state":"running"}]}
+`
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/locks", "/tasks":
+			w.Write([]byte("[]"))
+		case "/deployments/cf/instances":
+			w.Header().Set("Location", "/tasks/12")
+			w.WriteHeader(http.StatusFound)
+		case "/tasks/12":
+			json.NewEncoder(w).Encode(bosh.Task{ID: 12, State: "done"})
+		case "/tasks/12/output":
+			w.Write([]byte(instances))
+		case "/deployments/cf/vms":
+			w.Write([]byte(`[{"agent_id":"a1","job":"router","index":0,"id":"r1","process_state":"running","state":"started"},{"agent_id":"a2","job":"router","index":1,"id":"r2","process_state":"running","state":"started"}]`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf"}
+	result, _ := registry.handleBoshHealth(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+
+	report := result.StructuredContent.(HealthResult).Deployments[0]
+	if report.Verdict != verdictHealthy || report.Running != 2 || len(report.Issues) != 0 {
+		t.Errorf("expected started, running instances to be healthy, got %+v", report)
+	}
+}
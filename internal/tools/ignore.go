@@ -0,0 +1,91 @@
+// ABOUTME: Implements bosh_ignore and bosh_unignore, which fence instances off from deploys.
+// ABOUTME: Instances can be given by ID or index; indexes are resolved to IDs.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func (r *DeploymentRegistry) handleBoshIgnore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return r.setIgnore(ctx, request, true)
+}
+
+func (r *DeploymentRegistry) handleBoshUnignore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return r.setIgnore(ctx, request, false)
+}
+
+func (r *DeploymentRegistry) setIgnore(ctx context.Context, request mcp.CallToolRequest, ignore bool) (*mcp.CallToolResult, error) {
+	deployment := request.GetString("deployment", "")
+	instance := request.GetString("instance", "")
+	environment := request.GetString("environment", "")
+
+	operation := "ignore"
+	if !ignore {
+		operation = "unignore"
+	}
+
+	if deployment == "" || instance == "" {
+		return mcp.NewToolResultError("deployment and instance are required"), nil
+	}
+	group, ref, ok := strings.Cut(instance, "/")
+	if !ok || group == "" || ref == "" {
+		return mcp.NewToolResultError("instance must be in the form group/id or group/index"), nil
+	}
+
+	if r.config.IsBlocked(operation) {
+		return mcp.NewToolResultError(operation + " is blocked by configuration"), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	id, err := resolveInstanceID(client, deployment, group, ref)
+	if err != nil {
+		return r.deploymentError(ctx, environment, deployment, operation+" instance", err), nil
+	}
+
+	if err := client.SetInstanceIgnore(deployment, group, id, ignore); err != nil {
+		return r.deploymentError(ctx, environment, deployment, operation+" instance", err), nil
+	}
+
+	message := fmt.Sprintf("%s/%s is now ignored: deploys and other deployment-wide operations will skip it", group, id)
+	if !ignore {
+		message = fmt.Sprintf("%s/%s is no longer ignored", group, id)
+	}
+	return toolResult(IgnoreResult{
+		Deployment: deployment,
+		Instance:   group + "/" + id,
+		Ignore:     ignore,
+		Message:    message,
+	})
+}
+
+// resolveInstanceID returns the instance ID for ref, which is either an
+// instance ID or an index within the instance group. The Director only
+// accepts IDs for ignore.
+func resolveInstanceID(client *bosh.Client, deployment, group, ref string) (string, error) {
+	index, err := strconv.Atoi(ref)
+	if err != nil {
+		return ref, nil
+	}
+
+	instances, err := client.ListInstances(deployment)
+	if err != nil {
+		return "", err
+	}
+	for _, inst := range instances {
+		if inst.Job == group && inst.Index == index {
+			return inst.ID, nil
+		}
+	}
+	return "", fmt.Errorf("instance %s/%d not found", group, index)
+}
@@ -0,0 +1,100 @@
+// ABOUTME: Tests for bosh_ignore and bosh_unignore.
+// ABOUTME: Verifies instance indexes are resolved to IDs and the ignore flag is sent.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestHandleBoshIgnore(t *testing.T) {
+	var requests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.URL.Path+" "+string(body))
+		case r.URL.Path == "/deployments/cf/instances":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]bosh.Instance{
+				{Job: "router", Index: 0, ID: "uuid-0"},
+				{Job: "router", Index: 1, ID: "uuid-1"},
+			})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "router/1"}
+	result, _ := deploymentRegistry.handleBoshIgnore(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if ignored := result.StructuredContent.(IgnoreResult); ignored.Instance != "router/uuid-1" || !ignored.Ignore {
+		t.Errorf("unexpected result: %+v", ignored)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "router/uuid-0"}
+	result, _ = deploymentRegistry.handleBoshUnignore(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+
+	expected := []string{
+		`/deployments/cf/instance_groups/router/uuid-1/ignore {"ignore":true}`,
+		`/deployments/cf/instance_groups/router/uuid-0/ignore {"ignore":false}`,
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"deployment": "cf", "instance": "router/5"}
+	result, _ = deploymentRegistry.handleBoshIgnore(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "router/5 not found") {
+		t.Errorf("expected unknown index error, got %v", result.Content)
+	}
+}
+
+func TestHandleBoshIgnore_Validation(t *testing.T) {
+	cfg := config.Load("")
+	cfg.BlockedOperations = []string{"ignore"}
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), cfg)
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"deployment": "cf"}, "required"},
+		{map[string]interface{}{"deployment": "cf", "instance": "router"}, "group/id"},
+		{map[string]interface{}{"deployment": "cf", "instance": "router/uuid-0"}, "blocked"},
+	}
+
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = tt.args
+		result, _ := deploymentRegistry.handleBoshIgnore(context.Background(), request)
+		if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, tt.want) {
+			t.Errorf("expected error containing %q for %v, got %v", tt.want, tt.args, result.Content)
+		}
+	}
+}
@@ -5,16 +5,22 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"reflect"
 
+	"github.com/malston/bosh-mcp-server/internal/bosh"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func (r *Registry) handleBoshStemcells(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
 
-	client, err := r.GetClient(environment)
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.Stemcell{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -24,22 +30,32 @@ func (r *Registry) handleBoshStemcells(ctx context.Context, request mcp.CallTool
 		return mcp.NewToolResultError(fmt.Sprintf("failed to list stemcells: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"stemcells": stemcells,
-	}
+	sortItems(stemcells, opts.Sort)
+	stemcells, page := paginate(stemcells, opts)
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	result := StemcellsResult{
+		Stemcells: stemcells,
+		Page:      page,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return listResult(result, "stemcells", opts, page)
 }
 
 func (r *Registry) handleBoshReleases(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
+	name := request.GetString("name", "")
+	groupByName := request.GetBool("group_by_name", false)
+
+	itemType := reflect.TypeOf(bosh.Release{})
+	if groupByName {
+		itemType = reflect.TypeOf(ReleaseGroup{})
+	}
+	opts, err := parseListOptions(request, itemType)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -49,22 +65,37 @@ func (r *Registry) handleBoshReleases(ctx context.Context, request mcp.CallToolR
 		return mcp.NewToolResultError(fmt.Sprintf("failed to list releases: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"releases": releases,
+	if name != "" {
+		releases = filterSlice(releases, func(rel bosh.Release) bool { return rel.Name == name })
 	}
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	if groupByName {
+		groups := groupReleases(releases)
+		sortItems(groups, opts.Sort)
+		groups, page := paginate(groups, opts)
+		return listResult(ReleasesResult{Groups: groups, Page: page}, "groups", opts, page)
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	sortItems(releases, opts.Sort)
+	releases, page := paginate(releases, opts)
+
+	result := ReleasesResult{
+		Releases: releases,
+		Page:     page,
+	}
+
+	return listResult(result, "releases", opts, page)
 }
 
 func (r *Registry) handleBoshDeployments(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
 
-	client, err := r.GetClient(environment)
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.Deployment{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -74,22 +105,21 @@ func (r *Registry) handleBoshDeployments(ctx context.Context, request mcp.CallTo
 		return mcp.NewToolResultError(fmt.Sprintf("failed to list deployments: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"deployments": deployments,
-	}
+	sortItems(deployments, opts.Sort)
+	deployments, page := paginate(deployments, opts)
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	result := DeploymentsResult{
+		Deployments: deployments,
+		Page:        page,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return listResult(result, "deployments", opts, page)
 }
 
 func (r *Registry) handleBoshCloudConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -99,22 +129,17 @@ func (r *Registry) handleBoshCloudConfig(ctx context.Context, request mcp.CallTo
 		return mcp.NewToolResultError(fmt.Sprintf("failed to get cloud config: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"cloud_config": config,
+	result := CloudConfigResult{
+		CloudConfig: config,
 	}
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *Registry) handleBoshRuntimeConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -124,22 +149,17 @@ func (r *Registry) handleBoshRuntimeConfig(ctx context.Context, request mcp.Call
 		return mcp.NewToolResultError(fmt.Sprintf("failed to get runtime configs: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"runtime_configs": configs,
-	}
-
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	result := RuntimeConfigsResult{
+		RuntimeConfigs: configs,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
 func (r *Registry) handleBoshCPIConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
 
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -149,53 +169,22 @@ func (r *Registry) handleBoshCPIConfig(ctx context.Context, request mcp.CallTool
 		return mcp.NewToolResultError(fmt.Sprintf("failed to get CPI config: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"cpi_config": config,
-	}
-
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	result := CPIConfigResult{
+		CPIConfig: config,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return toolResult(result)
 }
 
-func (r *Registry) handleBoshVariables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	deployment := request.GetString("deployment", "")
+func (r *Registry) handleBoshLocks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	environment := request.GetString("environment", "")
 
-	if deployment == "" {
-		return mcp.NewToolResultError("deployment is required"), nil
-	}
-
-	client, err := r.GetClient(environment)
+	opts, err := parseListOptions(request, reflect.TypeOf(bosh.Lock{}))
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	variables, err := client.ListVariables(deployment)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to list variables: %v", err)), nil
-	}
-
-	result := map[string]interface{}{
-		"deployment": deployment,
-		"variables":  variables,
-	}
-
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return mcp.NewToolResultText(string(jsonBytes)), nil
-}
-
-func (r *Registry) handleBoshLocks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	environment := request.GetString("environment", "")
-
-	client, err := r.GetClient(environment)
+	client, err := r.GetClient(ctx, environment)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
 	}
@@ -205,14 +194,13 @@ func (r *Registry) handleBoshLocks(ctx context.Context, request mcp.CallToolRequ
 		return mcp.NewToolResultError(fmt.Sprintf("failed to list locks: %v", err)), nil
 	}
 
-	result := map[string]interface{}{
-		"locks": locks,
-	}
+	sortItems(locks, opts.Sort)
+	locks, page := paginate(locks, opts)
 
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	result := LocksResult{
+		Locks: locks,
+		Page:  page,
 	}
 
-	return mcp.NewToolResultText(string(jsonBytes)), nil
+	return listResult(result, "locks", opts, page)
 }
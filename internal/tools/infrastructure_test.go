@@ -32,6 +32,7 @@ func TestHandleBoshStemcells_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -132,6 +133,7 @@ func TestHandleBoshReleases_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -228,6 +230,7 @@ func TestHandleBoshDeployments_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -329,6 +332,7 @@ func TestHandleBoshCloudConfig_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -425,6 +429,7 @@ func TestHandleBoshRuntimeConfig_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -526,6 +531,7 @@ func TestHandleBoshCPIConfig_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -617,6 +623,7 @@ func TestHandleBoshVariables_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
@@ -755,6 +762,7 @@ func TestHandleBoshLocks_Success(t *testing.T) {
 	defer server.Close()
 
 	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
 	t.Setenv("BOSH_CLIENT", "admin")
 	t.Setenv("BOSH_CLIENT_SECRET", "secret")
 
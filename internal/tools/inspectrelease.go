@@ -0,0 +1,174 @@
+// ABOUTME: Implements bosh_inspect_release, which lists a release version's jobs and packages.
+// ABOUTME: Shows which stemcells the release is compiled for and checks one ahead of an upgrade.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// CompiledStemcell counts a release's packages compiled for one stemcell.
+type CompiledStemcell struct {
+	Stemcell string `json:"stemcell"`
+	Packages int    `json:"packages"`
+	Complete bool   `json:"complete"`
+}
+
+// StemcellCheck reports whether a release can be deployed on a stemcell
+// without compiling. Missing packages are compiled during deploy if the
+// release has their source; uncompilable ones have neither.
+type StemcellCheck struct {
+	Stemcell     string   `json:"stemcell"`
+	Compiled     bool     `json:"compiled"`
+	Missing      []string `json:"missing,omitempty"`
+	Uncompilable []string `json:"uncompilable,omitempty"`
+	Summary      string   `json:"summary"`
+}
+
+func (r *Registry) handleBoshInspectRelease(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	version := request.GetString("version", "")
+	stemcell := request.GetString("stemcell", "")
+	environment := request.GetString("environment", "")
+
+	if name == "" || version == "" {
+		return mcp.NewToolResultError("name and version are required"), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	details, err := client.InspectRelease(name, version)
+	if err != nil {
+		if bosh.IsNotFound(err) {
+			return mcp.NewToolResultError(fmt.Sprintf("release '%s/%s' not found", name, version)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to inspect release: %v", err)), nil
+	}
+
+	result := inspectRelease(name, version, details)
+
+	if stemcell != "" {
+		stemcell, err = resolveStemcellOS(client, stemcell)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		check := checkCompilation(details, stemcell)
+		result.StemcellCheck = &check
+	}
+
+	return toolResult(result)
+}
+
+// inspectRelease summarizes a release version's packages and the stemcells
+// they are compiled for.
+func inspectRelease(name, version string, details *bosh.ReleaseDetails) InspectReleaseResult {
+	result := InspectReleaseResult{
+		Name:              name,
+		Version:           version,
+		Jobs:              details.Jobs,
+		Packages:          details.Packages,
+		CompiledStemcells: []CompiledStemcell{},
+	}
+	if result.Jobs == nil {
+		result.Jobs = []bosh.ReleaseJob{}
+	}
+	if result.Packages == nil {
+		result.Packages = []bosh.ReleasePackage{}
+	}
+
+	counts := make(map[string]int)
+	for _, pkg := range details.Packages {
+		if pkg.BlobstoreID != "" {
+			result.SourcePackages++
+		}
+		seen := make(map[string]bool)
+		for _, compiled := range pkg.CompiledPackages {
+			if !seen[compiled.Stemcell] {
+				seen[compiled.Stemcell] = true
+				counts[compiled.Stemcell]++
+			}
+		}
+	}
+
+	for stemcell, count := range counts {
+		result.CompiledStemcells = append(result.CompiledStemcells, CompiledStemcell{
+			Stemcell: stemcell,
+			Packages: count,
+			Complete: count == len(details.Packages),
+		})
+	}
+	sort.Slice(result.CompiledStemcells, func(i, j int) bool {
+		a, b := result.CompiledStemcells[i].Stemcell, result.CompiledStemcells[j].Stemcell
+		aOS, aVersion, _ := strings.Cut(a, "/")
+		bOS, bVersion, _ := strings.Cut(b, "/")
+		if aOS != bOS {
+			return aOS < bOS
+		}
+		return compareVersions(aVersion, bVersion) > 0
+	})
+
+	return result
+}
+
+// checkCompilation reports which packages have no compiled package for stemcell.
+func checkCompilation(details *bosh.ReleaseDetails, stemcell string) StemcellCheck {
+	check := StemcellCheck{Stemcell: stemcell}
+	for _, pkg := range details.Packages {
+		compiled := false
+		for _, cp := range pkg.CompiledPackages {
+			if cp.Stemcell == stemcell {
+				compiled = true
+				break
+			}
+		}
+		if compiled {
+			continue
+		}
+		check.Missing = append(check.Missing, pkg.Name)
+		if pkg.BlobstoreID == "" {
+			check.Uncompilable = append(check.Uncompilable, pkg.Name)
+		}
+	}
+	check.Compiled = len(check.Missing) == 0
+
+	total := len(details.Packages)
+	switch {
+	case check.Compiled:
+		check.Summary = fmt.Sprintf("all %d packages are compiled for %s", total, stemcell)
+	case len(check.Uncompilable) > 0:
+		check.Summary = fmt.Sprintf("%d of %d packages have neither source nor a compiled package for %s; deploying on it will fail", len(check.Uncompilable), total, stemcell)
+	default:
+		check.Summary = fmt.Sprintf("%d of %d packages are not compiled for %s and will be compiled during deploy", len(check.Missing), total, stemcell)
+	}
+	return check
+}
+
+// resolveStemcellOS turns "name/version" into the "operating-system/version"
+// form used by compiled packages when name is an uploaded stemcell's name.
+// Anything else is assumed to already be in that form.
+func resolveStemcellOS(client *bosh.Client, stemcell string) (string, error) {
+	name, version, ok := strings.Cut(stemcell, "/")
+	if !ok || name == "" || version == "" {
+		return "", fmt.Errorf("stemcell must be in the form operating-system/version or name/version")
+	}
+
+	stemcells, err := client.ListStemcells()
+	if err != nil {
+		return "", fmt.Errorf("failed to list stemcells: %v", err)
+	}
+	for _, sc := range stemcells {
+		if sc.Name == name && sc.OperatingSystem != "" {
+			return sc.OperatingSystem + "/" + version, nil
+		}
+	}
+	return stemcell, nil
+}
@@ -0,0 +1,109 @@
+// ABOUTME: Tests for bosh_inspect_release.
+// ABOUTME: Verifies compiled stemcell counts and the stemcell compilation check.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func testReleaseDetails() *bosh.ReleaseDetails {
+	jammy200 := bosh.CompiledPackage{Stemcell: "ubuntu-jammy/1.200"}
+	jammy300 := bosh.CompiledPackage{Stemcell: "ubuntu-jammy/1.300"}
+	return &bosh.ReleaseDetails{
+		Jobs: []bosh.ReleaseJob{{Name: "router", Packages: []string{"gorouter", "golang"}}},
+		Packages: []bosh.ReleasePackage{
+			{Name: "golang", BlobstoreID: "src-1", CompiledPackages: []bosh.CompiledPackage{jammy200, jammy300}},
+			{Name: "gorouter", BlobstoreID: "src-2", CompiledPackages: []bosh.CompiledPackage{jammy200}},
+			{Name: "routing-api", CompiledPackages: []bosh.CompiledPackage{jammy200}},
+		},
+	}
+}
+
+func TestInspectRelease_CompiledStemcells(t *testing.T) {
+	result := inspectRelease("routing", "1.0", testReleaseDetails())
+
+	if result.SourcePackages != 2 {
+		t.Errorf("expected 2 source packages, got %d", result.SourcePackages)
+	}
+	expected := []CompiledStemcell{
+		{Stemcell: "ubuntu-jammy/1.300", Packages: 1, Complete: false},
+		{Stemcell: "ubuntu-jammy/1.200", Packages: 3, Complete: true},
+	}
+	if !reflect.DeepEqual(result.CompiledStemcells, expected) {
+		t.Errorf("expected %+v, got %+v", expected, result.CompiledStemcells)
+	}
+}
+
+func TestCheckCompilation(t *testing.T) {
+	details := testReleaseDetails()
+
+	compiled := checkCompilation(details, "ubuntu-jammy/1.200")
+	if !compiled.Compiled || len(compiled.Missing) != 0 {
+		t.Errorf("expected fully compiled, got %+v", compiled)
+	}
+
+	partial := checkCompilation(details, "ubuntu-jammy/1.300")
+	if partial.Compiled {
+		t.Error("expected not compiled")
+	}
+	if !reflect.DeepEqual(partial.Missing, []string{"gorouter", "routing-api"}) {
+		t.Errorf("unexpected missing packages: %v", partial.Missing)
+	}
+	if !reflect.DeepEqual(partial.Uncompilable, []string{"routing-api"}) {
+		t.Errorf("unexpected uncompilable packages: %v", partial.Uncompilable)
+	}
+}
+
+func TestHandleBoshInspectRelease_ResolvesStemcellName(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/releases/routing/versions/1.0":
+			json.NewEncoder(w).Encode(testReleaseDetails())
+		case "/stemcells":
+			json.NewEncoder(w).Encode([]bosh.Stemcell{{Name: "bosh-vsphere-esxi-ubuntu-jammy-go_agent", OperatingSystem: "ubuntu-jammy", Version: "1.300"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"name":     "routing",
+		"version":  "1.0",
+		"stemcell": "bosh-vsphere-esxi-ubuntu-jammy-go_agent/1.300",
+	}
+	result, _ := registry.handleBoshInspectRelease(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+
+	check := result.StructuredContent.(InspectReleaseResult).StemcellCheck
+	if check == nil || check.Stemcell != "ubuntu-jammy/1.300" || check.Compiled {
+		t.Errorf("expected check against ubuntu-jammy/1.300, got %+v", check)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"name": "routing", "version": "9.9"}
+	result, _ = registry.handleBoshInspectRelease(context.Background(), request)
+	if !result.IsError {
+		t.Error("expected not found error")
+	}
+}
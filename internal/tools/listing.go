@@ -0,0 +1,399 @@
+// ABOUTME: Implements pagination, field projection, and filters for list tools.
+// ABOUTME: Keeps large list results (VMs, instances, tasks, releases) within context limits.
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// PageInfo describes which slice of a list result was returned.
+type PageInfo struct {
+	Total      int    `json:"total"`
+	Returned   int    `json:"returned"`
+	Omitted    int    `json:"omitted"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// listOptions holds the pagination, projection, sorting, and format arguments of a list tool.
+type listOptions struct {
+	Offset   int
+	PageSize int
+	Fields   []string
+	Sort     string
+	Format   string
+}
+
+// withPagination adds the cursor, page_size, and fields arguments to a list tool.
+func withPagination() mcp.ToolOption {
+	return withOptions(
+		mcp.WithString("cursor",
+			mcp.Description("Cursor from a previous page's next_cursor (optional)")),
+		mcp.WithNumber("page_size",
+			mcp.Description(fmt.Sprintf("Maximum items per page (default: %d, max: %d)", defaultPageSize, maxPageSize))),
+		mcp.WithArray("fields",
+			mcp.WithStringItems(),
+			mcp.Description("Only return these fields for each item (optional)")),
+	)
+}
+
+// withInstanceFilters adds the filter arguments for VM and instance tools.
+func withInstanceFilters() mcp.ToolOption {
+	return withOptions(
+		mcp.WithString("job",
+			mcp.Description("Filter by job (instance group) name")),
+		mcp.WithString("az",
+			mcp.Description("Filter by availability zone")),
+		mcp.WithString("state",
+			mcp.Description("Filter by instance state")),
+		mcp.WithString("process_state",
+			mcp.Description("Filter by process state (e.g. running, failing)")),
+		mcp.WithString("ip_prefix",
+			mcp.Description("Filter by IP address prefix (e.g. 10.0.1.)")),
+	)
+}
+
+// withOptions combines several tool options into one.
+func withOptions(opts ...mcp.ToolOption) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		for _, opt := range opts {
+			opt(t)
+		}
+	}
+}
+
+// parseListOptions reads cursor, page_size, fields, sort, and format from the request.
+// itemType is the element type whose JSON fields may be projected or sorted on.
+func parseListOptions(request mcp.CallToolRequest, itemType reflect.Type) (listOptions, error) {
+	opts := listOptions{
+		PageSize: request.GetInt("page_size", defaultPageSize),
+		Fields:   request.GetStringSlice("fields", nil),
+		Sort:     request.GetString("sort", ""),
+		Format:   request.GetString("format", formatJSON),
+	}
+
+	if !contains(outputFormats, opts.Format) {
+		return opts, fmt.Errorf("unknown format %q; valid formats: %s", opts.Format, strings.Join(outputFormats, ", "))
+	}
+
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+	if opts.PageSize > maxPageSize {
+		opts.PageSize = maxPageSize
+	}
+
+	if cursor := request.GetString("cursor", ""); cursor != "" {
+		offset, err := strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		opts.Offset = offset
+	}
+
+	valid := jsonFieldNames(itemType)
+	for _, field := range opts.Fields {
+		if !contains(valid, field) {
+			return opts, fmt.Errorf("unknown field %q; valid fields: %s", field, strings.Join(valid, ", "))
+		}
+	}
+	if sortField := strings.TrimPrefix(opts.Sort, "-"); sortField != "" && !contains(valid, sortField) {
+		return opts, fmt.Errorf("unknown sort field %q; valid fields: %s", sortField, strings.Join(valid, ", "))
+	}
+
+	return opts, nil
+}
+
+// paginate returns the page of items selected by opts.
+func paginate[T any](items []T, opts listOptions) ([]T, *PageInfo) {
+	total := len(items)
+	start := opts.Offset
+	if start > total {
+		start = total
+	}
+	end := start + opts.PageSize
+	if end > total {
+		end = total
+	}
+
+	page := &PageInfo{
+		Total:    total,
+		Returned: end - start,
+		Omitted:  total - (end - start),
+	}
+	if end < total {
+		page.NextCursor = strconv.Itoa(end)
+	}
+
+	return items[start:end], page
+}
+
+// listResult builds a list tool result, projecting the items under listKey to
+// the requested fields, rendering the text in the requested format, and
+// appending a footer when items were omitted.
+func listResult(v any, listKey string, opts listOptions, page *PageInfo) (*mcp.CallToolResult, error) {
+	structured := v
+	if len(opts.Fields) > 0 {
+		projected, err := projectList(v, listKey, opts.Fields)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to project fields: %v", err)), nil
+		}
+		structured = projected
+	}
+
+	result, err := toolResult(structured)
+	if err != nil || result.IsError {
+		return result, err
+	}
+
+	if opts.Format != formatJSON {
+		columns := opts.Fields
+		if len(columns) == 0 {
+			columns = defaultColumns[listKey]
+		}
+		text, err := renderList(structured, listKey, columns, opts.Format)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to render %s: %v", opts.Format, err)), nil
+		}
+		result.Content[0] = mcp.NewTextContent(text)
+	}
+
+	if page != nil && page.Omitted > 0 {
+		footer := fmt.Sprintf("Showing %d of %d items; %d omitted.", page.Returned, page.Total, page.Omitted)
+		if page.NextCursor != "" {
+			footer += fmt.Sprintf(" Pass cursor %q for the next page.", page.NextCursor)
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(footer))
+	}
+
+	return result, nil
+}
+
+// sortItems sorts items in place by the JSON field named in spec.
+// A leading "-" sorts in descending order. Numbers compare numerically.
+func sortItems[T any](items []T, spec string) {
+	field := strings.TrimPrefix(spec, "-")
+	if field == "" {
+		return
+	}
+	descending := strings.HasPrefix(spec, "-")
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a := jsonFieldValue(reflect.ValueOf(items[i]), field)
+		b := jsonFieldValue(reflect.ValueOf(items[j]), field)
+		if descending {
+			return lessValue(b, a)
+		}
+		return lessValue(a, b)
+	})
+}
+
+// jsonFieldValue returns the struct field of v tagged with the given JSON name.
+func jsonFieldValue(v reflect.Value, name string) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// lessValue orders two field values of the same type.
+func lessValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return !a.IsValid() && b.IsValid()
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.String:
+		return a.String() < b.String()
+	default:
+		return formatCell(a.Interface()) < formatCell(b.Interface())
+	}
+}
+
+// projectList converts v to a map and keeps only fields in each item of listKey.
+func projectList(v any, listKey string, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	items, _ := obj[listKey].([]any)
+	for i, item := range items {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		projected := make(map[string]any, len(fields))
+		for _, field := range fields {
+			if value, ok := entry[field]; ok {
+				projected[field] = value
+			}
+		}
+		items[i] = projected
+	}
+
+	return obj, nil
+}
+
+// jsonFieldNames returns the JSON field names of a struct type.
+func jsonFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// instanceFilter selects VMs or instances by job, AZ, state, and IP.
+type instanceFilter struct {
+	Job          string
+	AZ           string
+	State        string
+	ProcessState string
+	IPPrefix     string
+}
+
+func parseInstanceFilter(request mcp.CallToolRequest) instanceFilter {
+	return instanceFilter{
+		Job:          request.GetString("job", ""),
+		AZ:           request.GetString("az", ""),
+		State:        request.GetString("state", ""),
+		ProcessState: request.GetString("process_state", ""),
+		IPPrefix:     request.GetString("ip_prefix", ""),
+	}
+}
+
+func (f instanceFilter) matchVM(vm bosh.VM) bool {
+	return f.matchCommon(vm.Job, vm.AZ, vm.IPs) &&
+		(f.State == "" || vm.State == f.State) &&
+		(f.ProcessState == "" || vm.ProcessState == f.ProcessState)
+}
+
+// matchInstance matches process_state, like matchVM, against the instance's
+// process state, or any of its processes' states.
+func (f instanceFilter) matchInstance(inst bosh.Instance) bool {
+	if !f.matchCommon(inst.Job, inst.AZ, inst.IPs) {
+		return false
+	}
+	if f.State != "" && inst.State != f.State {
+		return false
+	}
+	if f.ProcessState != "" && inst.ProcessState != f.ProcessState {
+		found := false
+		for _, p := range inst.Processes {
+			if p.State == f.ProcessState {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (f instanceFilter) matchCommon(job, az string, ips []string) bool {
+	if f.Job != "" && job != f.Job {
+		return false
+	}
+	if f.AZ != "" && az != f.AZ {
+		return false
+	}
+	if f.IPPrefix != "" {
+		for _, ip := range ips {
+			if strings.HasPrefix(ip, f.IPPrefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// filterSlice returns the items for which keep returns true.
+func filterSlice[T any](items []T, keep func(T) bool) []T {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if keep(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// ReleaseGroup lists the uploaded versions of one release.
+type ReleaseGroup struct {
+	Name     string   `json:"name"`
+	Versions []string `json:"versions"`
+}
+
+// groupReleases groups release versions by name, preserving director order.
+func groupReleases(releases []bosh.Release) []ReleaseGroup {
+	groups := []ReleaseGroup{}
+	index := make(map[string]int)
+
+	for _, rel := range releases {
+		i, ok := index[rel.Name]
+		if !ok {
+			i = len(groups)
+			index[rel.Name] = i
+			groups = append(groups, ReleaseGroup{Name: rel.Name, Versions: []string{}})
+		}
+		groups[i].Versions = append(groups[i].Versions, rel.Version)
+	}
+
+	return groups
+}
@@ -0,0 +1,283 @@
+// ABOUTME: Tests for list tool pagination, field projection, and filters.
+// ABOUTME: Uses httptest to mock BOSH Director with large VM and release lists.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newCellVMs(count int) []bosh.VM {
+	vms := make([]bosh.VM, 0, count)
+	for i := 0; i < count; i++ {
+		az := "z1"
+		state := "running"
+		if i%2 == 1 {
+			az = "z2"
+		}
+		if i == 7 {
+			state = "failing"
+		}
+		vms = append(vms, bosh.VM{
+			Job:          "diego_cell",
+			Index:        i,
+			AZ:           az,
+			ProcessState: state,
+			IPs:          []string{fmt.Sprintf("10.0.%d.%d", i/100, i%100)},
+		})
+	}
+	return vms
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	page, info := paginate(items, listOptions{Offset: 0, PageSize: 2})
+	if len(page) != 2 || info.Omitted != 3 || info.NextCursor != "2" {
+		t.Errorf("unexpected first page: %v %+v", page, info)
+	}
+
+	page, info = paginate(items, listOptions{Offset: 4, PageSize: 2})
+	if len(page) != 1 || page[0] != 5 || info.NextCursor != "" {
+		t.Errorf("unexpected last page: %v %+v", page, info)
+	}
+
+	page, info = paginate(items, listOptions{Offset: 10, PageSize: 2})
+	if len(page) != 0 || info.Total != 5 {
+		t.Errorf("expected empty page past end, got %v %+v", page, info)
+	}
+}
+
+func TestInstanceFilter_MatchInstanceProcessState(t *testing.T) {
+	inst := bosh.Instance{
+		Job:          "router",
+		AZ:           "z1",
+		State:        "started",
+		ProcessState: "failing",
+		IPs:          []string{"10.0.1.5"},
+		Processes: []bosh.Process{
+			{Name: "gorouter", State: "running"},
+			{Name: "metron", State: "failing"},
+		},
+	}
+
+	if !(instanceFilter{ProcessState: "failing"}).matchInstance(inst) {
+		t.Error("expected match on failing process")
+	}
+	if (instanceFilter{ProcessState: "unknown"}).matchInstance(inst) {
+		t.Error("expected no match on unknown process state")
+	}
+	if !(instanceFilter{IPPrefix: "10.0.1."}).matchInstance(inst) {
+		t.Error("expected match on IP prefix")
+	}
+	if (instanceFilter{Job: "router", AZ: "z2"}).matchInstance(inst) {
+		t.Error("expected no match on other AZ")
+	}
+}
+
+func TestInstanceFilter_MatchInstanceStateAndProcessState(t *testing.T) {
+	running := bosh.Instance{Job: "api", State: "started", ProcessState: "running"}
+	if !(instanceFilter{State: "started", ProcessState: "running"}).matchInstance(running) {
+		t.Error("expected started instance with running processes to match")
+	}
+	if (instanceFilter{ProcessState: "started"}).matchInstance(running) {
+		t.Error("expected process_state not to match the desired state")
+	}
+
+	stopped := bosh.Instance{Job: "api", State: "stopped", ProcessState: "stopped"}
+	if (instanceFilter{State: "started", ProcessState: "running"}).matchInstance(stopped) {
+		t.Error("expected stopped instance not to match")
+	}
+}
+
+func TestGroupReleases(t *testing.T) {
+	releases := []bosh.Release{
+		{Name: "cf", Version: "1.0"},
+		{Name: "routing", Version: "0.9"},
+		{Name: "cf", Version: "1.1"},
+	}
+
+	groups := groupReleases(releases)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Name != "cf" || len(groups[0].Versions) != 2 || groups[0].Versions[1] != "1.1" {
+		t.Errorf("unexpected cf group: %+v", groups[0])
+	}
+}
+
+func TestHandleBoshVMs_PaginatesWithFooter(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newCellVMs(400))
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"deployment": "cf",
+		"page_size":  float64(100),
+		"cursor":     "100",
+	}
+
+	result, err := registry.handleBoshVMs(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+
+	vmsResult := result.StructuredContent.(VMsResult)
+	if len(vmsResult.VMs) != 100 || vmsResult.VMs[0].Index != 100 {
+		t.Errorf("expected VMs 100-199, got %d starting at %d", len(vmsResult.VMs), vmsResult.VMs[0].Index)
+	}
+	if vmsResult.Page.Omitted != 300 || vmsResult.Page.NextCursor != "200" {
+		t.Errorf("unexpected page info: %+v", vmsResult.Page)
+	}
+
+	if len(result.Content) != 2 {
+		t.Fatalf("expected JSON and footer content, got %d items", len(result.Content))
+	}
+	footer := result.Content[1].(mcp.TextContent).Text
+	if !strings.Contains(footer, "300 omitted") || !strings.Contains(footer, `"200"`) {
+		t.Errorf("unexpected footer: %s", footer)
+	}
+}
+
+func TestHandleBoshVMs_FiltersAndProjects(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newCellVMs(20))
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"deployment":    "cf",
+		"az":            "z2",
+		"process_state": "failing",
+		"fields":        []interface{}{"index", "ips"},
+	}
+
+	result, err := registry.handleBoshVMs(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+	if len(result.Content) != 1 {
+		t.Errorf("expected no footer when nothing omitted, got %d content items", len(result.Content))
+	}
+
+	var response struct {
+		VMs []map[string]interface{} `json:"vms"`
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if err := json.Unmarshal([]byte(text), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if len(response.VMs) != 1 {
+		t.Fatalf("expected 1 failing VM in z2, got %d", len(response.VMs))
+	}
+	if len(response.VMs[0]) != 2 || response.VMs[0]["index"] != float64(7) {
+		t.Errorf("expected only index and ips fields, got %v", response.VMs[0])
+	}
+
+	var schema map[string]interface{}
+	json.Unmarshal(schemaFor[VMsResult](), &schema)
+	var structured interface{}
+	data, _ := json.Marshal(result.StructuredContent)
+	json.Unmarshal(data, &structured)
+	if problems := validateSchema("$", structured, schema); len(problems) > 0 {
+		t.Errorf("projected result does not match schema: %v", problems)
+	}
+}
+
+func TestHandleBoshVMs_UnknownField(t *testing.T) {
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"deployment": "cf",
+		"fields":     []interface{}{"cpu"},
+	}
+
+	result, err := registry.handleBoshVMs(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error for unknown field")
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, `unknown field "cpu"`) {
+		t.Errorf("expected unknown field error, got: %s", text)
+	}
+}
+
+func TestHandleBoshReleases_GroupByName(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]bosh.Release{
+			{Name: "cf", Version: "1.0"},
+			{Name: "cf", Version: "1.1"},
+			{Name: "routing", Version: "0.9"},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"group_by_name": true,
+	}
+
+	result, err := registry.handleBoshReleases(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+
+	releasesResult := result.StructuredContent.(ReleasesResult)
+	if len(releasesResult.Groups) != 2 || len(releasesResult.Releases) != 0 {
+		t.Fatalf("expected 2 groups and no flat releases, got %+v", releasesResult)
+	}
+	if releasesResult.Groups[0].Name != "cf" || len(releasesResult.Groups[0].Versions) != 2 {
+		t.Errorf("unexpected cf group: %+v", releasesResult.Groups[0])
+	}
+}
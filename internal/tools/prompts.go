@@ -0,0 +1,93 @@
+// ABOUTME: Registers MCP prompts for common BOSH troubleshooting workflows.
+// ABOUTME: Their environment, deployment, job, and task_id arguments are completed by the Completer.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterPrompts registers the troubleshooting prompts with the MCP server.
+func (r *Registry) RegisterPrompts(s *server.MCPServer) {
+	s.AddPrompt(mcp.NewPrompt("diagnose_deployment",
+		mcp.WithPromptDescription("Diagnose an unhealthy BOSH deployment or one of its jobs"),
+		mcp.WithArgument("deployment",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Name of the deployment")),
+		mcp.WithArgument("job",
+			mcp.ArgumentDescription("Job (instance group) to focus on (optional)")),
+		mcp.WithArgument("environment",
+			mcp.ArgumentDescription("Named BOSH environment (optional)")),
+	), handleDiagnoseDeploymentPrompt)
+
+	s.AddPrompt(mcp.NewPrompt("investigate_task",
+		mcp.WithPromptDescription("Explain what a BOSH task did and why it failed"),
+		mcp.WithArgument("task_id",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Task ID")),
+		mcp.WithArgument("environment",
+			mcp.ArgumentDescription("Named BOSH environment (optional)")),
+	), handleInvestigateTaskPrompt)
+}
+
+func handleDiagnoseDeploymentPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	deployment := args["deployment"]
+	if deployment == "" {
+		return nil, fmt.Errorf("deployment is required")
+	}
+
+	target := fmt.Sprintf("deployment '%s'", deployment)
+	filter := ""
+	if job := args["job"]; job != "" {
+		target = fmt.Sprintf("job '%s' of deployment '%s'", job, deployment)
+		filter = fmt.Sprintf(" with job %q", job)
+	}
+
+	steps := []string{
+		fmt.Sprintf("Diagnose %s%s.", target, environmentNote(args["environment"])),
+		fmt.Sprintf("1. Call bosh_health for %q to get a verdict and the issues found.", deployment),
+		fmt.Sprintf("2. Call bosh_instances%s and look at instances and processes that aren't running.", filter),
+		"3. Call bosh_tasks with state error for the deployment, and bosh_task with output for recent failures.",
+		"4. Call bosh_vitals if instances are running but slow or flapping.",
+		"Summarize the likely cause and suggest a fix. Don't stop, recreate, or change anything without asking the user first.",
+	}
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Diagnose %s", target),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(strings.Join(steps, "\n")))},
+	), nil
+}
+
+func handleInvestigateTaskPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	taskID := args["task_id"]
+	if taskID == "" {
+		return nil, fmt.Errorf("task_id is required")
+	}
+
+	steps := []string{
+		fmt.Sprintf("Investigate BOSH task %s%s.", taskID, environmentNote(args["environment"])),
+		fmt.Sprintf("1. Call bosh_task with id %s and output to see its state, description, and result.", taskID),
+		"2. If it failed, find the step that failed and the instance it ran on, then check that instance with bosh_instances.",
+		"Explain what the task did, why it failed if it did, and what to do next.",
+	}
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Investigate task %s", taskID),
+		[]mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(strings.Join(steps, "\n")))},
+	), nil
+}
+
+// environmentNote names the environment a prompt targets, if one was given.
+func environmentNote(environment string) string {
+	if environment == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in environment '%s' (pass environment %q to every tool call)", environment, environment)
+}
@@ -0,0 +1,56 @@
+// ABOUTME: Tests for the troubleshooting prompts.
+// ABOUTME: Verifies required arguments and the steps each prompt asks for.
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func promptText(t *testing.T, result *mcp.GetPromptResult) string {
+	t.Helper()
+
+	if len(result.Messages) != 1 {
+		t.Fatalf("expected one message, got %+v", result.Messages)
+	}
+	return result.Messages[0].Content.(mcp.TextContent).Text
+}
+
+func TestDiagnoseDeploymentPrompt(t *testing.T) {
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"deployment": "cf", "job": "router", "environment": "prod"}
+
+	result, err := handleDiagnoseDeploymentPrompt(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := promptText(t, result)
+	for _, want := range []string{"job 'router' of deployment 'cf'", "environment \"prod\"", "bosh_health", `bosh_instances with job "router"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected prompt to contain %q, got %q", want, text)
+		}
+	}
+
+	request.Params.Arguments = map[string]string{"job": "router"}
+	if _, err := handleDiagnoseDeploymentPrompt(context.Background(), request); err == nil {
+		t.Error("expected an error without a deployment")
+	}
+}
+
+func TestInvestigateTaskPrompt(t *testing.T) {
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = map[string]string{"task_id": "142"}
+
+	result, err := handleInvestigateTaskPrompt(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := promptText(t, result)
+	if !strings.Contains(text, "bosh_task with id 142") || strings.Contains(text, "environment") {
+		t.Errorf("unexpected prompt: %q", text)
+	}
+}
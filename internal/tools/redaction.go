@@ -0,0 +1,142 @@
+// ABOUTME: Masks secrets in every tool result before it reaches the model.
+// ABOUTME: Callers may opt out per call only when the server configuration allows it.
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"strings"
+
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/malston/bosh-mcp-server/internal/redact"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RedactionMiddleware masks values under sensitive keys, PEM blocks, and
+// common secret formats in the text and structured content of every tool
+// result. A call with redact: false skips redaction if cfg.AllowOptOut is
+// set and is refused otherwise. The opt-out is server-wide: any caller of a
+// server that allows it may use it.
+func RedactionMiddleware(cfg config.Redaction) server.ToolHandlerMiddleware {
+	redactor := redact.New(redact.Options{
+		SensitiveKeys: cfg.SensitiveKeys,
+		AllowedKeys:   cfg.AllowedKeys,
+	})
+
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !request.GetBool("redact", true) {
+				if !cfg.AllowOptOut {
+					return mcp.NewToolResultError("unredacted output is not allowed by configuration; set redaction.allow_opt_out to permit redact: false"), nil
+				}
+				return next(ctx, request)
+			}
+
+			result, err := next(ctx, request)
+			if err != nil {
+				return nil, errors.New(redactor.String(err.Error()))
+			}
+			if result == nil {
+				return nil, nil
+			}
+			return redactResult(redactor, result), nil
+		}
+	}
+}
+
+// RedactionToolFilter declares the redact argument on every listed tool when
+// cfg.AllowOptOut is set, since RedactionMiddleware accepts it on any call.
+// Otherwise the argument isn't advertised and calls passing it are refused.
+func RedactionToolFilter(cfg config.Redaction) server.ToolFilterFunc {
+	return func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		if !cfg.AllowOptOut {
+			return tools
+		}
+
+		declared := make([]mcp.Tool, len(tools))
+		for i, tool := range tools {
+			// Copy the properties so the registered tool is left unchanged.
+			properties := make(map[string]any, len(tool.InputSchema.Properties)+1)
+			maps.Copy(properties, tool.InputSchema.Properties)
+			properties["redact"] = map[string]any{
+				"type":        "boolean",
+				"description": "Set to false to return output without masking secrets (default: true)",
+			}
+			tool.InputSchema.Properties = properties
+			declared[i] = tool
+		}
+		return declared
+	}
+}
+
+// redactResult returns a copy of result with secrets masked.
+func redactResult(redactor *redact.Redactor, result *mcp.CallToolResult) *mcp.CallToolResult {
+	redacted := *result
+
+	redacted.Content = make([]mcp.Content, len(result.Content))
+	for i, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			text.Text = redactText(redactor, text.Text)
+			content = text
+		}
+		redacted.Content[i] = content
+	}
+
+	if result.StructuredContent != nil {
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			if value, err := decodeJSON(data); err == nil {
+				if masked := redactor.Value(value); !reflect.DeepEqual(masked, value) {
+					redacted.StructuredContent = masked
+				}
+			}
+		}
+	}
+
+	return &redacted
+}
+
+// redactText masks secrets in text. JSON text is redacted structurally and
+// re-encoded only if something was masked, keeping its indentation.
+func redactText(redactor *redact.Redactor, text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return redactor.String(text)
+	}
+
+	value, err := decodeJSON([]byte(text))
+	if err != nil {
+		return redactor.String(text)
+	}
+	masked := redactor.Value(value)
+	if reflect.DeepEqual(masked, value) {
+		return text
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if strings.Contains(trimmed, "\n") {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(masked); err != nil {
+		return redactor.String(text)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// decodeJSON decodes data keeping numbers exact.
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
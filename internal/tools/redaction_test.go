@@ -0,0 +1,139 @@
+// ABOUTME: Tests for the redaction middleware applied to every tool result.
+// ABOUTME: Verifies text and structured content are masked and opt-out follows configuration.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// cloudConfigHandler returns a cloud config holding a vCenter password.
+func cloudConfigHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return toolResult(CloudConfigResult{CloudConfig: &bosh.CloudConfig{
+		Properties: "vm_types:\n- name: small\n  cloud_properties:\n    cpu: 2\n    vcenter_password: hunter2\n",
+		CreatedAt:  "2024-01-01",
+	}})
+}
+
+func TestRedactionMiddleware_MasksResults(t *testing.T) {
+	handler := RedactionMiddleware(config.Redaction{})(cloudConfigHandler)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	if strings.Contains(text, "hunter2") || !strings.Contains(text, "vcenter_password: <redacted>") || !strings.Contains(text, "cpu: 2") {
+		t.Errorf("expected password redacted in text, got %s", text)
+	}
+	if !json.Valid([]byte(text)) || !strings.Contains(text, "\n  ") {
+		t.Errorf("expected indented JSON text, got %s", text)
+	}
+
+	structured, _ := json.Marshal(result.StructuredContent)
+	if strings.Contains(string(structured), "hunter2") {
+		t.Errorf("expected password redacted in structured content, got %s", structured)
+	}
+}
+
+func TestRedactionMiddleware_KeepsUnchangedResults(t *testing.T) {
+	original, _ := toolResult(OperationResult{RequiresConfirmation: true, ConfirmationToken: "tok-123", Operation: "stop"})
+	handler := RedactionMiddleware(config.Redaction{})(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return original, nil
+	})
+
+	result, _ := handler(context.Background(), mcp.CallToolRequest{})
+	if result.Content[0].(mcp.TextContent).Text != original.Content[0].(mcp.TextContent).Text {
+		t.Errorf("expected unchanged text, got %s", result.Content[0].(mcp.TextContent).Text)
+	}
+	if _, ok := result.StructuredContent.(OperationResult); !ok {
+		t.Errorf("expected structured content to be left as is, got %T", result.StructuredContent)
+	}
+}
+
+func TestRedactionMiddleware_OptOut(t *testing.T) {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"redact": false}
+
+	handler := RedactionMiddleware(config.Redaction{})(cloudConfigHandler)
+	result, _ := handler(context.Background(), request)
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "allow_opt_out") {
+		t.Errorf("expected opt-out to be refused, got %v", result.Content)
+	}
+
+	handler = RedactionMiddleware(config.Redaction{AllowOptOut: true})(cloudConfigHandler)
+	result, _ = handler(context.Background(), request)
+	if !strings.Contains(result.Content[0].(mcp.TextContent).Text, "hunter2") {
+		t.Errorf("expected unredacted output when opt-out is allowed, got %v", result.Content)
+	}
+}
+
+func TestRedactionToolFilter_DeclaresRedactWhenAllowed(t *testing.T) {
+	tools := []mcp.Tool{mcp.NewTool("bosh_cloud_config", mcp.WithString("environment"))}
+
+	listed := RedactionToolFilter(config.Redaction{})(context.Background(), tools)
+	if _, ok := listed[0].InputSchema.Properties["redact"]; ok {
+		t.Error("expected redact not to be declared when opt-out isn't allowed")
+	}
+
+	listed = RedactionToolFilter(config.Redaction{AllowOptOut: true})(context.Background(), tools)
+	redact, ok := listed[0].InputSchema.Properties["redact"].(map[string]any)
+	if !ok || redact["type"] != "boolean" {
+		t.Errorf("expected a boolean redact argument, got %+v", listed[0].InputSchema.Properties)
+	}
+	if _, ok := listed[0].InputSchema.Properties["environment"]; !ok {
+		t.Error("expected existing arguments to be kept")
+	}
+	if _, ok := tools[0].InputSchema.Properties["redact"]; ok {
+		t.Error("expected the registered tool to be left unchanged")
+	}
+}
+
+func TestRedactionMiddleware_AllowedKeys(t *testing.T) {
+	handler := RedactionMiddleware(config.Redaction{AllowedKeys: []string{"vcenter_password"}})(cloudConfigHandler)
+
+	result, _ := handler(context.Background(), mcp.CallToolRequest{})
+	if !strings.Contains(result.Content[0].(mcp.TextContent).Text, "hunter2") {
+		t.Errorf("expected allowed key to be kept, got %v", result.Content)
+	}
+}
+
+func TestRedactionMiddleware_MasksDiffedPasswords(t *testing.T) {
+	source := "vm_types:\n- name: small\n  cloud_properties:\n    cpu: 2\n    vcenter_password: hunter2\n"
+	target := "vm_types:\n- name: small\n  cloud_properties:\n    cpu: 4\n    vcenter_password: hunter3\n"
+
+	handler := RedactionMiddleware(config.Redaction{})(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		diff, err := diffConfig("default", source, target, true, true)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult(EnvDiffResult{CloudConfig: diff})
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	structured, _ := json.Marshal(result.StructuredContent)
+	for _, output := range []string{text, string(structured)} {
+		if strings.Contains(output, "hunter2") || strings.Contains(output, "hunter3") {
+			t.Errorf("expected diffed passwords to be redacted, got %s", output)
+		}
+		if !strings.Contains(output, "vcenter_password") || !strings.Contains(output, `"op": "changed"`) && !strings.Contains(output, `"op":"changed"`) {
+			t.Errorf("expected the change's path and op to be kept, got %s", output)
+		}
+		if !strings.Contains(output, `"4"`) {
+			t.Errorf("expected non-secret changes to be kept, got %s", output)
+		}
+	}
+}
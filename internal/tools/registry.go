@@ -4,6 +4,9 @@
 package tools
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/malston/bosh-mcp-server/internal/auth"
 	"github.com/malston/bosh-mcp-server/internal/bosh"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,24 +16,50 @@ import (
 // Registry holds tool dependencies and registrations.
 type Registry struct {
 	authProvider *auth.Provider
+	lookups      *lookupCache
+	infos        *infoCache
+	credhubs     *credhubClientCache
+	// namedOnly resolves environments only from the BOSH config file, so
+	// fan-out queries reach each named director even when env vars are set.
+	namedOnly bool
 }
 
 // NewRegistry creates a tool registry with the given auth provider.
 func NewRegistry(authProvider *auth.Provider) *Registry {
 	return &Registry{
 		authProvider: authProvider,
+		lookups:      newLookupCache(lookupCacheTTL),
+		infos:        newInfoCache(),
+		credhubs:     newCredhubClientCache(),
 	}
 }
 
 // GetClient returns a BOSH client for the given environment.
-func (r *Registry) GetClient(environment string) (*bosh.Client, error) {
-	creds, err := r.authProvider.GetCredentials(environment)
+func (r *Registry) GetClient(ctx context.Context, environment string) (*bosh.Client, error) {
+	creds, err := r.credentials(ctx, environment)
 	if err != nil {
 		return nil, err
 	}
 	return bosh.NewClient(creds)
 }
 
+// credentials resolves the credentials for the given environment and
+// records them in ctx for EnvironmentMiddleware.
+func (r *Registry) credentials(ctx context.Context, environment string) (*auth.Credentials, error) {
+	var creds *auth.Credentials
+	var err error
+	if r.namedOnly {
+		creds, err = r.authProvider.NamedCredentials(environment)
+	} else {
+		creds, err = r.authProvider.GetCredentials(environment)
+	}
+	if err != nil {
+		return nil, err
+	}
+	recordCredentials(ctx, creds)
+	return creds, nil
+}
+
 // RegisterTools registers all tools with the MCP server.
 func (r *Registry) RegisterTools(s *server.MCPServer) {
 	r.registerDiagnosticTools(s)
@@ -39,27 +68,37 @@ func (r *Registry) RegisterTools(s *server.MCPServer) {
 
 func (r *Registry) registerDiagnosticTools(s *server.MCPServer) {
 	// bosh_vms
-	s.AddTool(mcp.NewTool("bosh_vms",
+	r.addFanOutTool(s, mcp.NewTool("bosh_vms",
 		mcp.WithDescription("List VMs for a BOSH deployment"),
 		mcp.WithString("deployment",
 			mcp.Required(),
 			mcp.Description("Name of the deployment")),
+		mcp.WithBoolean("vitals",
+			mcp.Description("Include CPU, memory, swap, load, and disk vitals (slower; the Director queries each agent)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshVMs)
+		withInstanceFilters(),
+		withPagination(),
+		withFormat(),
+		outputSchema[VMsResult](),
+	), (*Registry).handleBoshVMs)
 
 	// bosh_instances
-	s.AddTool(mcp.NewTool("bosh_instances",
+	r.addFanOutTool(s, mcp.NewTool("bosh_instances",
 		mcp.WithDescription("List instances with process details for a BOSH deployment"),
 		mcp.WithString("deployment",
 			mcp.Required(),
 			mcp.Description("Name of the deployment")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshInstances)
+		withInstanceFilters(),
+		withPagination(),
+		withFormat(),
+		outputSchema[InstancesResult](),
+	), (*Registry).handleBoshInstances)
 
 	// bosh_tasks
-	s.AddTool(mcp.NewTool("bosh_tasks",
+	r.addFanOutTool(s, mcp.NewTool("bosh_tasks",
 		mcp.WithDescription("List recent BOSH tasks"),
 		mcp.WithString("state",
 			mcp.Description("Filter by state: queued, processing, done, error")),
@@ -69,7 +108,10 @@ func (r *Registry) registerDiagnosticTools(s *server.MCPServer) {
 			mcp.Description("Maximum number of tasks to return")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshTasks)
+		withPagination(),
+		withFormat(),
+		outputSchema[TasksResult](),
+	), (*Registry).handleBoshTasks)
 
 	// bosh_task
 	s.AddTool(mcp.NewTool("bosh_task",
@@ -81,6 +123,7 @@ func (r *Registry) registerDiagnosticTools(s *server.MCPServer) {
 			mcp.Description("Include task output")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[TaskResult](),
 	), r.handleBoshTask)
 
 	// bosh_task_wait
@@ -93,66 +136,245 @@ func (r *Registry) registerDiagnosticTools(s *server.MCPServer) {
 			mcp.Description("Timeout in seconds (default: 600)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[TaskResult](),
 	), r.handleBoshTaskWait)
+
+	// bosh_vitals
+	r.addFanOutTool(s, mcp.NewTool("bosh_vitals",
+		mcp.WithDescription("Rank instances of a deployment by memory, disk, and load, flagging those above thresholds"),
+		mcp.WithString("deployment",
+			mcp.Required(),
+			mcp.Description("Name of the deployment")),
+		mcp.WithNumber("memory_threshold",
+			mcp.Description(fmt.Sprintf("Flag instances at or above this memory percentage (default: %d)", defaultMemoryThreshold))),
+		mcp.WithNumber("disk_threshold",
+			mcp.Description(fmt.Sprintf("Flag instances at or above this system, ephemeral, or persistent disk percentage (default: %d)", defaultDiskThreshold))),
+		mcp.WithNumber("load_threshold",
+			mcp.Description("Flag instances at or above this 1-minute load average (default: disabled)")),
+		mcp.WithBoolean("flagged_only",
+			mcp.Description("Only return flagged instances")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		withInstanceFilters(),
+		withPagination(),
+		withFormat(),
+		outputSchema[VitalsResult](),
+	), (*Registry).handleBoshVitals)
+
+	// bosh_health
+	r.addFanOutTool(s, mcp.NewTool("bosh_health",
+		mcp.WithDescription("Summarize deployment health: failing instances and processes, unresponsive agents, stopped or missing VMs, AZ imbalance, locks, and recent failed tasks. Checks all deployments when deployment is omitted"),
+		mcp.WithString("deployment",
+			mcp.Description("Name of the deployment (optional; defaults to all deployments)")),
+		mcp.WithNumber("window_hours",
+			mcp.Description("How many hours back failed tasks count as recent errors (default: 24)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[HealthResult](),
+	), (*Registry).handleBoshHealth)
 }
 
 func (r *Registry) registerInfrastructureTools(s *server.MCPServer) {
+	// bosh_info
+	r.addFanOutTool(s, mcp.NewTool("bosh_info",
+		mcp.WithDescription("Show the Director's name, UUID, version, CPI, user authentication type and UAA URL, enabled features such as config server and DNS, and which tools it can't serve"),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[InfoResult](),
+	), (*Registry).handleBoshInfo)
+
 	// bosh_stemcells
-	s.AddTool(mcp.NewTool("bosh_stemcells",
+	r.addFanOutTool(s, mcp.NewTool("bosh_stemcells",
 		mcp.WithDescription("List uploaded stemcells"),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshStemcells)
+		withPagination(),
+		withFormat(),
+		outputSchema[StemcellsResult](),
+	), (*Registry).handleBoshStemcells)
+
+	// bosh_stemcell_report
+	r.addFanOutTool(s, mcp.NewTool("bosh_stemcell_report",
+		mcp.WithDescription("Report the stemcell each deployment uses, how many versions it is behind the newest uploaded stemcell, which deployments deleting old stemcells would affect, and an ordered upgrade plan"),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[StemcellReportResult](),
+	), (*Registry).handleBoshStemcellReport)
 
 	// bosh_releases
-	s.AddTool(mcp.NewTool("bosh_releases",
+	r.addFanOutTool(s, mcp.NewTool("bosh_releases",
 		mcp.WithDescription("List uploaded releases"),
+		mcp.WithString("name",
+			mcp.Description("Filter by release name")),
+		mcp.WithBoolean("group_by_name",
+			mcp.Description("Group versions under each release name")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshReleases)
+		withPagination(),
+		withFormat(),
+		outputSchema[ReleasesResult](),
+	), (*Registry).handleBoshReleases)
+
+	// bosh_inspect_release
+	r.addFanOutTool(s, mcp.NewTool("bosh_inspect_release",
+		mcp.WithDescription("Show a release version's jobs and packages with fingerprints, which stemcells its packages are compiled for, and optionally whether it is fully compiled for a given stemcell"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the release")),
+		mcp.WithString("version",
+			mcp.Required(),
+			mcp.Description("Release version")),
+		mcp.WithString("stemcell",
+			mcp.Description("Check compilation for this stemcell, as operating-system/version (e.g. ubuntu-jammy/1.200) or uploaded stemcell name/version (optional)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[InspectReleaseResult](),
+	), (*Registry).handleBoshInspectRelease)
 
 	// bosh_deployments
-	s.AddTool(mcp.NewTool("bosh_deployments",
+	r.addFanOutTool(s, mcp.NewTool("bosh_deployments",
 		mcp.WithDescription("List all deployments"),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshDeployments)
+		withPagination(),
+		withFormat(),
+		outputSchema[DeploymentsResult](),
+	), (*Registry).handleBoshDeployments)
 
 	// bosh_cloud_config
-	s.AddTool(mcp.NewTool("bosh_cloud_config",
+	r.addFanOutTool(s, mcp.NewTool("bosh_cloud_config",
 		mcp.WithDescription("Get current cloud config"),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshCloudConfig)
+		outputSchema[CloudConfigResult](),
+	), (*Registry).handleBoshCloudConfig)
 
 	// bosh_runtime_config
-	s.AddTool(mcp.NewTool("bosh_runtime_config",
+	r.addFanOutTool(s, mcp.NewTool("bosh_runtime_config",
 		mcp.WithDescription("Get runtime configs"),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshRuntimeConfig)
+		outputSchema[RuntimeConfigsResult](),
+	), (*Registry).handleBoshRuntimeConfig)
 
 	// bosh_cpi_config
-	s.AddTool(mcp.NewTool("bosh_cpi_config",
+	r.addFanOutTool(s, mcp.NewTool("bosh_cpi_config",
 		mcp.WithDescription("Get CPI config"),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshCPIConfig)
+		outputSchema[CPIConfigResult](),
+	), (*Registry).handleBoshCPIConfig)
+
+	// bosh_configs
+	r.addFanOutTool(s, mcp.NewTool("bosh_configs",
+		mcp.WithDescription("List configs of every type (cloud, runtime, cpi, and others) with their IDs, optionally including previous versions. Pass id to get one version with its content."),
+		mcp.WithString("type",
+			mcp.Description("Only list configs of this type, e.g. cloud, runtime, or cpi (optional)")),
+		mcp.WithString("name",
+			mcp.Description("Only list configs with this name (optional)")),
+		mcp.WithBoolean("history",
+			mcp.Description("Include previous versions, not just the latest of each config")),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of versions the Director returns with history (optional)")),
+		mcp.WithString("id",
+			mcp.Description("Get this config version, including its content (optional)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		withPagination(),
+		withFormat(),
+		outputSchema[ConfigsResult](),
+	), (*Registry).handleBoshConfigs)
+
+	// bosh_config_diff
+	r.addFanOutTool(s, mcp.NewTool("bosh_config_diff",
+		mcp.WithDescription("Show how proposed config content differs from the latest config of the same type and name, without changing anything"),
+		mcp.WithString("type",
+			mcp.Required(),
+			mcp.Description("Config type, e.g. cloud, runtime, or cpi")),
+		mcp.WithString("name",
+			mcp.Description("Config name (default: default)")),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("Proposed config YAML")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[ConfigDiffResult](),
+	), (*Registry).handleBoshConfigDiff)
 
 	// bosh_variables
-	s.AddTool(mcp.NewTool("bosh_variables",
-		mcp.WithDescription("List variables for a deployment"),
+	r.addFanOutTool(s, mcp.NewTool("bosh_variables",
+		mcp.WithDescription("List variables for a deployment, optionally with CredHub metadata: type, version creation date, and for certificates the subject, issuer, expiry, and SANs. Secret values are never returned"),
 		mcp.WithString("deployment",
 			mcp.Required(),
 			mcp.Description("Name of the deployment")),
+		mcp.WithBoolean("metadata",
+			mcp.Description("Look up each variable's metadata in CredHub (slower; one request per variable; needs the config server)")),
+		mcp.WithString("type",
+			mcp.Description("Only list variables of this CredHub type, e.g. certificate or password (implies metadata)")),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshVariables)
+		withPagination(),
+		withFormat(),
+		outputSchema[VariablesResult](),
+	), (*Registry).handleBoshVariables)
+
+	// bosh_cert_expiry
+	r.addFanOutTool(s, mcp.NewTool("bosh_cert_expiry",
+		mcp.WithDescription("Scan deployments' certificate variables in CredHub and list those expiring within a number of days, soonest first, with whether a newer version is already generated but not yet deployed. Pass environments [\"*\"] to scan every environment"),
+		mcp.WithNumber("days",
+			mcp.Description(fmt.Sprintf("List certificates expiring within this many days, including expired ones (default: %d)", defaultExpiryDays))),
+		mcp.WithString("deployment",
+			mcp.Description("Only scan this deployment (optional; defaults to all deployments)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		withPagination(),
+		withFormat(),
+		outputSchema[CertExpiryResult](),
+	), (*Registry).handleBoshCertExpiry)
 
 	// bosh_locks
-	s.AddTool(mcp.NewTool("bosh_locks",
+	r.addFanOutTool(s, mcp.NewTool("bosh_locks",
 		mcp.WithDescription("Show current deployment locks"),
 		mcp.WithString("environment",
 			mcp.Description("Named BOSH environment (optional)")),
-	), r.handleBoshLocks)
+		withPagination(),
+		withFormat(),
+		outputSchema[LocksResult](),
+	), (*Registry).handleBoshLocks)
+
+	// bosh_orphaned_disks
+	r.addFanOutTool(s, mcp.NewTool("bosh_orphaned_disks",
+		mcp.WithDescription("List persistent disks orphaned by deleted instances or deployments, with the deployment and instance they came from"),
+		mcp.WithString("deployment",
+			mcp.Description("Only list disks orphaned from this deployment (optional)")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		withPagination(),
+		withFormat(),
+		outputSchema[OrphanedDisksResult](),
+	), (*Registry).handleBoshOrphanedDisks)
+
+	// bosh_cleanup_plan
+	r.addFanOutTool(s, mcp.NewTool("bosh_cleanup_plan",
+		mcp.WithDescription("List exactly which release versions, stemcells, orphaned disks, and orphaned VMs a clean-up would remove, with reclaimed disk space"),
+		mcp.WithBoolean("all",
+			mcp.Description("Plan a clean-up with --all semantics: remove all unused releases and stemcells and all orphaned disks")),
+		mcp.WithString("environment",
+			mcp.Description("Named BOSH environment (optional)")),
+		outputSchema[CleanupPlanResult](),
+	), (*Registry).handleBoshCleanupPlan)
+
+	// bosh_env_diff
+	s.AddTool(mcp.NewTool("bosh_env_diff",
+		mcp.WithDescription("Compare two environments' deployments, release and stemcell versions, runtime configs, and cloud config, reporting what the target has ahead, behind, or missing relative to the source"),
+		mcp.WithString("source",
+			mcp.Required(),
+			mcp.Description("Named environment to compare from, e.g. sandbox")),
+		mcp.WithString("target",
+			mcp.Required(),
+			mcp.Description("Named environment to compare to, e.g. production")),
+		mcp.WithBoolean("include_same",
+			mcp.Description("Also list items that are identical in both environments")),
+		outputSchema[EnvDiffResult](),
+	), r.handleBoshEnvDiff)
 }
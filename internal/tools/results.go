@@ -0,0 +1,353 @@
+// ABOUTME: Defines typed result structs returned by each tool.
+// ABOUTME: Output schemas are generated from these types and the BOSH API types they embed.
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// VMsResult is returned by bosh_vms.
+type VMsResult struct {
+	Deployment string    `json:"deployment"`
+	VMs        []bosh.VM `json:"vms"`
+	Page       *PageInfo `json:"page,omitempty"`
+}
+
+// InstancesResult is returned by bosh_instances.
+type InstancesResult struct {
+	Deployment string          `json:"deployment"`
+	Instances  []bosh.Instance `json:"instances"`
+	Page       *PageInfo       `json:"page,omitempty"`
+}
+
+// TasksResult is returned by bosh_tasks.
+type TasksResult struct {
+	Tasks []bosh.Task `json:"tasks"`
+	Page  *PageInfo   `json:"page,omitempty"`
+}
+
+// TaskResult is returned by bosh_task and bosh_task_wait.
+type TaskResult struct {
+	Task   *bosh.Task `json:"task"`
+	Output string     `json:"output,omitempty"`
+}
+
+// StemcellsResult is returned by bosh_stemcells.
+type StemcellsResult struct {
+	Stemcells []bosh.Stemcell `json:"stemcells"`
+	Page      *PageInfo       `json:"page,omitempty"`
+}
+
+// ReleasesResult is returned by bosh_releases. When grouped by name,
+// groups is set instead of releases.
+type ReleasesResult struct {
+	Releases []bosh.Release `json:"releases,omitempty"`
+	Groups   []ReleaseGroup `json:"groups,omitempty"`
+	Page     *PageInfo      `json:"page,omitempty"`
+}
+
+// InfoResult is returned by bosh_info.
+type InfoResult struct {
+	Info             bosh.Info         `json:"info"`
+	UnsupportedTools []UnsupportedTool `json:"unsupported_tools"`
+}
+
+// DeploymentsResult is returned by bosh_deployments.
+type DeploymentsResult struct {
+	Deployments []bosh.Deployment `json:"deployments"`
+	Page        *PageInfo         `json:"page,omitempty"`
+}
+
+// CloudConfigResult is returned by bosh_cloud_config.
+type CloudConfigResult struct {
+	CloudConfig *bosh.CloudConfig `json:"cloud_config"`
+}
+
+// RuntimeConfigsResult is returned by bosh_runtime_config.
+type RuntimeConfigsResult struct {
+	RuntimeConfigs []bosh.RuntimeConfig `json:"runtime_configs"`
+}
+
+// CPIConfigResult is returned by bosh_cpi_config.
+type CPIConfigResult struct {
+	CPIConfig *bosh.CPIConfig `json:"cpi_config"`
+}
+
+// VariablesResult is returned by bosh_variables.
+type VariablesResult struct {
+	Deployment string            `json:"deployment"`
+	Variables  []VariableDetails `json:"variables"`
+	Page       *PageInfo         `json:"page,omitempty"`
+}
+
+// CertExpiryResult is returned by bosh_cert_expiry. Scanned counts the
+// certificates checked; errors lists deployments and variables that could
+// not be read.
+type CertExpiryResult struct {
+	Days         int                   `json:"days"`
+	Scanned      int                   `json:"scanned"`
+	Certificates []ExpiringCertificate `json:"certificates"`
+	Errors       []string              `json:"errors,omitempty"`
+	Page         *PageInfo             `json:"page,omitempty"`
+}
+
+// LocksResult is returned by bosh_locks.
+type LocksResult struct {
+	Locks []bosh.Lock `json:"locks"`
+	Page  *PageInfo   `json:"page,omitempty"`
+}
+
+// OrphanedDisksResult is returned by bosh_orphaned_disks.
+type OrphanedDisksResult struct {
+	Disks []bosh.OrphanedDisk `json:"disks"`
+	Page  *PageInfo           `json:"page,omitempty"`
+}
+
+// ConfigsResult is returned by bosh_configs. Content is only included when
+// a single version is requested by id.
+type ConfigsResult struct {
+	Configs []bosh.Config `json:"configs"`
+	Page    *PageInfo     `json:"page,omitempty"`
+}
+
+// ConfigDiffResult is returned by bosh_config_diff. LatestID is the version
+// the proposed content was compared against.
+type ConfigDiffResult struct {
+	Type     string                `json:"type"`
+	Name     string                `json:"name"`
+	LatestID string                `json:"latest_id,omitempty"`
+	Changed  bool                  `json:"changed"`
+	Added    int                   `json:"added"`
+	Removed  int                   `json:"removed"`
+	Diff     []bosh.ConfigDiffLine `json:"diff"`
+}
+
+// ConfigUpdateResult is returned by bosh_update_config. It is either a
+// confirmation request showing the diff or the applied change.
+type ConfigUpdateResult struct {
+	RequiresConfirmation bool              `json:"requires_confirmation,omitempty"`
+	ConfirmationToken    string            `json:"confirmation_token,omitempty"`
+	Operation            string            `json:"operation"`
+	Type                 string            `json:"type"`
+	Name                 string            `json:"name"`
+	Diff                 *ConfigDiffResult `json:"diff,omitempty"`
+	Config               *bosh.Config      `json:"config,omitempty"`
+	ExpiresInSeconds     int               `json:"expires_in_seconds,omitempty"`
+	Message              string            `json:"message,omitempty"`
+}
+
+// VitalsResult is returned by bosh_vitals.
+type VitalsResult struct {
+	Deployment string           `json:"deployment"`
+	Thresholds VitalsThresholds `json:"thresholds"`
+	Flagged    int              `json:"flagged"`
+	Vitals     []InstanceVitals `json:"vitals"`
+	Page       *PageInfo        `json:"page,omitempty"`
+}
+
+// StemcellReportResult is returned by bosh_stemcell_report.
+type StemcellReportResult struct {
+	Deployments []DeploymentStemcell `json:"deployments"`
+	Stemcells   []StemcellUsage      `json:"stemcells"`
+	UpgradePlan []UpgradeStep        `json:"upgrade_plan"`
+}
+
+// InspectReleaseResult is returned by bosh_inspect_release. SourcePackages
+// counts packages whose source is in the release and can be compiled on any
+// stemcell. StemcellCheck is set when a stemcell was given.
+type InspectReleaseResult struct {
+	Name              string                `json:"name"`
+	Version           string                `json:"version"`
+	Jobs              []bosh.ReleaseJob     `json:"jobs"`
+	Packages          []bosh.ReleasePackage `json:"packages"`
+	SourcePackages    int                   `json:"source_packages"`
+	CompiledStemcells []CompiledStemcell    `json:"compiled_stemcells"`
+	StemcellCheck     *StemcellCheck        `json:"stemcell_check,omitempty"`
+}
+
+// EnvDiffResult is returned by bosh_env_diff. Statuses describe the target
+// environment relative to the source: same, ahead, behind, changed, missing
+// (only in source), or extra (only in target).
+type EnvDiffResult struct {
+	Source         string           `json:"source"`
+	Target         string           `json:"target"`
+	Summary        EnvDiffSummary   `json:"summary"`
+	Deployments    []DeploymentDiff `json:"deployments"`
+	Releases       []VersionDiff    `json:"releases"`
+	Stemcells      []VersionDiff    `json:"stemcells"`
+	RuntimeConfigs []ConfigDiff     `json:"runtime_configs"`
+	CloudConfig    ConfigDiff       `json:"cloud_config"`
+}
+
+// HealthResult is returned by bosh_health. Verdict is the worst verdict of
+// any deployment: healthy, degraded, unknown, or unhealthy.
+type HealthResult struct {
+	Verdict     string             `json:"verdict"`
+	Deployments []DeploymentHealth `json:"deployments"`
+}
+
+// DeploymentHealth is the health report for a single deployment.
+type DeploymentHealth struct {
+	Deployment   string        `json:"deployment"`
+	Verdict      string        `json:"verdict"`
+	Instances    int           `json:"instances"`
+	Running      int           `json:"running"`
+	Stopped      int           `json:"stopped"`
+	Locked       bool          `json:"locked"`
+	Issues       []HealthIssue `json:"issues"`
+	RecentErrors []bosh.Task   `json:"recent_errors,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// HealthIssue is a single problem found by a health check.
+type HealthIssue struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Instance string `json:"instance,omitempty"`
+	Message  string `json:"message"`
+}
+
+// CleanupPlanResult is returned by bosh_cleanup_plan and lists what a
+// clean-up would remove. ReclaimedDiskMB covers orphaned disks only, since
+// the Director doesn't report release or stemcell sizes.
+type CleanupPlanResult struct {
+	RemoveAll         bool                `json:"remove_all"`
+	Summary           string              `json:"summary"`
+	Digest            string              `json:"plan_digest"`
+	Releases          []bosh.NameVersion  `json:"releases"`
+	Stemcells         []bosh.NameVersion  `json:"stemcells"`
+	OrphanedDisks     []bosh.OrphanedDisk `json:"orphaned_disks"`
+	OrphanedVMs       []bosh.OrphanedVM   `json:"orphaned_vms"`
+	ReclaimedDiskMB   int                 `json:"reclaimed_disk_mb"`
+	KeptOrphanedDisks int                 `json:"kept_orphaned_disks"`
+}
+
+// CleanupResult is returned by bosh_cleanup. It is either a confirmation
+// request for the plan or the completed clean-up task.
+type CleanupResult struct {
+	RequiresConfirmation bool               `json:"requires_confirmation,omitempty"`
+	ConfirmationToken    string             `json:"confirmation_token,omitempty"`
+	ExpiresInSeconds     int                `json:"expires_in_seconds,omitempty"`
+	Message              string             `json:"message,omitempty"`
+	Plan                 *CleanupPlanResult `json:"plan"`
+	TaskID               int                `json:"task_id,omitempty"`
+	State                string             `json:"state,omitempty"`
+	Output               string             `json:"output,omitempty"`
+}
+
+// DiskOperationResult is returned by bosh_delete_disk and bosh_attach_disk.
+// Disk describes the orphaned disk when the Director knows it as one.
+type DiskOperationResult struct {
+	RequiresConfirmation bool               `json:"requires_confirmation,omitempty"`
+	ConfirmationToken    string             `json:"confirmation_token,omitempty"`
+	Operation            string             `json:"operation,omitempty"`
+	DiskCID              string             `json:"disk_cid"`
+	Disk                 *bosh.OrphanedDisk `json:"disk,omitempty"`
+	Deployment           string             `json:"deployment,omitempty"`
+	Instance             string             `json:"instance,omitempty"`
+	ExpiresInSeconds     int                `json:"expires_in_seconds,omitempty"`
+	Message              string             `json:"message,omitempty"`
+	TaskID               int                `json:"task_id,omitempty"`
+	State                string             `json:"state,omitempty"`
+	Output               string             `json:"output,omitempty"`
+}
+
+// UploadResult is returned by bosh_upload_release and bosh_upload_stemcell.
+// Source is the URL or local path uploaded. A local path upload is first
+// a confirmation request (requires_confirmation set).
+type UploadResult struct {
+	RequiresConfirmation bool   `json:"requires_confirmation,omitempty"`
+	ConfirmationToken    string `json:"confirmation_token,omitempty"`
+	Type                 string `json:"type"`
+	Source               string `json:"source"`
+	ExpiresInSeconds     int    `json:"expires_in_seconds,omitempty"`
+	Message              string `json:"message,omitempty"`
+	TaskID               int    `json:"task_id,omitempty"`
+	State                string `json:"state,omitempty"`
+	Output               string `json:"output,omitempty"`
+}
+
+// DeleteArtifactResult is returned by bosh_delete_release and
+// bosh_delete_stemcell. UsedBy lists deployments still using the release or
+// stemcell, which is only possible when forced.
+type DeleteArtifactResult struct {
+	RequiresConfirmation bool     `json:"requires_confirmation,omitempty"`
+	ConfirmationToken    string   `json:"confirmation_token,omitempty"`
+	Operation            string   `json:"operation"`
+	Name                 string   `json:"name"`
+	Version              string   `json:"version,omitempty"`
+	UsedBy               []string `json:"used_by,omitempty"`
+	ExpiresInSeconds     int      `json:"expires_in_seconds,omitempty"`
+	Message              string   `json:"message,omitempty"`
+	TaskID               int      `json:"task_id,omitempty"`
+	State                string   `json:"state,omitempty"`
+	Output               string   `json:"output,omitempty"`
+}
+
+// IgnoreResult is returned by bosh_ignore and bosh_unignore. Instance is
+// always given as group/id.
+type IgnoreResult struct {
+	Deployment string `json:"deployment"`
+	Instance   string `json:"instance"`
+	Ignore     bool   `json:"ignore"`
+	Message    string `json:"message"`
+}
+
+// OperationResult is returned by deployment operation tools. It is either a
+// confirmation request (requires_confirmation set) or a completed task.
+type OperationResult struct {
+	RequiresConfirmation bool     `json:"requires_confirmation,omitempty"`
+	ConfirmationToken    string   `json:"confirmation_token,omitempty"`
+	Operation            string   `json:"operation,omitempty"`
+	Deployment           string   `json:"deployment"`
+	Job                  string   `json:"job,omitempty"`
+	Instance             string   `json:"instance,omitempty"`
+	Options              []string `json:"options,omitempty"`
+	ExpiresInSeconds     int      `json:"expires_in_seconds,omitempty"`
+	Message              string   `json:"message,omitempty"`
+	TaskID               int      `json:"task_id,omitempty"`
+	State                string   `json:"state,omitempty"`
+	Output               string   `json:"output,omitempty"`
+}
+
+// outputSchema declares a tool's output schema generated from result type T.
+func outputSchema[T any]() mcp.ToolOption {
+	return mcp.WithRawOutputSchema(schemaFor[T]())
+}
+
+// schemaFor generates a JSON schema for T. Fields are optional so that
+// projected results remain valid; unknown fields are allowed for clients.
+func schemaFor[T any]() json.RawMessage {
+	reflector := jsonschema.Reflector{
+		DoNotReference:             true,
+		Anonymous:                  true,
+		AllowAdditionalProperties:  true,
+		RequiredFromJSONSchemaTags: true,
+	}
+
+	var zero T
+	schema := reflector.Reflect(zero)
+	schema.Version = ""
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return json.RawMessage(`{"type":"object"}`)
+	}
+	return data
+}
+
+// toolResult returns v as structured content with pretty-printed JSON text.
+func toolResult(v any) (*mcp.CallToolResult, error) {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultStructured(v, string(jsonBytes)), nil
+}
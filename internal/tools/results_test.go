@@ -0,0 +1,370 @@
+// ABOUTME: Tests that every tool declares an output schema and its results match it.
+// ABOUTME: Calls each registered tool against a mock Director and validates structured content.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// schemaToolArgs lists the arguments used to exercise each tool.
+// Every registered tool must have an entry.
+var schemaToolArgs = map[string]map[string]interface{}{
+	"bosh_vms":               {"deployment": "cf", "vitals": true},
+	"bosh_instances":         {"deployment": "cf"},
+	"bosh_tasks":             {},
+	"bosh_task":              {"id": float64(42), "output": true},
+	"bosh_task_wait":         {"id": float64(42)},
+	"bosh_health":            {"deployment": "cf"},
+	"bosh_vitals":            {"deployment": "cf"},
+	"bosh_stemcells":         {},
+	"bosh_stemcell_report":   {},
+	"bosh_releases":          {},
+	"bosh_deployments":       {},
+	"bosh_cloud_config":      {},
+	"bosh_runtime_config":    {},
+	"bosh_cpi_config":        {},
+	"bosh_variables":         {"deployment": "cf"},
+	"bosh_locks":             {},
+	"bosh_env_diff":          {"source": "sandbox", "target": "prod", "include_same": true},
+	"bosh_delete_deployment": {"deployment": "cf"},
+	"bosh_recreate":          {"deployment": "cf", "job": "router"},
+	"bosh_stop":              {"deployment": "cf", "job": "router"},
+	"bosh_start":             {"deployment": "cf", "job": "router"},
+	"bosh_restart":           {"deployment": "cf", "job": "router"},
+	"bosh_cleanup_plan":      {"all": true},
+	"bosh_cleanup":           {},
+	"bosh_orphaned_disks":    {"deployment": "cf"},
+	"bosh_delete_disk":       {"disk_cid": "disk-9"},
+	"bosh_attach_disk":       {"deployment": "cf", "instance": "router/uuid-1", "disk_cid": "disk-9"},
+	"bosh_configs":           {"type": "cloud", "history": true},
+	"bosh_config_diff":       {"type": "cloud", "content": "vm_types: [{name: large}]"},
+	"bosh_update_config":     {"type": "cloud", "content": "vm_types: [{name: large}]"},
+	"bosh_upload_release":    {"url": "https://example.com/cf.tgz", "sha1": "abc"},
+	"bosh_upload_stemcell":   {"url": "https://example.com/stemcell.tgz"},
+	"bosh_delete_release":    {"name": "cf", "version": "1.0.0", "force": true},
+	"bosh_delete_stemcell":   {"name": "bosh-stemcell", "version": "1.200", "force": true},
+	"bosh_inspect_release":   {"name": "cf", "version": "1.0.0", "stemcell": "bosh-stemcell/1.200"},
+	"bosh_info":              {},
+	"bosh_cert_expiry":       {"deployment": "cf"},
+	"bosh_ignore":            {"deployment": "cf", "instance": "router/0"},
+	"bosh_unignore":          {"deployment": "cf", "instance": "router/uuid-1"},
+}
+
+// newSchemaTestDirector serves fully-populated responses for every endpoint.
+func newSchemaTestDirector(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	responses := map[string]interface{}{
+		"/deployments/cf/vms": []bosh.VM{{
+			VMCID: "vm-1", Active: true, AgentID: "agent-1", AZ: "z1", Bootstrap: true,
+			Deployment: "cf", IPs: []string{"10.0.0.1"}, Job: "router", Index: 0, ID: "uuid-1",
+			ProcessState: "running", State: "started", VMType: "small", Ignore: false,
+			Vitals: &bosh.Vitals{
+				CPU:  &bosh.VitalsCPU{Sys: "1.0", User: "2.5", Wait: "0.1"},
+				Mem:  &bosh.VitalsMemory{KB: "1024", Percent: "42"},
+				Swap: &bosh.VitalsMemory{KB: "0", Percent: "0"},
+				Load: []string{"0.10", "0.20", "0.30"},
+				Disk: &bosh.VitalsDisks{
+					System:     &bosh.VitalsDisk{Percent: "40", InodePercent: "10"},
+					Ephemeral:  &bosh.VitalsDisk{Percent: "91", InodePercent: "5"},
+					Persistent: &bosh.VitalsDisk{Percent: "12", InodePercent: "1"},
+				},
+				Uptime: &bosh.Uptime{Seconds: 3600},
+			},
+		}},
+		"/deployments/cf/instances": []bosh.Instance{{
+			AgentID: "agent-1", AZ: "z1", Bootstrap: true, Deployment: "cf", Disk: "disk-1",
+			Expects: true, ID: "uuid-1", IPs: []string{"10.0.0.1"}, Job: "router", Index: 0,
+			State: "running", VMType: "small", VMCID: "vm-1", Ignore: false,
+			Processes: []bosh.Process{{
+				Name: "gorouter", State: "running",
+				Uptime: &bosh.Uptime{Seconds: 60},
+				Memory: &bosh.ResourceUsage{Percent: 1.5, KB: 1024},
+				CPU:    &bosh.CPUUsage{Total: 0.5},
+			}},
+		}},
+		"/tasks": []bosh.Task{{
+			ID: 42, State: "done", Description: "deploy", Timestamp: 1700000000,
+			Result: "ok", User: "admin", Deployment: "cf", ContextID: "ctx",
+		}},
+		"/tasks/42": bosh.Task{ID: 42, State: "done", Description: "deploy", User: "admin"},
+		"/stemcells": []bosh.Stemcell{{
+			Name: "bosh-stemcell", OperatingSystem: "ubuntu-jammy", Version: "1.200",
+			CID: "sc-1", Deployments: []string{"cf"},
+		}},
+		"/releases": []bosh.Release{{Name: "cf", Version: "1.0.0", CommitHash: "abc", UncommittedChanges: false}},
+		"/deployments": []bosh.Deployment{{
+			Name: "cf", CloudConfig: "latest",
+			Releases:  []bosh.NameVersion{{Name: "cf", Version: "1.0.0"}},
+			Stemcells: []bosh.NameVersion{{Name: "bosh-stemcell", Version: "1.200"}},
+		}},
+		"/deployments/cf/variables": []bosh.Variable{{ID: "1", Name: "/cf/admin_password"}},
+		"/releases/cf/versions/1.0.0": bosh.ReleaseDetails{
+			Jobs: []bosh.ReleaseJob{{Name: "router", Version: "abc", Fingerprint: "abc", SHA1: "sha", BlobstoreID: "blob-1", Packages: []string{"gorouter"}}},
+			Packages: []bosh.ReleasePackage{{
+				Name: "gorouter", Version: "def", Fingerprint: "def", SHA1: "sha", BlobstoreID: "blob-2", Dependencies: []string{"golang"},
+				CompiledPackages: []bosh.CompiledPackage{{Stemcell: "ubuntu-jammy/1.200", SHA1: "sha", BlobstoreID: "blob-3", DependencyKey: "[]"}},
+			}},
+		},
+		"/locks": []bosh.Lock{{Type: "deployment", Resource: "cf", Timeout: "1", TaskID: "42"}},
+		"/disks": []bosh.OrphanedDisk{{
+			DiskCID: "disk-9", Size: 10240, AZ: "z1", Deployment: "cf", Instance: "router/uuid-9", OrphanedAt: "2024-01-01",
+		}},
+		"/orphaned_vms": []bosh.OrphanedVM{{
+			CID: "vm-9", AZ: "z1", Deployment: "cf", Instance: "router/uuid-9", IPs: []string{"10.0.0.9"}, OrphanedAt: "2024-01-01",
+		}},
+	}
+
+	// The Director's config server and UAA are served at the same address.
+	expiring := testCertificatePEM(t, "router.service.cf.internal", time.Now().Add(7*24*time.Hour))
+	renewed := testCertificatePEM(t, "router.service.cf.internal", time.Now().Add(365*24*time.Hour))
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/info":
+			info := bosh.Info{
+				Name:     "bosh",
+				UUID:     "d1f6-uuid",
+				Version:  "280.0.14 (00000000)",
+				CPI:      "vsphere_cpi",
+				Features: map[string]bosh.Feature{"config_server": {Status: true, Extras: map[string]any{"urls": []string{"https://" + r.Host + "/api/"}}}},
+			}
+			info.UserAuthentication.Type = "uaa"
+			info.UserAuthentication.Options.URL = "https://" + r.Host
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(info)
+			return
+		case r.URL.Path == "/oauth/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		case strings.HasPrefix(r.URL.Path, "/api/v1/data"):
+			cert := map[string]any{"id": "1", "name": "/bosh/cf/router_tls", "type": "certificate", "version_created_at": "2024-01-01T00:00:00Z", "value": map[string]string{"certificate": expiring}}
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/api/v1/data" {
+				cert["id"], cert["value"] = "2", map[string]string{"certificate": renewed}
+				json.NewEncoder(w).Encode(map[string]any{"data": []any{cert}})
+				return
+			}
+			json.NewEncoder(w).Encode(cert)
+			return
+		case r.Method == http.MethodPost && r.URL.Path == "/configs/diff":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"diff":[["vm_types:",null],["- name: small","removed"],["- name: large","added"]]}`))
+			return
+		case r.Method == http.MethodPost && r.URL.Path == "/configs":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"8","type":"cloud","name":"default","content":"vm_types: []","created_at":"2024-01-02","team":"","current":true}`))
+			return
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/ignore"):
+			return
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/configs/"):
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"7","type":"cloud","name":"default","content":"vm_types: []","created_at":"2024-01-01","team":"","current":true}`))
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			w.Header().Set("Location", "/tasks/42")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/tasks/42/output":
+			w.Write([]byte("task output"))
+			return
+		case "/configs":
+			w.Header().Set("Content-Type", "application/json")
+			configType := r.URL.Query().Get("type")
+			fmt.Fprintf(w, `[{"id":"7","type":"%s","name":"default","properties":"%s: {}","content":"%s: {}","created_at":"2024-01-01","team":"","current":true}]`, configType, configType, configType)
+			return
+		}
+
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}))
+}
+
+func TestToolResults_MatchOutputSchemas(t *testing.T) {
+	director := newSchemaTestDirector(t)
+	defer director.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", director.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(director))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	configPath := writeBoshConfig(t, map[string]string{"sandbox": director.URL, "prod": director.URL})
+	registry := NewRegistry(auth.NewProvider(configPath))
+	deploymentRegistry := NewDeploymentRegistry(registry, config.Load(""))
+
+	s := server.NewMCPServer("test", "0.0.0", server.WithToolCapabilities(true))
+	registry.RegisterTools(s)
+	deploymentRegistry.RegisterDeploymentTools(s)
+
+	for name, tool := range s.ListTools() {
+		t.Run(name, func(t *testing.T) {
+			if tool.Tool.RawOutputSchema == nil {
+				t.Fatalf("tool %s declares no output schema", name)
+			}
+
+			args, ok := schemaToolArgs[name]
+			if !ok {
+				t.Fatalf("tool %s has no entry in schemaToolArgs", name)
+			}
+
+			request := mcp.CallToolRequest{}
+			request.Params.Name = name
+			request.Params.Arguments = args
+
+			result, err := tool.Handler(context.Background(), request)
+			if err != nil {
+				t.Fatalf("handler failed: %v", err)
+			}
+			if result.IsError {
+				t.Fatalf("expected success, got error: %v", result.Content)
+			}
+			if result.StructuredContent == nil {
+				t.Fatal("expected structured content")
+			}
+
+			var schema map[string]interface{}
+			if err := json.Unmarshal(tool.Tool.RawOutputSchema, &schema); err != nil {
+				t.Fatalf("invalid output schema: %v", err)
+			}
+
+			data, err := json.Marshal(result.StructuredContent)
+			if err != nil {
+				t.Fatalf("failed to marshal structured content: %v", err)
+			}
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err != nil {
+				t.Fatalf("failed to unmarshal structured content: %v", err)
+			}
+
+			for _, problem := range validateSchema("$", value, schema) {
+				t.Error(problem)
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			if !json.Valid([]byte(text)) {
+				t.Errorf("expected JSON text content, got: %s", text)
+			}
+		})
+	}
+}
+
+// validateSchema strictly checks value against schema: every field must be
+// declared, required fields must be present, and types must match.
+// Null is accepted for any optional value, and objects without declared
+// properties are free-form.
+func validateSchema(path string, value interface{}, schema map[string]interface{}) []string {
+	if value == nil {
+		return nil
+	}
+
+	var problems []string
+	schemaType, _ := schema["type"].(string)
+
+	switch schemaType {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected object, got %T", path, value)}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok && properties == nil {
+			for key, v := range obj {
+				problems = append(problems, validateSchema(path+"."+key, v, additional)...)
+			}
+			return problems
+		}
+		if properties == nil {
+			// A free-form object, e.g. map[string]any
+			return nil
+		}
+		for key, v := range obj {
+			propSchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: field not declared in schema", path, key))
+				continue
+			}
+			problems = append(problems, validateSchema(path+"."+key, v, propSchema)...)
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := obj[key.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: required field missing", path, key))
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected array, got %T", path, value)}
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, v := range arr {
+			problems = append(problems, validateSchema(fmt.Sprintf("%s[%d]", path, i), v, items)...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected string, got %T", path, value))
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			problems = append(problems, fmt.Sprintf("%s: expected integer, got %v", path, value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected number, got %T", path, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected boolean, got %T", path, value))
+		}
+	case "":
+		// Unconstrained value
+	default:
+		problems = append(problems, fmt.Sprintf("%s: unsupported schema type %s", path, schemaType))
+	}
+
+	return problems
+}
+
+func TestValidateSchema_DetectsDrift(t *testing.T) {
+	var schema map[string]interface{}
+	json.Unmarshal(schemaFor[VMsResult](), &schema)
+
+	value := map[string]interface{}{
+		"deployment": "cf",
+		"vms":        []interface{}{map[string]interface{}{"job": "router", "renamed_field": true}},
+	}
+
+	problems := validateSchema("$", value, schema)
+	if len(problems) != 1 || !strings.Contains(problems[0], "renamed_field") {
+		t.Errorf("expected drift on renamed_field, got %v", problems)
+	}
+}
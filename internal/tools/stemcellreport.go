@@ -0,0 +1,152 @@
+// ABOUTME: Implements bosh_stemcell_report for stemcell drift and upgrade planning.
+// ABOUTME: Shows how far each deployment is behind the newest stemcell and what deleting old stemcells affects.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DeploymentStemcell is a stemcell in use by a deployment and how far behind it is.
+type DeploymentStemcell struct {
+	Deployment     string `json:"deployment"`
+	Name           string `json:"name"`
+	OS             string `json:"os"`
+	Version        string `json:"version"`
+	Latest         string `json:"latest"`
+	VersionsBehind int    `json:"versions_behind"`
+}
+
+// StemcellUsage is an uploaded stemcell with the deployments that would be
+// affected by deleting it.
+type StemcellUsage struct {
+	Name        string   `json:"name"`
+	OS          string   `json:"os"`
+	Version     string   `json:"version"`
+	Latest      bool     `json:"latest"`
+	Deployments []string `json:"deployments"`
+	Deletable   bool     `json:"deletable"`
+}
+
+// UpgradeStep is one deployment to move to the newest stemcell.
+type UpgradeStep struct {
+	Order          int    `json:"order"`
+	Deployment     string `json:"deployment"`
+	Stemcell       string `json:"stemcell"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	VersionsBehind int    `json:"versions_behind"`
+}
+
+func (r *Registry) handleBoshStemcellReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	environment := request.GetString("environment", "")
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	stemcells, err := client.ListStemcells()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list stemcells: %v", err)), nil
+	}
+
+	deployments, err := client.ListDeployments()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list deployments: %v", err)), nil
+	}
+
+	return toolResult(stemcellReport(stemcells, deployments))
+}
+
+// stemcellReport compares the stemcells deployments use with the newest
+// uploaded version of each stemcell line. A line is a stemcell name, which
+// identifies both the OS and the IaaS.
+func stemcellReport(stemcells []bosh.Stemcell, deployments []bosh.Deployment) StemcellReportResult {
+	versions := make(map[string][]string)
+	osByName := make(map[string]string)
+	for _, sc := range stemcells {
+		if !contains(versions[sc.Name], sc.Version) {
+			versions[sc.Name] = append(versions[sc.Name], sc.Version)
+		}
+		osByName[sc.Name] = sc.OperatingSystem
+	}
+
+	latest := make(map[string]string, len(versions))
+	for name, vs := range versions {
+		latest[name] = latestVersion(vs)
+	}
+
+	result := StemcellReportResult{
+		Deployments: []DeploymentStemcell{},
+		Stemcells:   []StemcellUsage{},
+		UpgradePlan: []UpgradeStep{},
+	}
+
+	for _, d := range deployments {
+		for _, sc := range d.Stemcells {
+			behind := 0
+			for _, v := range versions[sc.Name] {
+				if compareVersions(v, sc.Version) > 0 {
+					behind++
+				}
+			}
+			result.Deployments = append(result.Deployments, DeploymentStemcell{
+				Deployment:     d.Name,
+				Name:           sc.Name,
+				OS:             osByName[sc.Name],
+				Version:        sc.Version,
+				Latest:         latest[sc.Name],
+				VersionsBehind: behind,
+			})
+		}
+	}
+
+	for _, sc := range stemcells {
+		inUse := sc.Deployments
+		if inUse == nil {
+			inUse = []string{}
+		}
+		result.Stemcells = append(result.Stemcells, StemcellUsage{
+			Name:        sc.Name,
+			OS:          sc.OperatingSystem,
+			Version:     sc.Version,
+			Latest:      sc.Version == latest[sc.Name],
+			Deployments: inUse,
+			Deletable:   len(inUse) == 0 && sc.Version != latest[sc.Name],
+		})
+	}
+	sort.SliceStable(result.Stemcells, func(i, j int) bool {
+		a, b := result.Stemcells[i], result.Stemcells[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return compareVersions(a.Version, b.Version) > 0
+	})
+
+	// Upgrade the most outdated deployments first
+	behind := filterSlice(result.Deployments, func(d DeploymentStemcell) bool { return d.VersionsBehind > 0 })
+	sort.SliceStable(behind, func(i, j int) bool {
+		if behind[i].VersionsBehind != behind[j].VersionsBehind {
+			return behind[i].VersionsBehind > behind[j].VersionsBehind
+		}
+		return behind[i].Deployment < behind[j].Deployment
+	})
+	for i, d := range behind {
+		result.UpgradePlan = append(result.UpgradePlan, UpgradeStep{
+			Order:          i + 1,
+			Deployment:     d.Deployment,
+			Stemcell:       d.Name,
+			From:           d.Version,
+			To:             d.Latest,
+			VersionsBehind: d.VersionsBehind,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+// ABOUTME: Tests for the stemcell drift and upgrade-planning report.
+// ABOUTME: Verifies versions-behind counts, deletion impact, and plan ordering.
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+)
+
+func TestStemcellReport(t *testing.T) {
+	const jammy = "bosh-aws-xen-hvm-ubuntu-jammy-go_agent"
+	stemcells := []bosh.Stemcell{
+		{Name: jammy, OperatingSystem: "ubuntu-jammy", Version: "1.300"},
+		{Name: jammy, OperatingSystem: "ubuntu-jammy", Version: "1.100", Deployments: []string{"cf"}},
+		{Name: jammy, OperatingSystem: "ubuntu-jammy", Version: "1.200", Deployments: []string{"redis", "mysql"}},
+		{Name: jammy, OperatingSystem: "ubuntu-jammy", Version: "1.50"},
+	}
+	deployments := []bosh.Deployment{
+		{Name: "redis", Stemcells: []bosh.NameVersion{{Name: jammy, Version: "1.200"}}},
+		{Name: "cf", Stemcells: []bosh.NameVersion{{Name: jammy, Version: "1.100"}}},
+		{Name: "mysql", Stemcells: []bosh.NameVersion{{Name: jammy, Version: "1.200"}}},
+	}
+
+	report := stemcellReport(stemcells, deployments)
+
+	if len(report.Deployments) != 3 {
+		t.Fatalf("expected 3 deployment stemcells, got %d", len(report.Deployments))
+	}
+	cf := report.Deployments[1]
+	if cf.Deployment != "cf" || cf.OS != "ubuntu-jammy" || cf.Latest != "1.300" || cf.VersionsBehind != 2 {
+		t.Errorf("unexpected cf stemcell: %+v", cf)
+	}
+
+	versions := []string{}
+	for _, sc := range report.Stemcells {
+		versions = append(versions, sc.Version)
+	}
+	if len(versions) != 4 || versions[0] != "1.300" || versions[3] != "1.50" {
+		t.Errorf("expected stemcells newest first, got %v", versions)
+	}
+	if !report.Stemcells[0].Latest || report.Stemcells[0].Deletable {
+		t.Errorf("expected newest stemcell to be latest and kept: %+v", report.Stemcells[0])
+	}
+	if report.Stemcells[1].Deletable || len(report.Stemcells[1].Deployments) != 2 {
+		t.Errorf("expected 1.200 to be in use by two deployments: %+v", report.Stemcells[1])
+	}
+	if !report.Stemcells[3].Deletable {
+		t.Errorf("expected unused 1.50 to be deletable: %+v", report.Stemcells[3])
+	}
+
+	plan := report.UpgradePlan
+	if len(plan) != 3 {
+		t.Fatalf("expected 3 upgrade steps, got %d", len(plan))
+	}
+	if plan[0].Deployment != "cf" || plan[1].Deployment != "mysql" || plan[2].Deployment != "redis" {
+		t.Errorf("expected most outdated first then by name, got %+v", plan)
+	}
+	if plan[0].From != "1.100" || plan[0].To != "1.300" || plan[0].Order != 1 {
+		t.Errorf("unexpected first step: %+v", plan[0])
+	}
+}
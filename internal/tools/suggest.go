@@ -0,0 +1,172 @@
+// ABOUTME: Suggests similar deployment names when a tool is called with one that doesn't exist.
+// ABOUTME: Backs suggestions and argument completions with a short-lived lookup cache.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lookupCacheTTL is how long director lookups are reused for suggestions and completions.
+const lookupCacheTTL = 30 * time.Second
+
+// lookupCache caches name lookups keyed by kind and environment.
+type lookupCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*lookupEntry
+}
+
+type lookupEntry struct {
+	values    []string
+	expiresAt time.Time
+}
+
+func newLookupCache(ttl time.Duration) *lookupCache {
+	return &lookupCache{
+		ttl:     ttl,
+		entries: make(map[string]*lookupEntry),
+	}
+}
+
+// get returns cached values for key, calling fetch when missing or expired.
+func (c *lookupCache) get(key string, fetch func() ([]string, error)) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.values, nil
+	}
+	c.mu.Unlock()
+
+	values, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &lookupEntry{
+		values:    values,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	return values, nil
+}
+
+// invalidate removes all cached entries whose key starts with prefix.
+func (c *lookupCache) invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// deploymentNames returns the deployment names in an environment.
+func (r *Registry) deploymentNames(ctx context.Context, environment string) ([]string, error) {
+	return r.lookups.get("deployments|"+environment, func() ([]string, error) {
+		client, err := r.GetClient(ctx, environment)
+		if err != nil {
+			return nil, err
+		}
+		deployments, err := client.ListDeployments()
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(deployments))
+		for _, d := range deployments {
+			names = append(names, d.Name)
+		}
+		sort.Strings(names)
+		return names, nil
+	})
+}
+
+// suggestDeployments returns up to three deployment names similar to name.
+func (r *Registry) suggestDeployments(ctx context.Context, environment, name string) []string {
+	names, err := r.deploymentNames(ctx, environment)
+	if err != nil {
+		return nil
+	}
+	return suggestNames(names, name, 3)
+}
+
+// suggestNames ranks candidates by edit distance to name, keeping close matches.
+func suggestNames(candidates []string, name string, limit int) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	target := strings.ToLower(name)
+	threshold := len(target) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	var matches []scored
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		distance := levenshtein(target, lower)
+		if distance <= threshold || (target != "" && (strings.Contains(lower, target) || strings.Contains(target, lower))) {
+			matches = append(matches, scored{name: candidate, distance: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// deploymentError builds an error result for a failed deployment-scoped call.
+// When the deployment doesn't exist, similar deployment names are suggested.
+func (r *Registry) deploymentError(ctx context.Context, environment, deployment, action string, err error) *mcp.CallToolResult {
+	msg := fmt.Sprintf("failed to %s: %v", action, err)
+	if bosh.IsNotFound(err) {
+		if suggestions := r.suggestDeployments(ctx, environment, deployment); len(suggestions) > 0 {
+			msg += fmt.Sprintf(" (deployment '%s' not found; did you mean: %s?)", deployment, strings.Join(suggestions, ", "))
+		}
+	}
+	return mcp.NewToolResultError(msg)
+}
@@ -0,0 +1,103 @@
+// ABOUTME: Tests for "did you mean" deployment suggestions.
+// ABOUTME: Uses httptest to mock the BOSH Director.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSuggestDeployments_Cached(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/deployments" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]bosh.Deployment{
+			{Name: "cf"}, {Name: "redis"}, {Name: "cf-mysql"},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	if suggestions := registry.suggestDeployments(context.Background(), "", "cf-mysq"); len(suggestions) == 0 || suggestions[0] != "cf-mysql" {
+		t.Errorf("expected cf-mysql first, got %v", suggestions)
+	}
+	if suggestions := registry.suggestDeployments(context.Background(), "", "rediss"); len(suggestions) != 1 || suggestions[0] != "redis" {
+		t.Errorf("expected [redis], got %v", suggestions)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected deployments to be fetched once, got %d calls", calls)
+	}
+}
+
+func TestSuggestNames(t *testing.T) {
+	names := []string{"cf", "cf-mysql", "redis", "rabbitmq"}
+
+	suggestions := suggestNames(names, "rediss", 3)
+	if len(suggestions) == 0 || suggestions[0] != "redis" {
+		t.Errorf("expected redis first, got %v", suggestions)
+	}
+
+	suggestions = suggestNames(names, "postgres", 3)
+	if len(suggestions) != 0 {
+		t.Errorf("expected no suggestions, got %v", suggestions)
+	}
+}
+
+func TestHandleBoshVMs_DeploymentNotFoundSuggests(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deployments":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]bosh.Deployment{{Name: "cf"}, {Name: "redis"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":70000,"description":"Deployment 'rediss' doesn't exist"}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"deployment": "rediss",
+	}
+
+	result, err := registry.handleBoshVMs(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error for missing deployment")
+	}
+
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "did you mean: redis?") {
+		t.Errorf("expected suggestion for redis, got: %s", text)
+	}
+}
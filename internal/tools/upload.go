@@ -0,0 +1,140 @@
+// ABOUTME: Implements bosh_upload_release and bosh_upload_stemcell.
+// ABOUTME: Uploads from a URL the Director fetches, or streams a confirmed local file, and waits for the task.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultUploadTimeout is longer than other operations since the Director
+// may be downloading and compiling a large tarball.
+const defaultUploadTimeout = 1800
+
+func (r *DeploymentRegistry) handleBoshUploadRelease(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return r.upload(ctx, request, "release")
+}
+
+func (r *DeploymentRegistry) handleBoshUploadStemcell(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return r.upload(ctx, request, "stemcell")
+}
+
+// upload uploads a release or stemcell from url or path and waits for the task.
+func (r *DeploymentRegistry) upload(ctx context.Context, request mcp.CallToolRequest, kind string) (*mcp.CallToolResult, error) {
+	location := request.GetString("url", "")
+	path := request.GetString("path", "")
+	environment := request.GetString("environment", "")
+	confirmToken := request.GetString("confirm", "")
+	opts := bosh.UploadOptions{
+		SHA1:   request.GetString("sha1", ""),
+		Fix:    request.GetBool("fix", false),
+		Rebase: request.GetBool("rebase", false),
+	}
+
+	if (location == "") == (path == "") {
+		return mcp.NewToolResultError("specify exactly one of url or path"), nil
+	}
+
+	operation := "upload_" + kind
+	if r.config.IsBlocked(operation) {
+		return mcp.NewToolResultError(operation + " is blocked by configuration"), nil
+	}
+
+	source := location
+	var info os.FileInfo
+	if path != "" {
+		source = path
+		var err error
+		info, err = os.Stat(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read %s: %v", path, err)), nil
+		}
+		if !info.Mode().IsRegular() {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is not a file", path)), nil
+		}
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+	client, err := bosh.NewClient(creds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	// A local path can name any file the server can read, so sending it
+	// off the host needs the user's confirmation. The token is bound to
+	// the Director, the file as it is now, and the upload options.
+	if path != "" && r.config.RequiresConfirmation("upload_file") {
+		resource := uploadFileResource(creds.Environment, kind, path, info, opts)
+		if confirmToken == "" {
+			token := r.tokenStore.Generate("upload_file", resource)
+			return toolResult(UploadResult{
+				RequiresConfirmation: true,
+				ConfirmationToken:    token,
+				Type:                 kind,
+				Source:               path,
+				ExpiresInSeconds:     r.config.TokenTTL,
+				Message:              fmt.Sprintf("STOP: Ask the user to confirm they want to upload the local file %s (%d bytes) from the server's host as a %s to %s. Only proceed with the confirm token if the user explicitly approves.", path, info.Size(), kind, resolvedEnvironment(creds)),
+			})
+		}
+
+		if !r.tokenStore.Validate(confirmToken, "upload_file", resource) {
+			return mcp.NewToolResultError("invalid or expired confirmation token, or the file, options, or environment changed since it was confirmed; request a new token"), nil
+		}
+	}
+
+	var taskID int
+	switch {
+	case kind == "release" && location != "":
+		taskID, err = client.UploadReleaseFromURL(location, opts)
+	case kind == "release":
+		taskID, err = client.UploadReleaseFile(path, opts)
+	case location != "":
+		taskID, err = client.UploadStemcellFromURL(location, opts)
+	default:
+		taskID, err = client.UploadStemcellFile(path, opts)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to upload %s: %v", kind, err)), nil
+	}
+
+	timeout := time.Duration(request.GetInt("timeout", defaultUploadTimeout)) * time.Second
+	task, err := client.WaitForTask(taskID, timeout, 2*time.Second)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
+	}
+
+	result := UploadResult{
+		Type:   kind,
+		Source: source,
+		TaskID: task.ID,
+		State:  task.State,
+	}
+	if task.State == "done" || task.State == "error" {
+		output, err := client.GetTaskOutput(task.ID, "result")
+		if err == nil && output != "" {
+			result.Output = output
+		}
+	}
+
+	return toolResult(result)
+}
+
+// uploadFileResource identifies a local file upload for its confirmation
+// token: the Director, the kind, the file's absolute path, size and
+// modification time, and the options.
+func uploadFileResource(director, kind, path string, info os.FileInfo, opts bosh.UploadOptions) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return fmt.Sprintf("%s#%s:%s@%d:%d?sha1=%s&fix=%t&rebase=%t", director, kind, path, info.Size(), info.ModTime().UnixNano(), opts.SHA1, opts.Fix, opts.Rebase)
+}
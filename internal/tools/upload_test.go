@@ -0,0 +1,142 @@
+// ABOUTME: Tests for release and stemcell upload tools.
+// ABOUTME: Streams a local tarball to a fake director and waits for the upload task.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestHandleBoshUploadStemcell_LocalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stemcell.tgz")
+	if err := os.WriteFile(path, []byte("stemcell-bytes"), 0644); err != nil {
+		t.Fatalf("failed to write stemcell: %v", err)
+	}
+
+	var uploaded string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/stemcells":
+			file, _, err := r.FormFile("stemcell")
+			if err != nil {
+				t.Errorf("expected stemcell form file: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			uploaded = string(data)
+			w.Header().Set("Location", "/tasks/31")
+			w.WriteHeader(http.StatusFound)
+		case r.URL.Path == "/tasks/31":
+			json.NewEncoder(w).Encode(bosh.Task{ID: 31, State: "done"})
+		case r.URL.Path == "/tasks/31/output":
+			w.Write([]byte("Stemcell uploaded"))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), config.Load(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"path": path}
+
+	// Nothing leaves the host until the user confirms.
+	result, _ := deploymentRegistry.handleBoshUploadStemcell(context.Background(), request)
+	confirmation := result.StructuredContent.(UploadResult)
+	if !confirmation.RequiresConfirmation || confirmation.ConfirmationToken == "" || !strings.Contains(confirmation.Message, path) {
+		t.Fatalf("expected confirmation naming the file, got %+v", confirmation)
+	}
+	if uploaded != "" {
+		t.Fatal("file must not be uploaded before confirmation")
+	}
+
+	// A token for a plain upload can't be used with fix.
+	request.Params.Arguments = map[string]interface{}{"path": path, "fix": true, "confirm": confirmation.ConfirmationToken}
+	result, _ = deploymentRegistry.handleBoshUploadStemcell(context.Background(), request)
+	if !result.IsError || uploaded != "" {
+		t.Fatalf("expected token to be rejected with other options, got %v", result.Content)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"path": path}
+	result, _ = deploymentRegistry.handleBoshUploadStemcell(context.Background(), request)
+	token := result.StructuredContent.(UploadResult).ConfirmationToken
+
+	// A file changed after confirmation needs a new token.
+	if err := os.WriteFile(path, []byte("other-stemcell-bytes"), 0644); err != nil {
+		t.Fatalf("failed to rewrite stemcell: %v", err)
+	}
+	request.Params.Arguments = map[string]interface{}{"path": path, "confirm": token}
+	result, _ = deploymentRegistry.handleBoshUploadStemcell(context.Background(), request)
+	if !result.IsError || uploaded != "" {
+		t.Fatalf("expected token to be rejected after the file changed, got %v", result.Content)
+	}
+
+	request.Params.Arguments = map[string]interface{}{"path": path}
+	result, _ = deploymentRegistry.handleBoshUploadStemcell(context.Background(), request)
+	token = result.StructuredContent.(UploadResult).ConfirmationToken
+
+	request.Params.Arguments = map[string]interface{}{"path": path, "confirm": token}
+	result, _ = deploymentRegistry.handleBoshUploadStemcell(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("unexpected error: %v", result.Content)
+	}
+	if uploaded != "other-stemcell-bytes" {
+		t.Errorf("expected stemcell to be streamed, got %q", uploaded)
+	}
+	upload := result.StructuredContent.(UploadResult)
+	if upload.Type != "stemcell" || upload.Source != path || upload.TaskID != 31 || upload.Output != "Stemcell uploaded" {
+		t.Errorf("unexpected result: %+v", upload)
+	}
+}
+
+func TestHandleBoshUploadRelease_Validation(t *testing.T) {
+	cfg := config.Load("")
+	cfg.BlockedOperations = []string{"upload_stemcell"}
+	deploymentRegistry := NewDeploymentRegistry(NewRegistry(auth.NewProvider("")), cfg)
+
+	tests := []struct {
+		name    string
+		handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args    map[string]interface{}
+		want    string
+	}{
+		{"neither", deploymentRegistry.handleBoshUploadRelease, map[string]interface{}{}, "exactly one of url or path"},
+		{"both", deploymentRegistry.handleBoshUploadRelease, map[string]interface{}{"url": "https://example.com/r.tgz", "path": "/tmp/r.tgz"}, "exactly one of url or path"},
+		{"missing file", deploymentRegistry.handleBoshUploadRelease, map[string]interface{}{"path": filepath.Join(t.TempDir(), "missing.tgz")}, "cannot read"},
+		{"directory", deploymentRegistry.handleBoshUploadRelease, map[string]interface{}{"path": t.TempDir()}, "is not a file"},
+		{"blocked", deploymentRegistry.handleBoshUploadStemcell, map[string]interface{}{"url": "https://example.com/s.tgz"}, "blocked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.args
+
+			result, _ := tt.handler(context.Background(), request)
+			if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, result.Content)
+			}
+		})
+	}
+}
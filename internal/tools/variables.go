@@ -0,0 +1,216 @@
+// ABOUTME: Implements bosh_variables, optionally with each variable's CredHub metadata.
+// ABOUTME: Metadata includes type, version date, and certificate details, never secret values.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/malston/bosh-mcp-server/internal/credhub"
+	"github.com/malston/bosh-mcp-server/internal/uaa"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// credhubConcurrency bounds how many variables are looked up in CredHub at once.
+const credhubConcurrency = 8
+
+// VariableDetails is a deployment variable, with its CredHub metadata when
+// requested. Error is set if the metadata could not be read.
+type VariableDetails struct {
+	ID               string                       `json:"id"`
+	Name             string                       `json:"name"`
+	Type             string                       `json:"type,omitempty"`
+	VersionCreatedAt string                       `json:"version_created_at,omitempty"`
+	Certificate      *credhub.CertificateMetadata `json:"certificate,omitempty"`
+	Error            string                       `json:"error,omitempty"`
+}
+
+func (r *Registry) handleBoshVariables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deployment := request.GetString("deployment", "")
+	environment := request.GetString("environment", "")
+	variableType := request.GetString("type", "")
+	withMetadata := request.GetBool("metadata", false) || variableType != ""
+
+	if deployment == "" {
+		return mcp.NewToolResultError("deployment is required"), nil
+	}
+
+	opts, err := parseListOptions(request, reflect.TypeOf(VariableDetails{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	variables, err := client.ListVariables(deployment)
+	if err != nil {
+		return r.deploymentError(ctx, environment, deployment, "list variables", err), nil
+	}
+
+	details := make([]VariableDetails, len(variables))
+	for i, v := range variables {
+		details[i] = VariableDetails{ID: v.ID, Name: v.Name}
+	}
+
+	if withMetadata {
+		credhubClient, err := r.credhubClient(ctx, environment, client)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		addCredHubMetadata(credhubClient, details)
+	}
+
+	if variableType != "" {
+		details = filterSlice(details, func(v VariableDetails) bool { return v.Type == variableType })
+	}
+
+	sortItems(details, opts.Sort)
+	details, page := paginate(details, opts)
+
+	result := VariablesResult{
+		Deployment: deployment,
+		Variables:  details,
+		Page:       page,
+	}
+
+	return listResult(result, "variables", opts, page)
+}
+
+// credhubClientCache keeps one CredHub client per Director, so its UAA
+// token is reused across calls instead of fetched for each one.
+type credhubClientCache struct {
+	mu      sync.Mutex
+	entries map[string]*credhubClientEntry
+}
+
+// credhubClientEntry is a cached client and what it was built from. The
+// client is rebuilt if any of it changes, e.g. after a secret is rotated.
+type credhubClientEntry struct {
+	key    credhubClientKey
+	client *credhub.Client
+}
+
+type credhubClientKey struct {
+	credhubURL        string
+	uaaURL            string
+	client            string
+	clientSecret      string
+	caCert            string
+	allProxy          string
+	skipSSLValidation bool
+	session           *auth.Session
+}
+
+func newCredhubClientCache() *credhubClientCache {
+	return &credhubClientCache{entries: make(map[string]*credhubClientEntry)}
+}
+
+// get returns the cached client for director if it was built from key,
+// calling build otherwise.
+func (c *credhubClientCache) get(director string, key credhubClientKey, build func() (*credhub.Client, error)) (*credhub.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[director]; ok && entry.key == key {
+		return entry.client, nil
+	}
+	client, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.entries[director] = &credhubClientEntry{key: key, client: client}
+	return client, nil
+}
+
+// credhubClient returns a CredHub client for the environment's config
+// server, authenticating through the Director's UAA with the environment's
+// BOSH client credentials or bosh log-in session. Clients are cached per
+// Director.
+func (r *Registry) credhubClient(ctx context.Context, environment string, client *bosh.Client) (*credhub.Client, error) {
+	info, err := client.GetInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get director info: %v", err)
+	}
+	if !info.FeatureEnabled("config_server") {
+		return nil, fmt.Errorf("the Director does not have a config server (CredHub) enabled")
+	}
+	credhubURL := configServerURL(info)
+	if credhubURL == "" {
+		return nil, fmt.Errorf("the Director does not advertise a config server URL")
+	}
+	if info.UserAuthentication.Type != "uaa" || info.UserAuthentication.Options.URL == "" {
+		return nil, fmt.Errorf("CredHub requires UAA authentication, but the Director uses %q", info.UserAuthentication.Type)
+	}
+
+	creds, err := r.credentials(ctx, environment)
+	if err != nil {
+		return nil, fmt.Errorf("auth failed: %v", err)
+	}
+	uaaURL := info.UserAuthentication.Options.URL
+	key := credhubClientKey{
+		credhubURL:        credhubURL,
+		uaaURL:            uaaURL,
+		client:            creds.Client,
+		clientSecret:      creds.ClientSecret,
+		caCert:            creds.CACert,
+		allProxy:          creds.AllProxy,
+		skipSSLValidation: creds.SkipSSLValidation,
+		session:           creds.Session,
+	}
+	return r.credhubs.get(creds.Environment, key, func() (*credhub.Client, error) {
+		transport, err := auth.NewTransport(creds.CACert, creds.AllProxy, creds.SkipSSLValidation)
+		if err != nil {
+			return nil, err
+		}
+
+		var tokens credhub.TokenSource = uaa.NewClient(uaaURL, creds.Client, creds.ClientSecret, transport)
+		if creds.Session != nil {
+			tokens = creds.Session.TokenSource(uaaURL, transport)
+		}
+		return credhub.NewClient(credhubURL, transport, tokens), nil
+	})
+}
+
+// configServerURL returns the first config server URL in the Director's info.
+func configServerURL(info *bosh.Info) string {
+	urls, _ := info.Features["config_server"].Extras["urls"].([]any)
+	for _, u := range urls {
+		if s, ok := u.(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// addCredHubMetadata looks up each variable's version in CredHub. Lookups
+// that fail are recorded on the variable rather than failing the call.
+func addCredHubMetadata(client *credhub.Client, variables []VariableDetails) {
+	sem := make(chan struct{}, credhubConcurrency)
+	var wg sync.WaitGroup
+	for i := range variables {
+		wg.Add(1)
+		go func(v *VariableDetails) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			meta, err := client.GetMetadataByID(v.ID)
+			if err != nil {
+				v.Error = err.Error()
+				return
+			}
+			v.Type = meta.Type
+			v.VersionCreatedAt = meta.VersionCreatedAt
+			v.Certificate = meta.Certificate
+		}(&variables[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+// ABOUTME: Compares BOSH release and stemcell version strings.
+// ABOUTME: Orders dotted versions segment by segment, numerically where possible.
+
+package tools
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions returns -1, 0, or 1 as version a is older than, equal to,
+// or newer than b. Segments separated by ".", "-" or "+" compare numerically
+// when both are numbers (so 1.10 > 1.9) and as strings otherwise. A version
+// with extra segments is newer (1.0.1 > 1.0).
+func compareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareSegment(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// latestVersion returns the newest of versions, or "" if there are none.
+func latestVersion(versions []string) string {
+	latest := ""
+	for _, v := range versions {
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+func versionSegments(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == '.' || r == '-' || r == '+'
+	})
+}
+
+func compareSegment(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,37 @@
+// ABOUTME: Tests for version string comparison.
+// ABOUTME: Covers numeric segments, differing lengths, and non-numeric parts.
+
+package tools
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"1.0", "1.0", 0},
+		{"1.0.1", "1.0", 1},
+		{"621.5", "1.200", 1},
+		{"2.0.0-rc.1", "2.0.0-rc.2", -1},
+		{"1.0+dev.3", "1.0+dev.2", 1},
+		{"abc", "abd", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	if got := latestVersion([]string{"1.9", "1.10", "1.2"}); got != "1.10" {
+		t.Errorf("expected 1.10, got %s", got)
+	}
+	if got := latestVersion(nil); got != "" {
+		t.Errorf("expected empty, got %s", got)
+	}
+}
@@ -0,0 +1,188 @@
+// ABOUTME: Implements the bosh_vitals tool that ranks instances by resource pressure.
+// ABOUTME: Flags instances whose memory, disk, or load exceed configurable thresholds.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultMemoryThreshold = 85
+	defaultDiskThreshold   = 80
+)
+
+// VitalsThresholds are the limits above which an instance is flagged.
+// A zero load threshold disables the load check.
+type VitalsThresholds struct {
+	MemoryPercent float64 `json:"memory_percent"`
+	DiskPercent   float64 `json:"disk_percent"`
+	Load          float64 `json:"load"`
+}
+
+// InstanceVitals is the resource usage of one instance, flattened from its VM vitals.
+type InstanceVitals struct {
+	Instance              string   `json:"instance"`
+	AZ                    string   `json:"az"`
+	ProcessState          string   `json:"process_state"`
+	Pressure              float64  `json:"pressure"`
+	CPUPercent            float64  `json:"cpu_percent"`
+	MemoryPercent         float64  `json:"memory_percent"`
+	SwapPercent           float64  `json:"swap_percent"`
+	Load1                 float64  `json:"load_1m"`
+	Load5                 float64  `json:"load_5m"`
+	Load15                float64  `json:"load_15m"`
+	SystemDiskPercent     float64  `json:"system_disk_percent"`
+	EphemeralDiskPercent  float64  `json:"ephemeral_disk_percent"`
+	PersistentDiskPercent float64  `json:"persistent_disk_percent"`
+	Alerts                []string `json:"alerts,omitempty"`
+}
+
+func (r *Registry) handleBoshVitals(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deployment := request.GetString("deployment", "")
+	environment := request.GetString("environment", "")
+
+	if deployment == "" {
+		return mcp.NewToolResultError("deployment is required"), nil
+	}
+
+	thresholds := VitalsThresholds{
+		MemoryPercent: request.GetFloat("memory_threshold", defaultMemoryThreshold),
+		DiskPercent:   request.GetFloat("disk_threshold", defaultDiskThreshold),
+		Load:          request.GetFloat("load_threshold", 0),
+	}
+	flaggedOnly := request.GetBool("flagged_only", false)
+
+	opts, err := parseListOptions(request, reflect.TypeOf(InstanceVitals{}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	filter := parseInstanceFilter(request)
+
+	client, err := r.GetClient(ctx, environment)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("auth failed: %v", err)), nil
+	}
+
+	vms, err := client.ListVMsWithVitals(ctx, deployment)
+	if err != nil {
+		return r.deploymentError(ctx, environment, deployment, "list VM vitals", err), nil
+	}
+
+	vms = filterSlice(vms, filter.matchVM)
+	vitals := make([]InstanceVitals, 0, len(vms))
+	flagged := 0
+	for _, vm := range vms {
+		v := instanceVitals(vm, thresholds)
+		if len(v.Alerts) > 0 {
+			flagged++
+		} else if flaggedOnly {
+			continue
+		}
+		vitals = append(vitals, v)
+	}
+
+	if opts.Sort == "" {
+		rankByPressure(vitals)
+	} else {
+		sortItems(vitals, opts.Sort)
+	}
+	vitals, page := paginate(vitals, opts)
+
+	result := VitalsResult{
+		Deployment: deployment,
+		Thresholds: thresholds,
+		Flagged:    flagged,
+		Vitals:     vitals,
+		Page:       page,
+	}
+
+	return listResult(result, "vitals", opts, page)
+}
+
+// instanceVitals flattens a VM's vitals and checks them against thresholds.
+func instanceVitals(vm bosh.VM, thresholds VitalsThresholds) InstanceVitals {
+	v := InstanceVitals{
+		Instance:     instanceName(vm.Job, vm.ID, vm.Index),
+		AZ:           vm.AZ,
+		ProcessState: vm.ProcessState,
+	}
+	if vm.Vitals == nil {
+		v.Alerts = []string{"no vitals reported"}
+		return v
+	}
+
+	if cpu := vm.Vitals.CPU; cpu != nil {
+		v.CPUPercent = parseVital(cpu.User) + parseVital(cpu.Sys) + parseVital(cpu.Wait)
+	}
+	if mem := vm.Vitals.Mem; mem != nil {
+		v.MemoryPercent = parseVital(mem.Percent)
+	}
+	if swap := vm.Vitals.Swap; swap != nil {
+		v.SwapPercent = parseVital(swap.Percent)
+	}
+	loads := []*float64{&v.Load1, &v.Load5, &v.Load15}
+	for i, load := range vm.Vitals.Load {
+		if i < len(loads) {
+			*loads[i] = parseVital(load)
+		}
+	}
+	if disk := vm.Vitals.Disk; disk != nil {
+		v.SystemDiskPercent = diskPercent(disk.System)
+		v.EphemeralDiskPercent = diskPercent(disk.Ephemeral)
+		v.PersistentDiskPercent = diskPercent(disk.Persistent)
+	}
+
+	v.Pressure = max(v.MemoryPercent, v.SystemDiskPercent, v.EphemeralDiskPercent, v.PersistentDiskPercent)
+
+	check := func(name string, value, limit float64, unit string) {
+		if limit > 0 && value >= limit {
+			v.Alerts = append(v.Alerts, fmt.Sprintf("%s %s%s >= %s%s", name, formatVital(value), unit, formatVital(limit), unit))
+		}
+	}
+	check("memory", v.MemoryPercent, thresholds.MemoryPercent, "%")
+	check("system disk", v.SystemDiskPercent, thresholds.DiskPercent, "%")
+	check("ephemeral disk", v.EphemeralDiskPercent, thresholds.DiskPercent, "%")
+	check("persistent disk", v.PersistentDiskPercent, thresholds.DiskPercent, "%")
+	check("load", v.Load1, thresholds.Load, "")
+
+	return v
+}
+
+// rankByPressure sorts flagged instances first, then by highest memory or disk usage.
+func rankByPressure(vitals []InstanceVitals) {
+	sort.SliceStable(vitals, func(i, j int) bool {
+		if len(vitals[i].Alerts) != len(vitals[j].Alerts) {
+			return len(vitals[i].Alerts) > len(vitals[j].Alerts)
+		}
+		if vitals[i].Pressure != vitals[j].Pressure {
+			return vitals[i].Pressure > vitals[j].Pressure
+		}
+		return vitals[i].Load1 > vitals[j].Load1
+	})
+}
+
+// diskPercent returns the higher of a disk's space and inode usage, since
+// running out of either fills the disk.
+func diskPercent(disk *bosh.VitalsDisk) float64 {
+	if disk == nil {
+		return 0
+	}
+	return max(parseVital(disk.Percent), parseVital(disk.InodePercent))
+}
+
+func parseVital(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func formatVital(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
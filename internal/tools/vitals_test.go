@@ -0,0 +1,120 @@
+// ABOUTME: Tests for the bosh_vitals resource-pressure report.
+// ABOUTME: Verifies vitals parsing, threshold alerts, and ranking.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/malston/bosh-mcp-server/internal/auth"
+	"github.com/malston/bosh-mcp-server/internal/bosh"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func vmWithVitals(job, id, mem, ephemeral, persistent string, load string) bosh.VM {
+	disks := &bosh.VitalsDisks{
+		System:    &bosh.VitalsDisk{Percent: "30", InodePercent: "5"},
+		Ephemeral: &bosh.VitalsDisk{Percent: ephemeral, InodePercent: "1"},
+	}
+	if persistent != "" {
+		disks.Persistent = &bosh.VitalsDisk{Percent: persistent, InodePercent: "1"}
+	}
+	return bosh.VM{
+		Job: job, ID: id, AZ: "z1", ProcessState: "running",
+		Vitals: &bosh.Vitals{
+			CPU:  &bosh.VitalsCPU{User: "10.5", Sys: "2", Wait: "0.5"},
+			Mem:  &bosh.VitalsMemory{Percent: mem},
+			Swap: &bosh.VitalsMemory{Percent: "0"},
+			Load: []string{load, "0.5", "0.25"},
+			Disk: disks,
+		},
+	}
+}
+
+func TestInstanceVitals_Thresholds(t *testing.T) {
+	thresholds := VitalsThresholds{MemoryPercent: 85, DiskPercent: 80, Load: 4}
+
+	v := instanceVitals(vmWithVitals("mysql", "m1", "90", "20", "95", "6.5"), thresholds)
+	if v.Instance != "mysql/m1" || v.CPUPercent != 13 || v.Load1 != 6.5 || v.PersistentDiskPercent != 95 {
+		t.Errorf("unexpected vitals: %+v", v)
+	}
+	if v.Pressure != 95 {
+		t.Errorf("expected pressure 95, got %v", v.Pressure)
+	}
+	expected := []string{"memory 90% >= 85%", "persistent disk 95% >= 80%", "load 6.5 >= 4"}
+	if len(v.Alerts) != len(expected) {
+		t.Fatalf("expected alerts %v, got %v", expected, v.Alerts)
+	}
+	for i := range expected {
+		if v.Alerts[i] != expected[i] {
+			t.Errorf("expected alert %q, got %q", expected[i], v.Alerts[i])
+		}
+	}
+
+	quiet := instanceVitals(vmWithVitals("router", "r1", "40", "20", "", "6.5"), VitalsThresholds{MemoryPercent: 85, DiskPercent: 80})
+	if len(quiet.Alerts) != 0 {
+		t.Errorf("expected no alerts with load check disabled, got %v", quiet.Alerts)
+	}
+
+	missing := instanceVitals(bosh.VM{Job: "router", ID: "r2"}, thresholds)
+	if len(missing.Alerts) != 1 || missing.Alerts[0] != "no vitals reported" {
+		t.Errorf("expected missing vitals alert, got %v", missing.Alerts)
+	}
+}
+
+func TestHandleBoshVitals_RanksByPressure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != "full" {
+			t.Errorf("expected format=full, got %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]bosh.VM{
+			vmWithVitals("router", "r1", "40", "20", "", "0.1"),
+			vmWithVitals("cell", "c1", "70", "92", "", "0.1"),
+			vmWithVitals("api", "a1", "60", "20", "", "0.1"),
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("BOSH_ENVIRONMENT", server.URL)
+	t.Setenv("BOSH_CA_CERT", testCA(server))
+	t.Setenv("BOSH_CLIENT", "admin")
+	t.Setenv("BOSH_CLIENT_SECRET", "secret")
+
+	registry := NewRegistry(auth.NewProvider(""))
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"deployment": "cf",
+	}
+
+	result, err := registry.handleBoshVitals(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %v", result.Content)
+	}
+
+	vitals := result.StructuredContent.(VitalsResult)
+	if vitals.Flagged != 1 {
+		t.Errorf("expected 1 flagged instance, got %d", vitals.Flagged)
+	}
+	order := []string{vitals.Vitals[0].Instance, vitals.Vitals[1].Instance, vitals.Vitals[2].Instance}
+	if order[0] != "cell/c1" || order[1] != "api/a1" || order[2] != "router/r1" {
+		t.Errorf("unexpected ranking: %v", order)
+	}
+
+	request.Params.Arguments = map[string]interface{}{
+		"deployment":   "cf",
+		"flagged_only": true,
+	}
+	result, _ = registry.handleBoshVitals(context.Background(), request)
+	if got := result.StructuredContent.(VitalsResult).Vitals; len(got) != 1 || got[0].Instance != "cell/c1" {
+		t.Errorf("expected only cell/c1 when flagged_only, got %+v", got)
+	}
+}
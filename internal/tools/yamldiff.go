@@ -0,0 +1,124 @@
+// ABOUTME: Produces normalized diffs between YAML documents such as cloud configs.
+// ABOUTME: Flattens documents to path/value pairs so key order and list order of named items don't matter.
+
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	changeMissing = "missing"
+	changeExtra   = "extra"
+	changeChanged = "changed"
+)
+
+// YAMLChange is one differing path between a source and target YAML document.
+// Op is missing (only in source), extra (only in target), or changed.
+type YAMLChange struct {
+	Path   string `json:"path"`
+	Op     string `json:"op"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// diffYAML compares two YAML documents and returns the differing paths, sorted.
+func diffYAML(source, target string) ([]YAMLChange, error) {
+	sourceValues, err := flattenYAML(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source: %w", err)
+	}
+	targetValues, err := flattenYAML(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse target: %w", err)
+	}
+
+	changes := []YAMLChange{}
+	for path, sv := range sourceValues {
+		tv, ok := targetValues[path]
+		switch {
+		case !ok:
+			changes = append(changes, YAMLChange{Path: path, Op: changeMissing, Source: sv})
+		case sv != tv:
+			changes = append(changes, YAMLChange{Path: path, Op: changeChanged, Source: sv, Target: tv})
+		}
+	}
+	for path, tv := range targetValues {
+		if _, ok := sourceValues[path]; !ok {
+			changes = append(changes, YAMLChange{Path: path, Op: changeExtra, Target: tv})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// flattenYAML parses doc and returns a map of path to scalar value, e.g.
+// vm_types[name=small].cloud_properties.cpu = 2. Lists of maps that all have
+// a name key are keyed by name; other lists are keyed by index.
+func flattenYAML(doc string) (map[string]string, error) {
+	var value any
+	if err := yaml.Unmarshal([]byte(doc), &value); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	flattenValue("", value, values)
+	return values, nil
+}
+
+func flattenValue(path string, value any, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			values[path] = "{}"
+		}
+		for key, child := range v {
+			flattenValue(joinPath(path, key), child, values)
+		}
+	case []any:
+		if len(v) == 0 {
+			values[path] = "[]"
+		}
+		named := namedItems(v)
+		for i, child := range v {
+			if named {
+				flattenValue(fmt.Sprintf("%s[name=%s]", path, child.(map[string]any)["name"]), child, values)
+			} else {
+				flattenValue(path+"["+strconv.Itoa(i)+"]", child, values)
+			}
+		}
+	case nil:
+		values[path] = "null"
+	default:
+		values[path] = fmt.Sprint(v)
+	}
+}
+
+// namedItems reports whether every item is a map with a unique name.
+func namedItems(items []any) bool {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+		name, ok := m["name"].(string)
+		if !ok || seen[name] {
+			return false
+		}
+		seen[name] = true
+	}
+	return len(items) > 0
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
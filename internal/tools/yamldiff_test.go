@@ -0,0 +1,58 @@
+// ABOUTME: Tests for normalized YAML diffs.
+// ABOUTME: Verifies named list items are matched by name regardless of order.
+
+package tools
+
+import "testing"
+
+func TestDiffYAML(t *testing.T) {
+	source := `
+azs:
+- name: z1
+  cloud_properties: {zone: us-east-1a}
+- name: z2
+  cloud_properties: {zone: us-east-1b}
+vm_types:
+- name: small
+  cloud_properties: {cpu: 2, ram: 4096}
+networks: []
+`
+	target := `
+vm_types:
+- name: small
+  cloud_properties: {ram: 8192, cpu: 2}
+azs:
+- name: z2
+  cloud_properties: {zone: us-east-1b}
+- name: z3
+  cloud_properties: {zone: us-east-1c}
+networks: []
+`
+
+	changes, err := diffYAML(source, target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []YAMLChange{
+		{Path: "azs[name=z1].cloud_properties.zone", Op: changeMissing, Source: "us-east-1a"},
+		{Path: "azs[name=z1].name", Op: changeMissing, Source: "z1"},
+		{Path: "azs[name=z3].cloud_properties.zone", Op: changeExtra, Target: "us-east-1c"},
+		{Path: "azs[name=z3].name", Op: changeExtra, Target: "z3"},
+		{Path: "vm_types[name=small].cloud_properties.ram", Op: changeChanged, Source: "4096", Target: "8192"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, expected[i], changes[i])
+		}
+	}
+}
+
+func TestDiffYAML_InvalidYAML(t *testing.T) {
+	if _, err := diffYAML("a: [", "a: 1"); err == nil {
+		t.Error("expected parse error")
+	}
+}
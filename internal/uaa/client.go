@@ -0,0 +1,137 @@
+// ABOUTME: Fetches UAA access tokens with the client credentials, password, or refresh token grant.
+// ABOUTME: Client tokens are cached until shortly before they expire.
+
+package uaa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expiryLeeway is how long before expiry a token is refreshed, so it can't
+// expire between being handed out and being used.
+const expiryLeeway = 30 * time.Second
+
+// Client obtains access tokens from a UAA server for one client.
+type Client struct {
+	url          string
+	client       string
+	clientSecret string
+	username     string
+	password     string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewClient creates a UAA client for the server at uaaURL that uses the
+// client credentials grant.
+func NewClient(uaaURL, client, clientSecret string, transport http.RoundTripper) *Client {
+	return &Client{
+		url:          strings.TrimSuffix(uaaURL, "/"),
+		client:       client,
+		clientSecret: clientSecret,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+// NewPasswordClient creates a UAA client for the server at uaaURL that logs
+// in as a user with the password grant, e.g. an Ops Manager admin through
+// the "opsman" client, whose secret is empty.
+func NewPasswordClient(uaaURL, client, clientSecret, username, password string, transport http.RoundTripper) *Client {
+	c := NewClient(uaaURL, client, clientSecret, transport)
+	c.username = username
+	c.password = password
+	return c
+}
+
+// Token returns a bearer access token, fetching a new one when the cached
+// token is missing or about to expire.
+func (c *Client) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if c.username != "" {
+		form = url.Values{
+			"grant_type": {"password"},
+			"username":   {c.username},
+			"password":   {c.password},
+		}
+	}
+
+	token, err := requestToken(c.httpClient, c.url, c.client, c.clientSecret, form)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - expiryLeeway)
+	return c.token, nil
+}
+
+// Token is a UAA token response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Refresh exchanges a refresh token for a new access token, as the BOSH
+// CLI does for users logged in with bosh log-in (client bosh_cli, empty
+// secret). UAA may also return a new refresh token.
+func Refresh(uaaURL, client, clientSecret, refreshToken string, transport http.RoundTripper) (*Token, error) {
+	httpClient := &http.Client{Transport: transport, Timeout: 30 * time.Second}
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}}
+	return requestToken(httpClient, strings.TrimSuffix(uaaURL, "/"), client, clientSecret, form)
+}
+
+// requestToken posts a token grant to the UAA at uaaURL.
+func requestToken(httpClient *http.Client, uaaURL, client, clientSecret string, form url.Values) (*Token, error) {
+	req, err := http.NewRequest("POST", uaaURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(url.QueryEscape(client), url.QueryEscape(clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("UAA token request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("UAA returned no access token")
+	}
+	return &token, nil
+}
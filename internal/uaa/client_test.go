@@ -0,0 +1,129 @@
+// ABOUTME: Tests for the UAA token client.
+// ABOUTME: Verifies the grant request, token caching, and error handling.
+
+package uaa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Token(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != "POST" || r.URL.Path != "/oauth/token" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		client, secret, ok := r.BasicAuth()
+		if !ok || client != "director" || secret != "s3cret" {
+			t.Errorf("unexpected basic auth: %s/%s", client, secret)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			t.Errorf("unexpected grant type: %s", r.PostForm.Get("grant_type"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"abc123","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "director", "s3cret", server.Client().Transport)
+
+	for i := 0; i < 2; i++ {
+		token, err := client.Token()
+		if err != nil {
+			t.Fatalf("Token failed: %v", err)
+		}
+		if token != "abc123" {
+			t.Errorf("expected token abc123, got %s", token)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the token to be cached, got %d requests", requests)
+	}
+}
+
+func TestPasswordClient_Token(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, secret, ok := r.BasicAuth()
+		if !ok || client != "opsman" || secret != "" {
+			t.Errorf("unexpected basic auth: %s/%s", client, secret)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "password" {
+			t.Errorf("unexpected grant type: %s", r.PostForm.Get("grant_type"))
+		}
+		if r.PostForm.Get("username") != "admin" || r.PostForm.Get("password") != "p@ss" {
+			t.Errorf("unexpected user: %s/%s", r.PostForm.Get("username"), r.PostForm.Get("password"))
+		}
+		w.Write([]byte(`{"access_token":"user-token","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	client := NewPasswordClient(server.URL, "opsman", "", "admin", "p@ss", server.Client().Transport)
+
+	token, err := client.Token()
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if token != "user-token" {
+		t.Errorf("expected token user-token, got %s", token)
+	}
+}
+
+func TestClient_Token_ExpiredIsRefreshed(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"access_token":"short","expires_in":10}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "director", "s3cret", server.Client().Transport)
+
+	// A token expiring within the leeway is never reused
+	client.Token()
+	client.Token()
+	if requests != 2 {
+		t.Errorf("expected a short-lived token to be refetched, got %d requests", requests)
+	}
+}
+
+func TestClient_Token_Unauthorized(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"unauthorized","error_description":"Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "director", "wrong", server.Client().Transport)
+
+	if _, err := client.Token(); err == nil {
+		t.Fatal("expected error for bad credentials")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, secret, ok := r.BasicAuth()
+		if !ok || client != "bosh_cli" || secret != "" {
+			t.Errorf("unexpected basic auth: %s/%s", client, secret)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "refresh-1" {
+			t.Errorf("unexpected grant: %v", r.PostForm)
+		}
+		w.Write([]byte(`{"access_token":"access-2","token_type":"bearer","refresh_token":"refresh-2","expires_in":600}`))
+	}))
+	defer server.Close()
+
+	token, err := Refresh(server.URL, "bosh_cli", "", "refresh-1", server.Client().Transport)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if token.AccessToken != "access-2" || token.RefreshToken != "refresh-2" || token.TokenType != "bearer" || token.ExpiresIn != 600 {
+		t.Errorf("unexpected token: %+v", token)
+	}
+}
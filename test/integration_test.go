@@ -22,6 +22,7 @@ func TestToolRegistration(t *testing.T) {
 	)
 
 	registry.RegisterTools(s)
+	registry.RegisterPrompts(s)
 
 	// Verify server was created without panic
 	if s == nil {